@@ -5,4 +5,6 @@ go 1.23.4
 require (
 	github.com/google/go-cmp v0.6.0
 	github.com/rogpeppe/generic v0.0.0-20241220094151-a3aeb75af60f
+	go.opentelemetry.io/otel v1.34.0
+	go.opentelemetry.io/otel/trace v1.34.0
 )
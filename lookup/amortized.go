@@ -0,0 +1,51 @@
+package lookup
+
+import "time"
+
+// timeBuilds returns the fastest of a few timings of calling build
+// timingRounds times. build should return the size of what it built, which is
+// summed and returned to keep it from being optimized away.
+func timeBuilds(build func() int) (best time.Duration, built int) {
+	best = time.Duration(1<<63 - 1)
+	for range timingReps {
+		start := time.Now()
+		for range timingRounds {
+			built += build()
+		}
+		best = min(best, time.Since(start))
+	}
+	return best, built
+}
+
+// CutoffAmortized returns the smallest size at which a map of ints is cheaper
+// than a slice, when each of them is built once and then queried
+// queriesPerBuild times.
+// Building a map costs more than building a slice, so the fewer the queries the
+// larger the cutoff. The search is capped at 1<<12: a result that large means
+// the slice is always cheaper for practical sizes.
+// Like CutoffWithHitRatio, the result comes from timings and is indicative.
+func CutoffAmortized(queriesPerBuild int) int {
+	const maxSize = 1 << 12
+	queriesPerBuild = max(queriesPerBuild, 1)
+	lo, hi := 1, maxSize
+	for lo < hi {
+		size := (lo + hi) / 2
+		m, s := setupInt(size)
+		needles := hitRatioNeedles(size, 256, 1)
+		// Per query costs, in nanoseconds per lookup.
+		lookups := float64(timingRounds * len(needles))
+		tqSlice, _ := timeLookups(needles, func(n int) bool { return sliceHas(s, n) })
+		tqMap, _ := timeLookups(needles, func(n int) bool { return mapHas(m, n) })
+		tbSlice, _ := timeBuilds(func() int { return len(setupIntSlice(size)) })
+		tbMap, _ := timeBuilds(func() int { return len(setupIntMap(size)) })
+		qSlice, qMap := float64(tqSlice)/lookups, float64(tqMap)/lookups
+		bSlice, bMap := float64(tbSlice)/timingRounds, float64(tbMap)/timingRounds
+		q := float64(queriesPerBuild)
+		if qMap+bMap/q < qSlice+bSlice/q {
+			hi = size
+		} else {
+			lo = size + 1
+		}
+	}
+	return lo
+}
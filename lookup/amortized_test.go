@@ -0,0 +1,16 @@
+package lookup
+
+import "testing"
+
+func TestCutoffAmortized(t *testing.T) {
+	if testing.Short() {
+		t.Skip("timing based")
+	}
+	// Timings are noisy, so this only compares extremes: with a single query
+	// the cost of building the map dominates, with many it vanishes.
+	few, many := CutoffAmortized(1), CutoffAmortized(1<<20)
+	t.Logf("cutoff: 1 query per build %v, 1<<20 queries per build %v", few, many)
+	if few <= many {
+		t.Errorf("cutoff with few queries (%v) is not greater than with many (%v)", few, many)
+	}
+}
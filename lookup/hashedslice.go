@@ -0,0 +1,58 @@
+package lookup
+
+// HashedSlice is a slice that stores a hash next to each element.
+// Has only compares elements whose hash matches the target's, which makes
+// scans over large keys reject most elements by looking at a single word.
+// Elements keep their insertion order.
+type HashedSlice[T comparable] struct {
+	hash   func(T) uint64
+	hashes []uint64
+	vs     []T
+}
+
+// NewHashedSlice returns an empty HashedSlice using hash to compute hashes.
+// Equal elements must have equal hashes.
+func NewHashedSlice[T comparable](hash func(T) uint64) *HashedSlice[T] {
+	return &HashedSlice[T]{hash: hash}
+}
+
+// Add appends v to the slice.
+func (hs *HashedSlice[T]) Add(v T) {
+	hs.hashes = append(hs.hashes, hs.hash(v))
+	hs.vs = append(hs.vs, v)
+}
+
+// Len returns the amount of elements in the slice.
+func (hs *HashedSlice[T]) Len() int {
+	return len(hs.vs)
+}
+
+// Has reports whether target is in the slice.
+func (hs *HashedSlice[T]) Has(target T) bool {
+	h := hs.hash(target)
+	for i, v := range hs.hashes {
+		if v == h && hs.vs[i] == target {
+			return true
+		}
+	}
+	return false
+}
+
+// hashLargeData is an FNV-1a hash over the words of l.
+func hashLargeData(l largeData) uint64 {
+	const prime = 1099511628211
+	h := uint64(14695981039346656037)
+	for _, v := range l {
+		h ^= uint64(v)
+		h *= prime
+	}
+	return h
+}
+
+func setupLargeHashedSlice(size int) *HashedSlice[largeData] {
+	hs := NewHashedSlice(hashLargeData)
+	for _, l := range setupLargeSlice(size) {
+		hs.Add(l)
+	}
+	return hs
+}
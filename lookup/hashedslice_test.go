@@ -0,0 +1,61 @@
+package lookup
+
+import "testing"
+
+func TestHashedSlice(t *testing.T) {
+	tests := []struct {
+		name string
+		hash func(string) uint64
+	}{
+		{"distinct", func(s string) uint64 {
+			var h uint64
+			for _, c := range s {
+				h = h*31 + uint64(c)
+			}
+			return h
+		}},
+		// All elements collide, so every lookup has to fall back to ==.
+		{"colliding", func(string) uint64 { return 42 }},
+		{"length", func(s string) uint64 { return uint64(len(s)) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := NewHashedSlice(tt.hash)
+			if hs.Has("") {
+				t.Errorf("Has on empty: got true want false")
+			}
+			for _, v := range []string{"a", "b", "ab", "ba", "abc"} {
+				hs.Add(v)
+			}
+			if got, want := hs.Len(), 5; got != want {
+				t.Errorf("Len: got %v want %v", got, want)
+			}
+			for _, v := range []string{"a", "b", "ab", "ba", "abc"} {
+				if !hs.Has(v) {
+					t.Errorf("Has(%q): got false want true", v)
+				}
+			}
+			for _, v := range []string{"", "c", "aa", "bb", "cba"} {
+				if hs.Has(v) {
+					t.Errorf("Has(%q): got true want false", v)
+				}
+			}
+		})
+	}
+}
+
+func TestHashedSliceLargeData(t *testing.T) {
+	hs := setupLargeHashedSlice(16)
+	s := setupLargeSlice(16)
+	for _, l := range s {
+		if !hs.Has(l) {
+			t.Errorf("Has(%v): got false want true", l[0])
+		}
+	}
+	// Same hash input prefix, different last word.
+	l := s[3]
+	l[len(l)-1]++
+	if hs.Has(l) {
+		t.Errorf("Has(modified): got true want false")
+	}
+}
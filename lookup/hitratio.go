@@ -0,0 +1,71 @@
+package lookup
+
+import (
+	"math/rand"
+	"time"
+)
+
+// hitRatioNeedles returns n values to look up in setupInt(size), a ratio of
+// which are present. The sequence is deterministic.
+func hitRatioNeedles(size, n int, ratio float64) []int {
+	r := rand.New(rand.NewSource(1))
+	needles := make([]int, n)
+	for i := range needles {
+		if r.Float64() < ratio {
+			needles[i] = r.Intn(size)
+		} else {
+			needles[i] = size + r.Intn(size)
+		}
+	}
+	return needles
+}
+
+// timingReps is the amount of repetitions of timings, of which the fastest is
+// kept. timingRounds is the amount of rounds timed in every repetition.
+const timingReps, timingRounds = 5, 32
+
+// timeLookups returns the fastest of a few timings of looking up all needles
+// timingRounds times with has, to filter out noise.
+// It also returns how many lookups succeeded, which keeps them from being
+// optimized away. It uses no shared state, so calibrations for different
+// types can run concurrently.
+func timeLookups[T any](needles []T, has func(T) bool) (best time.Duration, hits int) {
+	best = time.Duration(1<<63 - 1)
+	for range timingReps {
+		start := time.Now()
+		for range timingRounds {
+			for _, n := range needles {
+				if has(n) {
+					hits++
+				}
+			}
+		}
+		best = min(best, time.Since(start))
+	}
+	return best, hits
+}
+
+// CutoffWithHitRatio returns the smallest size at which looking up ints in a
+// map is faster than scanning a slice, when a ratio of the lookups, in [0, 1],
+// finds the element.
+// Misses scan the whole slice while maps are unaffected, so the cutoff gets
+// lower as the ratio of misses increases.
+// The result comes from timings, so it is only indicative and it can vary
+// across runs.
+func CutoffWithHitRatio(ratio float64) int {
+	const maxSize = 1024
+	lo, hi := 1, maxSize
+	for lo < hi {
+		size := (lo + hi) / 2
+		m, s := setupInt(size)
+		needles := hitRatioNeedles(size, 1024, ratio)
+		tSlice, _ := timeLookups(needles, func(n int) bool { return sliceHas(s, n) })
+		tMap, _ := timeLookups(needles, func(n int) bool { return mapHas(m, n) })
+		if tMap < tSlice {
+			hi = size
+		} else {
+			lo = size + 1
+		}
+	}
+	return lo
+}
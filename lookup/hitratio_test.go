@@ -0,0 +1,99 @@
+package lookup
+
+import (
+	"flag"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+var strictTiming = flag.Bool("timing.strict", false, "fail on timing comparisons that are too noisy for loaded machines, instead of only logging them")
+
+func TestHitRatioNeedles(t *testing.T) {
+	const size, n = 50, 1000
+	for _, ratio := range []float64{0, 0.3, 1} {
+		hits := 0
+		for _, v := range hitRatioNeedles(size, n, ratio) {
+			if v < size {
+				hits++
+			}
+		}
+		// The ratio is approximated by random draws.
+		if got, want := float64(hits)/n, ratio; got < want-0.05 || got > want+0.05 {
+			t.Errorf("hit ratio: got %v want %v", got, want)
+		}
+	}
+}
+
+func TestCutoffWithHitRatio(t *testing.T) {
+	if testing.Short() {
+		t.Skip("timing based")
+	}
+	// A single calibration is too noisy to compare cutoffs, which are only a
+	// few elements apart, so the median of a few of them is used.
+	median := func(ratio float64) int {
+		cutoffs := make([]int, 5)
+		for i := range cutoffs {
+			cutoffs[i] = CutoffWithHitRatio(ratio)
+		}
+		slices.Sort(cutoffs)
+		t.Logf("cutoffs with hit ratio %v: %v", ratio, cutoffs)
+		return cutoffs[len(cutoffs)/2]
+	}
+	miss, hit := median(0), median(1)
+	for _, got := range []int{miss, hit} {
+		if got < 1 || got > 1024 {
+			t.Errorf("median cutoff: got %v want in [1, 1024]", got)
+		}
+	}
+	if miss > hit {
+		report := t.Logf
+		if *strictTiming {
+			report = t.Errorf
+		}
+		report("median cutoff with all misses is %v, want it not higher than with all hits, %v", miss, hit)
+	}
+}
+
+// TestMissesScanLonger only fails with -timing.strict, like the comparison in
+// TestCutoffWithHitRatio.
+func TestMissesScanLonger(t *testing.T) {
+	if testing.Short() {
+		t.Skip("timing based")
+	}
+	// At a size well above the cutoff scans dominate, and misses scan the
+	// whole slice while hits stop halfway on average.
+	const size = 256
+	_, s := setupInt(size)
+	scan := func(ratio float64) time.Duration {
+		d, _ := timeLookups(hitRatioNeedles(size, 1024, ratio), func(n int) bool { return sliceHas(s, n) })
+		return d
+	}
+	miss, hit := scan(0), scan(1)
+	t.Logf("scanning for misses took %v, for hits %v", miss, hit)
+	if miss <= hit && *strictTiming {
+		t.Errorf("scanning for misses took %v, want more than for hits, %v", miss, hit)
+	}
+}
+
+func BenchmarkHitRatio(b *testing.B) {
+	for _, ratio := range []float64{0, 0.5, 1} {
+		for _, size := range sizes {
+			m, s := setupInt(size)
+			needles := hitRatioNeedles(size, 1024, ratio)
+			b.Run(fmt.Sprintf("ratio-%v/slice-%v", ratio, size), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := range b.N {
+					sliceHas(s, needles[i%len(needles)])
+				}
+			})
+			b.Run(fmt.Sprintf("ratio-%v/map-%v", ratio, size), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := range b.N {
+					mapHas(m, needles[i%len(needles)])
+				}
+			})
+		}
+	}
+}
@@ -19,11 +19,73 @@ func sliceHas[T comparable](s []T, target T) bool {
 	return false
 }
 
-func mapHas[T comparable](m map[T]none, target T) bool {
+// IndexOf returns the index of the first occurrence of target in s, or -1 if
+// it is not present.
+func IndexOf[T comparable](s []T, target T) int {
+	for i, v := range s {
+		if v == target {
+			return i
+		}
+	}
+	return -1
+}
+
+// Find returns the index of the first element of s for which pred returns
+// true. It reports false if there is none.
+func Find[T any](s []T, pred func(T) bool) (int, bool) {
+	for i, v := range s {
+		if pred(v) {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// InterpolationContains reports whether target is in s, which must be sorted
+// in ascending order.
+// It assumes values are roughly uniformly distributed, in which case it takes
+// O(log log n) comparisons. On skewed data it degrades towards a linear scan.
+func InterpolationContains(s []int, target int) bool {
+	lo, hi := 0, len(s)-1
+	for lo <= hi && target >= s[lo] && target <= s[hi] {
+		if s[lo] == s[hi] {
+			return s[lo] == target
+		}
+		// Floats avoid overflowing on large spans, clamping guards against
+		// rounding errors.
+		frac := (float64(target) - float64(s[lo])) / (float64(s[hi]) - float64(s[lo]))
+		pos := min(max(lo+int(frac*float64(hi-lo)), lo), hi)
+		switch {
+		case s[pos] == target:
+			return true
+		case s[pos] < target:
+			lo = pos + 1
+		default:
+			hi = pos - 1
+		}
+	}
+	return false
+}
+
+func mapHas[T comparable, V any](m map[T]V, target T) bool {
 	_, ok := m[target]
 	return ok
 }
 
+// sliceEach calls fn on every element of s, in order.
+func sliceEach[T any](s []T, fn func(T)) {
+	for _, v := range s {
+		fn(v)
+	}
+}
+
+// mapEach calls fn on every key of m, in random order.
+func mapEach[T comparable, V any](m map[T]V, fn func(T)) {
+	for k := range m {
+		fn(k)
+	}
+}
+
 func setupLargeMap(size int) map[largeData]none {
 	m := make(map[largeData]none, size)
 	for i := range size {
@@ -56,6 +118,14 @@ func setupIntMap(size int) map[int]none {
 	return m
 }
 
+func setupIntBoolMap(size int) map[int]bool {
+	m := make(map[int]bool, size)
+	for i := range size {
+		m[i] = true
+	}
+	return m
+}
+
 func setupIntSlice(size int) []int {
 	s := make([]int, 0, size)
 	for i := range size {
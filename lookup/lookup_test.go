@@ -5,6 +5,8 @@ import (
 	"sort"
 	"strconv"
 	"testing"
+
+	"github.com/google/go-cmp/cmp"
 )
 
 func TestSlice(t *testing.T) {
@@ -25,8 +27,78 @@ func TestSlice(t *testing.T) {
 	}
 }
 
+func TestIndexOf(t *testing.T) {
+	hayStack := []int{8, 9, 1, 2, 9, 7}
+	tests := []struct {
+		name   string
+		target int
+		want   int
+	}{
+		{"front", 8, 0},
+		{"middle", 1, 2},
+		{"end", 7, 5},
+		{"first of duplicates", 9, 1},
+		{"not found", 5, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IndexOf(hayStack, tt.target); got != tt.want {
+				t.Errorf("IndexOf(%v, %v): got %v want %v", hayStack, tt.target, got, tt.want)
+			}
+			gotIdx, gotOK := Find(hayStack, func(v int) bool { return v == tt.target })
+			if gotIdx != tt.want || gotOK != (tt.want >= 0) {
+				t.Errorf("Find(%v, ==%v): got (%v, %v) want (%v, %v)", hayStack, tt.target, gotIdx, gotOK, tt.want, tt.want >= 0)
+			}
+		})
+	}
+	if got := IndexOf[string](nil, ""); got != -1 {
+		t.Errorf("IndexOf on nil: got %v want -1", got)
+	}
+	if got, ok := Find([]int{1, 3, 4, 6}, func(v int) bool { return v%2 == 0 }); got != 2 || !ok {
+		t.Errorf("Find first even: got (%v, %v) want (2, true)", got, ok)
+	}
+}
+
+func TestInterpolationContains(t *testing.T) {
+	tests := []struct {
+		name   string
+		s      []int
+		target int
+		want   bool
+	}{
+		{"empty", nil, 1, false},
+		{"single hit", []int{3}, 3, true},
+		{"single miss", []int{3}, 4, false},
+		{"smaller than min", []int{2, 4, 6, 8}, 1, false},
+		{"larger than max", []int{2, 4, 6, 8}, 9, false},
+		{"not present", []int{2, 4, 6, 8}, 5, false},
+		{"first", []int{2, 4, 6, 8}, 2, true},
+		{"last", []int{2, 4, 6, 8}, 8, true},
+		{"middle", []int{2, 4, 6, 8}, 6, true},
+		{"duplicates", []int{1, 5, 5, 5, 5, 9}, 5, true},
+		{"skewed", []int{1, 2, 3, 4, 5, 1_000_000}, 4, true},
+		{"large span", []int{-1 << 62, 0, 1 << 62}, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := InterpolationContains(tt.s, tt.target); got != tt.want {
+				t.Errorf("InterpolationContains(%v, %v): got %v want %v", tt.s, tt.target, got, tt.want)
+			}
+		})
+	}
+	s := setupIntSlice(1000)
+	for i := range 1000 {
+		if !InterpolationContains(s, i) {
+			t.Errorf("InterpolationContains(0..999, %v): got false want true", i)
+		}
+	}
+}
+
 var sizes = []int{2, 4, 8, 16, 32, 64, 128}
 
+// found is a sink that keeps benchmarked code from being optimized away.
+var found int
+
 func BenchmarkLargeData(b *testing.B) {
 	for _, size := range sizes {
 		b.Run(fmt.Sprintf("slice-%v", size), func(b *testing.B) {
@@ -41,6 +113,18 @@ func BenchmarkLargeData(b *testing.B) {
 				sliceHas(s, needle)
 			}
 		})
+		b.Run(fmt.Sprintf("hashed-%v", size), func(b *testing.B) {
+			hs := setupLargeHashedSlice(size)
+			var needle largeData
+			for j := range needle {
+				needle[j] = size / 2
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for range b.N {
+				hs.Has(needle)
+			}
+		})
 		b.Run(fmt.Sprintf("map-%v", size), func(b *testing.B) {
 			s := setupLargeMap(size)
 			var needle largeData
@@ -73,8 +157,145 @@ func BenchmarkInts(b *testing.B) {
 				mapHas(s, size/2)
 			}
 		})
+		b.Run(fmt.Sprintf("interpolation-%v", size), func(b *testing.B) {
+			s := setupIntSlice(size)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for range b.N {
+				InterpolationContains(s, size/2)
+			}
+		})
+	}
+}
+func TestMapValueTypes(t *testing.T) {
+	const size = 128
+	set, bools := setupIntMap(size), setupIntBoolMap(size)
+	for i := -1; i <= size; i++ {
+		if got, want := mapHas(bools, i), mapHas(set, i); got != want {
+			t.Errorf("mapHas(%v): bool map got %v, struct map got %v", i, got, want)
+		}
 	}
 }
+
+/*
+With int keys there is no measurable difference: the bool value is padded to
+the alignment of the key, so both maps use the same memory, and lookups only
+differ by noise. The empty struct is still the safer default, as it doesn't
+waste space with keys that don't need padding.
+
+BenchmarkMapValue/lookup-struct-2         	349127563	         3.305 ns/op	       0 B/op	       0 allocs/op
+BenchmarkMapValue/lookup-bool-2           	365857423	         3.168 ns/op	       0 B/op	       0 allocs/op
+BenchmarkMapValue/build-struct-2          	36042754	        32.25 ns/op	       0 B/op	       0 allocs/op
+BenchmarkMapValue/build-bool-2            	33705992	        32.22 ns/op	       0 B/op	       0 allocs/op
+BenchmarkMapValue/lookup-struct-4         	274380206	         4.413 ns/op	       0 B/op	       0 allocs/op
+BenchmarkMapValue/lookup-bool-4           	332219064	         4.824 ns/op	       0 B/op	       0 allocs/op
+BenchmarkMapValue/build-struct-4          	20711857	        56.23 ns/op	       0 B/op	       0 allocs/op
+BenchmarkMapValue/build-bool-4            	23083759	        55.99 ns/op	       0 B/op	       0 allocs/op
+BenchmarkMapValue/lookup-struct-8         	173548876	         6.390 ns/op	       0 B/op	       0 allocs/op
+BenchmarkMapValue/lookup-bool-8           	188914288	         6.543 ns/op	       0 B/op	       0 allocs/op
+BenchmarkMapValue/build-struct-8          	11282828	       116.1 ns/op	       0 B/op	       0 allocs/op
+BenchmarkMapValue/build-bool-8            	10356831	       108.6 ns/op	       0 B/op	       0 allocs/op
+BenchmarkMapValue/lookup-struct-16        	193419532	         6.298 ns/op	       0 B/op	       0 allocs/op
+BenchmarkMapValue/lookup-bool-16          	209073144	         6.104 ns/op	       0 B/op	       0 allocs/op
+BenchmarkMapValue/build-struct-16         	 3395049	       354.4 ns/op	     616 B/op	       3 allocs/op
+BenchmarkMapValue/build-bool-16           	 3194266	       353.8 ns/op	     616 B/op	       3 allocs/op
+BenchmarkMapValue/lookup-struct-32        	198074802	         6.884 ns/op	       0 B/op	       0 allocs/op
+BenchmarkMapValue/lookup-bool-32          	200511688	         8.551 ns/op	       0 B/op	       0 allocs/op
+BenchmarkMapValue/build-struct-32         	 1941976	       596.0 ns/op	    1192 B/op	       3 allocs/op
+BenchmarkMapValue/build-bool-32           	 1836423	       729.5 ns/op	    1192 B/op	       3 allocs/op
+BenchmarkMapValue/lookup-struct-64        	168474115	         7.295 ns/op	       0 B/op	       0 allocs/op
+BenchmarkMapValue/lookup-bool-64          	157212687	         6.676 ns/op	       0 B/op	       0 allocs/op
+BenchmarkMapValue/build-struct-64         	 1000000	      1089 ns/op	    2344 B/op	       3 allocs/op
+BenchmarkMapValue/build-bool-64           	 1000000	      1133 ns/op	    2344 B/op	       3 allocs/op
+BenchmarkMapValue/lookup-struct-128       	184640860	         6.199 ns/op	       0 B/op	       0 allocs/op
+BenchmarkMapValue/lookup-bool-128         	199038994	         6.282 ns/op	       0 B/op	       0 allocs/op
+BenchmarkMapValue/build-struct-128        	  510972	      2266 ns/op	    4904 B/op	       3 allocs/op
+BenchmarkMapValue/build-bool-128          	  388042	      2641 ns/op	    4904 B/op	       3 allocs/op
+*/
+func BenchmarkMapValue(b *testing.B) {
+	for _, size := range sizes {
+		b.Run(fmt.Sprintf("lookup-struct-%v", size), func(b *testing.B) {
+			m := setupIntMap(size)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for range b.N {
+				mapHas(m, size/2)
+			}
+		})
+		b.Run(fmt.Sprintf("lookup-bool-%v", size), func(b *testing.B) {
+			m := setupIntBoolMap(size)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for range b.N {
+				mapHas(m, size/2)
+			}
+		})
+		b.Run(fmt.Sprintf("build-struct-%v", size), func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				setupIntMap(size)
+			}
+		})
+		b.Run(fmt.Sprintf("build-bool-%v", size), func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				setupIntBoolMap(size)
+			}
+		})
+	}
+}
+func TestEach(t *testing.T) {
+	for _, size := range sizes {
+		m, s := setupInt(size)
+		var fromSlice, fromMap []int
+		sliceEach(s, func(v int) { fromSlice = append(fromSlice, v) })
+		mapEach(m, func(v int) { fromMap = append(fromMap, v) })
+		sort.Ints(fromSlice)
+		sort.Ints(fromMap)
+		if diff := cmp.Diff(fromSlice, fromMap); diff != "" {
+			t.Errorf("size %v: mapEach and sliceEach visited different elements, diff:\n%s", size, diff)
+		}
+	}
+}
+
+/*
+Iterating a map costs about three times as much as a slice at every size, and
+it visits elements in random order. Sets that are iterated often are better
+stored as slices, even above the lookup cutoff.
+
+BenchmarkIterate/slice-2         	229828286	         5.086 ns/op	       0 B/op	       0 allocs/op
+BenchmarkIterate/map-2           	24210579	        50.36 ns/op	       0 B/op	       0 allocs/op
+BenchmarkIterate/slice-4         	120715184	         9.958 ns/op	       0 B/op	       0 allocs/op
+BenchmarkIterate/map-4           	19985728	        59.90 ns/op	       0 B/op	       0 allocs/op
+BenchmarkIterate/slice-8         	59339259	        19.88 ns/op	       0 B/op	       0 allocs/op
+BenchmarkIterate/map-8           	21286286	        55.17 ns/op	       0 B/op	       0 allocs/op
+BenchmarkIterate/slice-16        	30346347	        39.68 ns/op	       0 B/op	       0 allocs/op
+BenchmarkIterate/map-16          	 7505848	       161.1 ns/op	       0 B/op	       0 allocs/op
+BenchmarkIterate/slice-32        	15222668	        79.28 ns/op	       0 B/op	       0 allocs/op
+BenchmarkIterate/map-32          	 4121413	       294.7 ns/op	       0 B/op	       0 allocs/op
+BenchmarkIterate/slice-64        	 7612465	       158.0 ns/op	       0 B/op	       0 allocs/op
+BenchmarkIterate/map-64          	 2332048	       523.0 ns/op	       0 B/op	       0 allocs/op
+BenchmarkIterate/slice-128       	 3794749	       315.7 ns/op	       0 B/op	       0 allocs/op
+BenchmarkIterate/map-128         	 1223125	       978.7 ns/op	       0 B/op	       0 allocs/op
+*/
+func BenchmarkIterate(b *testing.B) {
+	for _, size := range sizes {
+		m, s := setupInt(size)
+		b.Run(fmt.Sprintf("slice-%v", size), func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				sliceEach(s, func(v int) { found += v })
+			}
+		})
+		b.Run(fmt.Sprintf("map-%v", size), func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				mapEach(m, func(v int) { found += v })
+			}
+		})
+	}
+}
+
 func BenchmarkStrings(b *testing.B) {
 	for _, size := range sizes {
 		b.Run(fmt.Sprintf("slice-%v", size), func(b *testing.B) {
@@ -0,0 +1,82 @@
+package lookup
+
+import "iter"
+
+type slot[T any] struct {
+	v    T
+	live bool
+}
+
+// OrderedSet is a set with O(1) membership checks that iterates in insertion order.
+// The zero value is an empty set ready to use.
+type OrderedSet[T comparable] struct {
+	// idx maps each element to its slot in order.
+	idx   map[T]int
+	order []slot[T]
+	dead  int
+}
+
+// Add adds v to the set, reporting whether it was not already present.
+// Re-adding a removed element puts it at the end of the iteration order.
+func (s *OrderedSet[T]) Add(v T) bool {
+	if _, ok := s.idx[v]; ok {
+		return false
+	}
+	if s.idx == nil {
+		s.idx = make(map[T]int)
+	}
+	s.idx[v] = len(s.order)
+	s.order = append(s.order, slot[T]{v: v, live: true})
+	return true
+}
+
+// Has reports whether v is in the set.
+func (s *OrderedSet[T]) Has(v T) bool {
+	return mapHas(s.idx, v)
+}
+
+// Remove removes v from the set, reporting whether it was present.
+func (s *OrderedSet[T]) Remove(v T) bool {
+	i, ok := s.idx[v]
+	if !ok {
+		return false
+	}
+	delete(s.idx, v)
+	s.order[i] = slot[T]{}
+	s.dead++
+	if s.dead > len(s.order)/2 {
+		s.compact()
+	}
+	return true
+}
+
+// compact drops the slots of removed elements.
+func (s *OrderedSet[T]) compact() {
+	live := s.order[:0]
+	for _, sl := range s.order {
+		if sl.live {
+			s.idx[sl.v] = len(live)
+			live = append(live, sl)
+		}
+	}
+	clear(s.order[len(live):])
+	s.order = live
+	s.dead = 0
+}
+
+// Len returns the amount of elements in the set.
+func (s *OrderedSet[T]) Len() int {
+	return len(s.idx)
+}
+
+// All yields the elements of the set in insertion order.
+// The set must not be modified during iteration.
+func (s *OrderedSet[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for _, sl := range s.order {
+			if sl.live && !yield(sl.v) {
+				return
+			}
+		}
+	}
+}
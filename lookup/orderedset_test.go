@@ -0,0 +1,53 @@
+package lookup
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestOrderedSet(t *testing.T) {
+	var s OrderedSet[string]
+	for _, v := range []string{"c", "a", "b", "a", "d"} {
+		s.Add(v)
+	}
+	if got, want := slices.Collect(s.All()), []string{"c", "a", "b", "d"}; !cmp.Equal(got, want) {
+		t.Errorf("All: got %v want %v", got, want)
+	}
+	if !s.Has("a") || s.Has("z") {
+		t.Errorf("Has: got a=%v z=%v want a=true z=false", s.Has("a"), s.Has("z"))
+	}
+
+	if !s.Remove("a") {
+		t.Errorf("Remove(a): got false want true")
+	}
+	if s.Remove("a") {
+		t.Errorf("Remove(a) twice: got true want false")
+	}
+	if s.Has("a") {
+		t.Errorf("Has(a) after Remove: got true")
+	}
+	if got, want := slices.Collect(s.All()), []string{"c", "b", "d"}; !cmp.Equal(got, want) {
+		t.Errorf("All after Remove: got %v want %v", got, want)
+	}
+
+	// Trigger a compaction and re-add a removed element.
+	s.Remove("c")
+	s.Remove("d")
+	if !s.Add("a") {
+		t.Errorf("Add(a) after Remove: got false want true")
+	}
+	s.Add("e")
+	if got, want := slices.Collect(s.All()), []string{"b", "a", "e"}; !cmp.Equal(got, want) {
+		t.Errorf("All after compaction: got %v want %v", got, want)
+	}
+	for _, v := range []string{"b", "a", "e"} {
+		if !s.Has(v) {
+			t.Errorf("Has(%v) after compaction: got false", v)
+		}
+	}
+	if got, want := s.Len(), 3; got != want {
+		t.Errorf("Len: got %v want %v", got, want)
+	}
+}
@@ -0,0 +1,155 @@
+package lookup
+
+import (
+	"reflect"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+const (
+	// defaultSetCutoff is the promotion threshold for types that can't be
+	// calibrated.
+	defaultSetCutoff = 16
+	// maxSetCutoff caps the calibration search.
+	maxSetCutoff = 256
+)
+
+// setCutoffs maps element types to a func returning their calibrated cutoff,
+// which runs the calibration only once.
+var setCutoffs sync.Map
+
+// Set is a set that stores its elements in a slice while it is small and
+// moves them to a map once it grows past the size at which maps become
+// faster. That size is measured on this machine the first time a Set for a
+// given element type is created, which takes a few milliseconds.
+type Set[T comparable] struct {
+	cutoff int
+	s      []T
+	// m is nil until the set is promoted.
+	m map[T]none
+}
+
+// NewSet returns an empty Set.
+func NewSet[T comparable]() *Set[T] {
+	return &Set[T]{cutoff: setCutoff[T]()}
+}
+
+// setCutoff returns the calibrated cutoff for T, calibrating it on first use.
+func setCutoff[T comparable]() int {
+	t := reflect.TypeFor[T]()
+	f, ok := setCutoffs.Load(t)
+	if !ok {
+		f, _ = setCutoffs.LoadOrStore(t, sync.OnceValue(calibrateSetCutoff[T]))
+	}
+	return f.(func() int)()
+}
+
+// BuildLookup returns a func that reports whether its argument is in haystack.
+// Like Set, it scans a copy of haystack if it is shorter than the calibrated
+// cutoff for T, and it looks up a map otherwise.
+// Later changes to haystack are not reflected by the returned func.
+func BuildLookup[T comparable](haystack []T) func(T) bool {
+	return buildLookup(haystack, setCutoff[T]())
+}
+
+func buildLookup[T comparable](haystack []T, cutoff int) func(T) bool {
+	if len(haystack) < cutoff {
+		s := slices.Clone(haystack)
+		return func(v T) bool { return sliceHas(s, v) }
+	}
+	m := make(map[T]none, len(haystack))
+	for _, v := range haystack {
+		m[v] = none{}
+	}
+	return func(v T) bool { return mapHas(m, v) }
+}
+
+// Add adds v to the set, reporting whether it was not already present.
+func (s *Set[T]) Add(v T) bool {
+	if s.Has(v) {
+		return false
+	}
+	if s.m != nil {
+		s.m[v] = none{}
+		return true
+	}
+	s.s = append(s.s, v)
+	if len(s.s) >= s.cutoff {
+		s.m = make(map[T]none, len(s.s))
+		for _, v := range s.s {
+			s.m[v] = none{}
+		}
+		s.s = nil
+	}
+	return true
+}
+
+// Has reports whether v is in the set.
+func (s *Set[T]) Has(v T) bool {
+	if s.m != nil {
+		return mapHas(s.m, v)
+	}
+	return sliceHas(s.s, v)
+}
+
+// Len returns the amount of elements in the set.
+func (s *Set[T]) Len() int {
+	if s.m != nil {
+		return len(s.m)
+	}
+	return len(s.s)
+}
+
+// genFor returns a function that returns distinct values of T, if T is a
+// number or a string.
+func genFor[T any]() (func(i int) T, bool) {
+	var set func(v reflect.Value, i int)
+	switch reflect.TypeFor[T]().Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		set = func(v reflect.Value, i int) { v.SetInt(int64(i)) }
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		set = func(v reflect.Value, i int) { v.SetUint(uint64(i)) }
+	case reflect.Float32, reflect.Float64:
+		set = func(v reflect.Value, i int) { v.SetFloat(float64(i)) }
+	case reflect.String:
+		set = func(v reflect.Value, i int) { v.SetString(strconv.Itoa(i)) }
+	default:
+		return nil, false
+	}
+	return func(i int) T {
+		var t T
+		set(reflect.ValueOf(&t).Elem(), i)
+		return t
+	}, true
+}
+
+// calibrateSetCutoff returns the smallest size at which looking up values of T
+// in a map is faster than scanning a slice, or defaultSetCutoff if values of T
+// can't be generated.
+func calibrateSetCutoff[T comparable]() int {
+	gen, ok := genFor[T]()
+	if !ok {
+		return defaultSetCutoff
+	}
+	lo, hi := 1, maxSetCutoff
+	for lo < hi {
+		size := (lo + hi) / 2
+		s := make([]T, size)
+		m := make(map[T]none, size)
+		for i := range s {
+			s[i] = gen(i)
+			m[s[i]] = none{}
+		}
+		// Look up every element once, so that half the slice is scanned on
+		// average.
+		tSlice, _ := timeLookups(s, func(v T) bool { return sliceHas(s, v) })
+		tMap, _ := timeLookups(s, func(v T) bool { return mapHas(m, v) })
+		if tMap < tSlice {
+			hi = size
+		} else {
+			lo = size + 1
+		}
+	}
+	return lo
+}
@@ -0,0 +1,169 @@
+package lookup
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+func TestSetCutoff(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		cutoff func() int
+	}{
+		{"int", func() int { return NewSet[int]().cutoff }},
+		{"string", func() int { return NewSet[string]().cutoff }},
+		{"float32", func() int { return NewSet[float32]().cutoff }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.cutoff()
+			t.Logf("cutoff: %v", got)
+			if got < 1 || got > maxSetCutoff {
+				t.Errorf("cutoff: got %v want in [1, %v]", got, maxSetCutoff)
+			}
+			// The calibration is cached.
+			if again := tc.cutoff(); again != got {
+				t.Errorf("second cutoff: got %v want %v", again, got)
+			}
+		})
+	}
+	if got := NewSet[[2]int]().cutoff; got != defaultSetCutoff {
+		t.Errorf("cutoff for a type that can't be calibrated: got %v want %v", got, defaultSetCutoff)
+	}
+	if _, ok := setCutoffs.Load(reflect.TypeFor[int]()); !ok {
+		t.Errorf("cutoff for int is not cached")
+	}
+}
+
+func TestNewSetConcurrent(t *testing.T) {
+	// Calibrations for different types run concurrently.
+	var wg sync.WaitGroup
+	for _, newSet := range []func(){
+		func() { NewSet[int8]() },
+		func() { NewSet[uint16]() },
+		func() { NewSet[float64]() },
+	} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			newSet()
+		}()
+	}
+	wg.Wait()
+	// Once calibrated, only the Set itself is allocated.
+	var s *Set[int8]
+	if n := testing.AllocsPerRun(100, func() { s = NewSet[int8]() }); n != 1 {
+		t.Errorf("NewSet allocated %v times, want 1", n)
+	}
+	runtime.KeepAlive(s)
+}
+
+func TestSet(t *testing.T) {
+	const cutoff = 8
+	s := NewSet[int]()
+	s.cutoff = cutoff
+	for i := range 2 * cutoff {
+		if !s.Add(i) {
+			t.Errorf("Add(%v): got false", i)
+		}
+		if s.Add(i) {
+			t.Errorf("Add(%v) twice: got true", i)
+		}
+		if promoted, want := s.m != nil, i+1 >= cutoff; promoted != want {
+			t.Errorf("after %v elements: promoted is %v want %v", i+1, promoted, want)
+		}
+		if got, want := s.Len(), i+1; got != want {
+			t.Errorf("Len: got %v want %v", got, want)
+		}
+		for j := range 2 * cutoff {
+			if got, want := s.Has(j), j <= i; got != want {
+				t.Errorf("after adding 0..%v: Has(%v) got %v want %v", i, j, got, want)
+			}
+		}
+	}
+}
+
+func TestBuildLookup(t *testing.T) {
+	const cutoff = 8
+	for _, size := range []int{0, 1, cutoff - 1, cutoff, 4 * cutoff} {
+		t.Run(fmt.Sprint(size), func(t *testing.T) {
+			haystack := make([]int, size)
+			for i := range haystack {
+				haystack[i] = 2 * i
+			}
+			has := buildLookup(haystack, cutoff)
+			// The lookup must not depend on the haystack after it is built.
+			for i := range haystack {
+				haystack[i] = -1
+			}
+			for v := -1; v <= 2*size; v++ {
+				if got, want := has(v), v >= 0 && v%2 == 0 && v < 2*size; got != want {
+					t.Errorf("lookup(%v): got %v want %v", v, got, want)
+				}
+			}
+		})
+	}
+	has := BuildLookup([]string{"a", "b"})
+	if !has("a") || has("c") {
+		t.Errorf("BuildLookup with the calibrated cutoff: got (%v, %v) want (true, false)", has("a"), has("c"))
+	}
+}
+
+/*
+The built lookup follows the faster container on either side of the cutoff,
+which was calibrated at 29 for ints on this machine, but calling through the
+closure adds about 2ns to every lookup. Callers that know the haystack size
+upfront can still beat it by using the container directly.
+
+BenchmarkBuildLookup/slice-2         	843293863	         1.458 ns/op	       0 B/op	       0 allocs/op
+BenchmarkBuildLookup/map-2           	400813354	         3.416 ns/op	       0 B/op	       0 allocs/op
+BenchmarkBuildLookup/built-2         	326141313	         3.448 ns/op	       0 B/op	       0 allocs/op
+BenchmarkBuildLookup/slice-4         	490540702	         2.659 ns/op	       0 B/op	       0 allocs/op
+BenchmarkBuildLookup/map-4           	317819866	         3.834 ns/op	       0 B/op	       0 allocs/op
+BenchmarkBuildLookup/built-4         	285637893	         4.541 ns/op	       0 B/op	       0 allocs/op
+BenchmarkBuildLookup/slice-8         	250491139	         4.963 ns/op	       0 B/op	       0 allocs/op
+BenchmarkBuildLookup/map-8           	186544508	         6.262 ns/op	       0 B/op	       0 allocs/op
+BenchmarkBuildLookup/built-8         	200236496	         5.901 ns/op	       0 B/op	       0 allocs/op
+BenchmarkBuildLookup/slice-16        	234479419	         4.699 ns/op	       0 B/op	       0 allocs/op
+BenchmarkBuildLookup/map-16          	207368185	         5.882 ns/op	       0 B/op	       0 allocs/op
+BenchmarkBuildLookup/built-16        	170278316	         7.124 ns/op	       0 B/op	       0 allocs/op
+BenchmarkBuildLookup/slice-32        	167973956	         7.189 ns/op	       0 B/op	       0 allocs/op
+BenchmarkBuildLookup/map-32          	207542700	         5.948 ns/op	       0 B/op	       0 allocs/op
+BenchmarkBuildLookup/built-32        	147174249	         8.547 ns/op	       0 B/op	       0 allocs/op
+BenchmarkBuildLookup/slice-64        	95339071	        12.61 ns/op	       0 B/op	       0 allocs/op
+BenchmarkBuildLookup/map-64          	207949586	         5.886 ns/op	       0 B/op	       0 allocs/op
+BenchmarkBuildLookup/built-64        	147488116	         8.124 ns/op	       0 B/op	       0 allocs/op
+BenchmarkBuildLookup/slice-128       	49095955	        23.81 ns/op	       0 B/op	       0 allocs/op
+BenchmarkBuildLookup/map-128         	208555948	         5.774 ns/op	       0 B/op	       0 allocs/op
+BenchmarkBuildLookup/built-128       	144826383	         8.484 ns/op	       0 B/op	       0 allocs/op
+*/
+func BenchmarkBuildLookup(b *testing.B) {
+	for _, size := range sizes {
+		b.Run(fmt.Sprintf("slice-%v", size), func(b *testing.B) {
+			s := setupIntSlice(size)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for range b.N {
+				sliceHas(s, size/2)
+			}
+		})
+		b.Run(fmt.Sprintf("map-%v", size), func(b *testing.B) {
+			m := setupIntMap(size)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for range b.N {
+				mapHas(m, size/2)
+			}
+		})
+		b.Run(fmt.Sprintf("built-%v", size), func(b *testing.B) {
+			has := BuildLookup(setupIntSlice(size))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for range b.N {
+				has(size / 2)
+			}
+		})
+	}
+}
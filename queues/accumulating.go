@@ -0,0 +1,35 @@
+package queues
+
+var _ Queue[int] = &AccumulatingQueue[int, int]{}
+
+// AccumulatingQueue is a KeyedQueue that combines values with the same key
+// instead of replacing them.
+type AccumulatingQueue[K comparable, V any] struct {
+	KeyedQueue[K, V]
+	combine func(old, new V) V
+}
+
+// NewAccumulatingQueue returns an empty AccumulatingQueue that uses key to
+// compute the key of each value and combine to merge a value into the queued
+// one with the same key.
+// combine must return a value with the same key as its arguments: Enqueue
+// panics otherwise, leaving the queue unchanged.
+func NewAccumulatingQueue[K comparable, V any](key func(V) K, combine func(old, new V) V) *AccumulatingQueue[K, V] {
+	return &AccumulatingQueue[K, V]{
+		KeyedQueue: *NewKeyedQueue(key),
+		combine:    combine,
+	}
+}
+
+// Enqueue adds v at the end of the queue, or combines it with the queued value
+// with the same key, which keeps its original position.
+func (aq *AccumulatingQueue[K, V]) Enqueue(v V) {
+	k := aq.key(v)
+	if old, ok := aq.vals[k]; ok {
+		v = aq.combine(old, v)
+		if aq.key(v) != k {
+			panic("accumulating queue combine changed the key of the value")
+		}
+	}
+	aq.KeyedQueue.Enqueue(v)
+}
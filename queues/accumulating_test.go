@@ -0,0 +1,65 @@
+package queues
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAccumulatingQueue(t *testing.T) {
+	type count struct {
+		Key string
+		N   int
+	}
+	q := NewAccumulatingQueue(
+		func(c count) string { return c.Key },
+		func(old, new count) count { return count{old.Key, old.N + new.N} },
+	)
+	for _, c := range []count{{"a", 1}, {"b", 2}, {"a", 3}, {"c", 1}, {"b", 1}, {"a", 1}} {
+		q.Enqueue(c)
+	}
+	if got, want := q.Len(), 3; got != want {
+		t.Errorf("Len: got %v want %v", got, want)
+	}
+	checkFront := func(want count) {
+		t.Helper()
+		if front, _, _ := q.Ends(); front != want {
+			t.Errorf("front: got %v want %v", front, want)
+		}
+	}
+	checkFront(count{"a", 5})
+	if got, want := q.Dequeue(), (count{"a", 5}); got != want {
+		t.Errorf("Dequeue: got %v want %v", got, want)
+	}
+	// Once dequeued, the key starts accumulating from scratch.
+	q.Enqueue(count{"a", 7})
+	q.Enqueue(count{"c", 2})
+	want := []count{{"b", 3}, {"c", 3}, {"a", 7}}
+	if diff := cmp.Diff(want, DrainToSlice[count](q)); diff != "" {
+		t.Errorf("accumulated values diff:\n%s", diff)
+	}
+}
+
+func TestAccumulatingQueueKeyChange(t *testing.T) {
+	q := NewAccumulatingQueue(
+		func(v int) int { return v % 10 },
+		// Adding the values moves them to a different key.
+		func(old, new int) int { return old + new },
+	)
+	q.Enqueue(1)
+	q.Enqueue(2)
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("Enqueue with a key-changing combine: got no panic")
+			}
+		}()
+		q.Enqueue(11)
+	}()
+	if err := CheckInvariants[int](q); err != nil {
+		t.Errorf("CheckInvariants: %v", err)
+	}
+	if diff := cmp.Diff([]int{1, 2}, DrainToSlice[int](q)); diff != "" {
+		t.Errorf("contents diff:\n%s", diff)
+	}
+}
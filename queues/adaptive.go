@@ -0,0 +1,63 @@
+package queues
+
+// adaptiveRingLen is the length at which an AdaptiveQueue moves its elements
+// from a map to a ring buffer. Below it the map's per-operation cost is
+// negligible, above it the ring wins on every workload we benchmarked.
+var adaptiveRingLen = 1 << 12
+
+var _ Queue[int] = &AdaptiveQueue[int]{}
+
+// AdaptiveQueue starts as a map backed queue and switches to a ring buffer
+// once it holds enough elements.
+// The map's keys are always contiguous, so the switch only depends on the
+// length. It happens once, preserving FIFO order.
+type AdaptiveQueue[T any] struct {
+	// m is nil once the queue switched to the ring.
+	m    *mapQueue[T]
+	ring ringQueue[T]
+}
+
+// NewAdaptiveQueue returns an empty map backed AdaptiveQueue.
+func NewAdaptiveQueue[T any]() *AdaptiveQueue[T] {
+	return &AdaptiveQueue[T]{m: newMapQueue[T]()}
+}
+
+func (aq *AdaptiveQueue[T]) Len() int {
+	if aq.m != nil {
+		return aq.m.Len()
+	}
+	return aq.ring.Len()
+}
+
+func (aq *AdaptiveQueue[T]) Dequeue() T {
+	if aq.m != nil {
+		return aq.m.Dequeue()
+	}
+	return aq.ring.Dequeue()
+}
+
+func (aq *AdaptiveQueue[T]) Ends() (front, back T, ok bool) {
+	if aq.m != nil {
+		return aq.m.Ends()
+	}
+	return aq.ring.Ends()
+}
+
+func (aq *AdaptiveQueue[T]) Enqueue(v T) {
+	if aq.m == nil {
+		aq.ring.Enqueue(v)
+		return
+	}
+	aq.m.Enqueue(v)
+	if aq.m.Len() >= adaptiveRingLen {
+		aq.toRing()
+	}
+}
+
+func (aq *AdaptiveQueue[T]) toRing() {
+	aq.ring.reserve(aq.m.Len(), false)
+	for aq.m.Len() > 0 {
+		aq.ring.Enqueue(aq.m.Dequeue())
+	}
+	aq.m = nil
+}
@@ -0,0 +1,81 @@
+package queues
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAdaptiveQueue(t *testing.T) {
+	bak := adaptiveRingLen
+	defer func() { adaptiveRingLen = bak }()
+	adaptiveRingLen = 100
+
+	tests := []struct {
+		name     string
+		ops      func(q Queue[int])
+		want     []int
+		wantRing bool
+	}{
+		{
+			name: "small",
+			ops: func(q Queue[int]) {
+				EnqueueMany(q, seq(0, 98))
+			},
+			want: seq(0, 98),
+		},
+		{
+			name: "small with churn",
+			ops: func(q Queue[int]) {
+				for i := range 1000 {
+					q.Enqueue(i)
+					if q.Len() > 50 {
+						q.Dequeue()
+					}
+				}
+			},
+			want: seq(950, 999),
+		},
+		{
+			name: "large",
+			ops: func(q Queue[int]) {
+				EnqueueMany(q, seq(0, 99))
+			},
+			want:     seq(0, 99),
+			wantRing: true,
+		},
+		{
+			name: "across the switch",
+			ops: func(q Queue[int]) {
+				EnqueueMany(q, seq(0, 89))
+				for range 10 {
+					q.Dequeue()
+				}
+				EnqueueMany(q, seq(90, 119))
+				for range 10 {
+					q.Dequeue()
+				}
+				EnqueueMany(q, seq(120, 129))
+			},
+			want:     seq(20, 129),
+			wantRing: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := NewAdaptiveQueue[int]()
+			tt.ops(q)
+			if gotRing := q.m == nil; gotRing != tt.wantRing {
+				t.Errorf("switched to ring: got %v want %v", gotRing, tt.wantRing)
+			}
+			checkEnds(t, q, tt.want[0], tt.want[len(tt.want)-1], true)
+			var got []int
+			for q.Len() > 0 {
+				got = append(got, q.Dequeue())
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("got %v want %v diff:\n%s", got, tt.want, diff)
+			}
+		})
+	}
+}
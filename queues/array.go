@@ -0,0 +1,81 @@
+package queues
+
+// Array queues hold up to a fixed amount of elements in an inline array, so
+// they don't need any heap allocation on their own.
+// Go generics don't allow to parametrize array sizes, so there is a type for
+// each of the common sizes. Enqueueing on a full array queue panics.
+
+var (
+	_ Queue[int] = &ArrayQueue4[int]{}
+	_ Queue[int] = &ArrayQueue8[int]{}
+	_ Queue[int] = &ArrayQueue16[int]{}
+)
+
+func arrayEnqueue[T any](buf []T, first, l *int, v T) {
+	if *l == len(buf) {
+		panic("enqueue on full array queue")
+	}
+	buf[(*first+*l)%len(buf)] = v
+	*l++
+}
+
+func arrayEnds[T any](buf []T, first, l int) (front, back T, ok bool) {
+	if l == 0 {
+		return front, back, false
+	}
+	return buf[first], buf[(first+l-1)%len(buf)], true
+}
+
+func arrayDequeue[T any](buf []T, first, l *int) T {
+	if *l == 0 {
+		panic(ErrEmptyQueue)
+	}
+	var zero T
+	v := buf[*first]
+	buf[*first] = zero
+	*first = (*first + 1) % len(buf)
+	*l--
+	return v
+}
+
+// ArrayQueue4 is a queue of at most 4 elements. The zero value is ready to use.
+type ArrayQueue4[T any] struct {
+	first, l int
+	buf      [4]T
+}
+
+func (aq *ArrayQueue4[T]) Len() int   { return aq.l }
+func (aq *ArrayQueue4[T]) Cap() int   { return len(aq.buf) }
+func (aq *ArrayQueue4[T]) Dequeue() T { return arrayDequeue(aq.buf[:], &aq.first, &aq.l) }
+func (aq *ArrayQueue4[T]) Ends() (front, back T, ok bool) {
+	return arrayEnds(aq.buf[:], aq.first, aq.l)
+}
+func (aq *ArrayQueue4[T]) Enqueue(v T) { arrayEnqueue(aq.buf[:], &aq.first, &aq.l, v) }
+
+// ArrayQueue8 is a queue of at most 8 elements. The zero value is ready to use.
+type ArrayQueue8[T any] struct {
+	first, l int
+	buf      [8]T
+}
+
+func (aq *ArrayQueue8[T]) Len() int   { return aq.l }
+func (aq *ArrayQueue8[T]) Cap() int   { return len(aq.buf) }
+func (aq *ArrayQueue8[T]) Dequeue() T { return arrayDequeue(aq.buf[:], &aq.first, &aq.l) }
+func (aq *ArrayQueue8[T]) Ends() (front, back T, ok bool) {
+	return arrayEnds(aq.buf[:], aq.first, aq.l)
+}
+func (aq *ArrayQueue8[T]) Enqueue(v T) { arrayEnqueue(aq.buf[:], &aq.first, &aq.l, v) }
+
+// ArrayQueue16 is a queue of at most 16 elements. The zero value is ready to use.
+type ArrayQueue16[T any] struct {
+	first, l int
+	buf      [16]T
+}
+
+func (aq *ArrayQueue16[T]) Len() int   { return aq.l }
+func (aq *ArrayQueue16[T]) Cap() int   { return len(aq.buf) }
+func (aq *ArrayQueue16[T]) Dequeue() T { return arrayDequeue(aq.buf[:], &aq.first, &aq.l) }
+func (aq *ArrayQueue16[T]) Ends() (front, back T, ok bool) {
+	return arrayEnds(aq.buf[:], aq.first, aq.l)
+}
+func (aq *ArrayQueue16[T]) Enqueue(v T) { arrayEnqueue(aq.buf[:], &aq.first, &aq.l, v) }
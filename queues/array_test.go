@@ -0,0 +1,93 @@
+package queues
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestArrayQueues(t *testing.T) {
+	tests := []struct {
+		name string
+		ctor func() Queue[int]
+	}{
+		{"4", func() Queue[int] { return &ArrayQueue4[int]{} }},
+		{"8", func() Queue[int] { return &ArrayQueue8[int]{} }},
+		{"16", func() Queue[int] { return &ArrayQueue16[int]{} }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := tt.ctor()
+			size := q.(capper).Cap()
+			var got []int
+			// Go around the array a few times.
+			for round := range 3 {
+				for i := range size {
+					q.Enqueue(round*size + i)
+				}
+				for range size {
+					got = append(got, q.Dequeue())
+				}
+			}
+			if diff := cmp.Diff(seq(0, 3*size-1), got); diff != "" {
+				t.Errorf("diff:\n%s", diff)
+			}
+
+			for i := range size {
+				q.Enqueue(i)
+			}
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Enqueue on full queue did not panic")
+				}
+			}()
+			q.Enqueue(size)
+		})
+	}
+}
+
+func TestArrayQueueAllocs(t *testing.T) {
+	var q ArrayQueue4[int]
+	allocs := testing.AllocsPerRun(100, func() {
+		for i := range 4 {
+			q.Enqueue(i)
+		}
+		for range 4 {
+			q.Dequeue()
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("allocs: got %v want 0", allocs)
+	}
+}
+
+/*
+BenchmarkArrayQueue/array_4         	71497816	        15.64 ns/op	       0 B/op	       0 allocs/op
+BenchmarkArrayQueue/ring            	12565260	        86.99 ns/op	      64 B/op	       1 allocs/op
+*/
+func BenchmarkArrayQueue(b *testing.B) {
+	b.Run("array 4", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			var q ArrayQueue4[int]
+			for i := range 4 {
+				q.Enqueue(i)
+			}
+			for range 4 {
+				q.Dequeue()
+			}
+		}
+	})
+	b.Run("ring", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			var q ringQueue[int]
+			for i := range 4 {
+				q.Enqueue(i)
+			}
+			for range 4 {
+				q.Dequeue()
+			}
+		}
+	})
+}
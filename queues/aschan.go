@@ -0,0 +1,37 @@
+package queues
+
+import "context"
+
+// AsChan drains q into the returned channel, so that queues can be used in a select.
+// The channel is closed when q is empty or when ctx is done, whichever comes first.
+// If ctx is done while an element is waiting to be received, the element is put
+// back at the front of q.
+//
+// Queues are not safe for concurrent use: q must not be accessed until the
+// returned channel is closed.
+func AsChan[T any](ctx context.Context, q Queue[T]) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		for q.Len() > 0 {
+			v := q.Dequeue()
+			select {
+			case out <- v:
+			case <-ctx.Done():
+				enqueueFront(q, v)
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// enqueueFront adds v at the front of q by rotating all other elements after it.
+// It costs O(Len) so it should only be used on slow paths.
+func enqueueFront[T any](q Queue[T], v T) {
+	q = storage(q)
+	q.Enqueue(v)
+	for range q.Len() - 1 {
+		q.Enqueue(q.Dequeue())
+	}
+}
@@ -0,0 +1,50 @@
+package queues
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAsChan(t *testing.T) {
+	for _, i := range impls {
+		t.Run(i.name+"/drain", func(t *testing.T) {
+			q := i.ctor()
+			for v := range 5 {
+				q.Enqueue(v)
+			}
+			var got []int
+			for v := range AsChan(context.Background(), q) {
+				got = append(got, v)
+			}
+			if diff := cmp.Diff([]int{0, 1, 2, 3, 4}, got); diff != "" {
+				t.Errorf("got %v diff:\n%s", got, diff)
+			}
+		})
+		t.Run(i.name+"/cancel", func(t *testing.T) {
+			q := i.ctor()
+			for v := range 5 {
+				q.Enqueue(v)
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			c := AsChan(ctx, q)
+			if got := <-c; got != 0 {
+				t.Fatalf("first receive: got %v want 0", got)
+			}
+			cancel()
+			// The goroutine might have already been sending the next value
+			// when the context got cancelled.
+			var got []int
+			for v := range c {
+				got = append(got, v)
+			}
+			for q.Len() > 0 {
+				got = append(got, q.Dequeue())
+			}
+			if diff := cmp.Diff([]int{1, 2, 3, 4}, got); diff != "" {
+				t.Errorf("got %v diff:\n%s", got, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package queues
+
+import (
+	"sync"
+	"time"
+)
+
+// BatchingQueue collects elements and passes them in batches to a flush
+// function, every interval or as soon as maxBatch elements are queued.
+// It is safe for concurrent use. Batches are passed to the flush function one
+// at a time and in order.
+type BatchingQueue[T any] struct {
+	flush    func([]T)
+	maxBatch int
+
+	mu  sync.Mutex
+	buf []T
+	// flushMu is held while a batch is being taken and flushed.
+	flushMu sync.Mutex
+
+	stop      chan struct{}
+	stopped   chan struct{}
+	closeOnce sync.Once
+}
+
+// NewBatchingQueue returns an empty BatchingQueue and starts its background
+// flusher. Call Close to stop it.
+func NewBatchingQueue[T any](interval time.Duration, maxBatch int, flush func([]T)) *BatchingQueue[T] {
+	bq := &BatchingQueue[T]{
+		flush:    flush,
+		maxBatch: maxBatch,
+		stop:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	go bq.run(interval)
+	return bq
+}
+
+func (bq *BatchingQueue[T]) run(interval time.Duration) {
+	defer close(bq.stopped)
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			bq.Flush()
+		case <-bq.stop:
+			return
+		}
+	}
+}
+
+// Len returns the amount of elements waiting to be flushed.
+func (bq *BatchingQueue[T]) Len() int {
+	bq.mu.Lock()
+	defer bq.mu.Unlock()
+	return len(bq.buf)
+}
+
+// Enqueue adds v to the current batch, flushing it if it is full.
+func (bq *BatchingQueue[T]) Enqueue(v T) {
+	bq.mu.Lock()
+	bq.buf = append(bq.buf, v)
+	full := len(bq.buf) >= bq.maxBatch
+	bq.mu.Unlock()
+	if full {
+		bq.Flush()
+	}
+}
+
+// Flush passes the queued elements to the flush function and waits for it to
+// return. Elements that are being flushed by the background flusher are
+// waited for as well, so when Flush returns all the elements enqueued before
+// the call have been flushed.
+func (bq *BatchingQueue[T]) Flush() {
+	bq.flushMu.Lock()
+	defer bq.flushMu.Unlock()
+	bq.mu.Lock()
+	batch := bq.buf
+	bq.buf = nil
+	bq.mu.Unlock()
+	if len(batch) > 0 {
+		bq.flush(batch)
+	}
+}
+
+// Close stops the background flusher and flushes the remaining elements.
+// The queue must not be used after Close.
+func (bq *BatchingQueue[T]) Close() error {
+	bq.closeOnce.Do(func() {
+		close(bq.stop)
+		<-bq.stopped
+		bq.Flush()
+	})
+	return nil
+}
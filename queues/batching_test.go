@@ -0,0 +1,89 @@
+package queues
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// batchRecorder collects the batches passed to a flush function.
+type batchRecorder struct {
+	mu      sync.Mutex
+	batches [][]int
+}
+
+func (br *batchRecorder) flush(b []int) {
+	br.mu.Lock()
+	defer br.mu.Unlock()
+	br.batches = append(br.batches, b)
+}
+
+func (br *batchRecorder) all() []int {
+	br.mu.Lock()
+	defer br.mu.Unlock()
+	return slices.Concat(br.batches...)
+}
+
+func TestBatchingQueueFlush(t *testing.T) {
+	var br batchRecorder
+	q := NewBatchingQueue(time.Hour, 100, br.flush)
+	defer q.Close()
+	for _, v := range seq(0, 9) {
+		q.Enqueue(v)
+	}
+	q.Flush()
+	if diff := cmp.Diff(seq(0, 9), br.all()); diff != "" {
+		t.Errorf("flushed diff:\n%s", diff)
+	}
+	if got := q.Len(); got != 0 {
+		t.Errorf("Len after Flush: got %v want 0", got)
+	}
+	q.Flush()
+	if got, want := len(br.batches), 1; got != want {
+		t.Errorf("batches after empty Flush: got %v want %v", got, want)
+	}
+}
+
+func TestBatchingQueueMaxBatch(t *testing.T) {
+	var br batchRecorder
+	q := NewBatchingQueue(time.Hour, 4, br.flush)
+	defer q.Close()
+	for _, v := range seq(0, 9) {
+		q.Enqueue(v)
+	}
+	want := [][]int{seq(0, 3), seq(4, 7)}
+	if diff := cmp.Diff(want, br.batches); diff != "" {
+		t.Errorf("batches diff:\n%s", diff)
+	}
+}
+
+func TestBatchingQueueConcurrent(t *testing.T) {
+	var br batchRecorder
+	q := NewBatchingQueue(time.Millisecond, 50, br.flush)
+	const producers, perProducer = 4, 500
+	var wg sync.WaitGroup
+	for p := range producers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range perProducer {
+				q.Enqueue(p*perProducer + i)
+				if i%100 == 0 {
+					q.Flush()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	// Everything enqueued so far must have been flushed when Flush returns.
+	q.Flush()
+	got := br.all()
+	q.Close()
+	slices.Sort(got)
+	if diff := cmp.Diff(seq(0, producers*perProducer-1), got); diff != "" {
+		t.Errorf("flushed elements diff:\n%s", diff)
+	}
+}
@@ -0,0 +1,91 @@
+package queues
+
+import (
+	"math/bits"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type candidate[T any] struct {
+	name string
+	ctor func() Queue[T]
+}
+
+func candidates[T any]() []candidate[T] {
+	return []candidate[T]{
+		{"slice", func() Queue[T] { return &sliceQueue[T]{} }},
+		{"ring", func() Queue[T] { return &ringQueue[T]{} }},
+		{"chan", func() Queue[T] { return newChanQueue[T]() }},
+		{"linked list", func() Queue[T] { return &linkedListQueue[T]{} }},
+		{"pooled linked list", func() Queue[T] { return newPooled[T]() }},
+		{"map", func() Queue[T] { return newMapQueue[T]() }},
+	}
+}
+
+type bestKey struct {
+	t      reflect.Type
+	bucket int
+}
+
+var (
+	// bestCache maps bestKey to the index of the winning candidate.
+	bestCache sync.Map
+	// bestRuns counts how many calibrations were performed.
+	bestRuns atomic.Int64
+)
+
+// maxCalibrationLen caps the size of the calibration workload.
+const maxCalibrationLen = 1 << 16
+
+// BestQueueFor returns an empty queue of the implementation that performs best
+// for elements of type T and queues of up to expectedMaxLen elements on this
+// machine.
+// The first call for a given type and order of magnitude of expectedMaxLen runs
+// a short benchmark of all implementations. The result is cached, so subsequent
+// calls are fast.
+func BestQueueFor[T any](expectedMaxLen int) Queue[T] {
+	cs := candidates[T]()
+	key := bestKey{
+		t:      reflect.TypeFor[T](),
+		bucket: bits.Len(uint(max(expectedMaxLen, 0))),
+	}
+	if i, ok := bestCache.Load(key); ok {
+		return cs[i.(int)].ctor()
+	}
+	i, _ := bestCache.LoadOrStore(key, calibrate(cs, min(1<<key.bucket, maxCalibrationLen)))
+	return cs[i.(int)].ctor()
+}
+
+// calibrate returns the index of the candidate that is fastest at filling up to
+// size and draining, with some jitter in between.
+func calibrate[T any](cs []candidate[T], size int) int {
+	bestRuns.Add(1)
+	const rounds = 3
+	var (
+		zero  T
+		best  int
+		bestD time.Duration
+	)
+	for i, c := range cs {
+		start := time.Now()
+		for range rounds {
+			q := c.ctor()
+			for range size {
+				q.Enqueue(zero)
+			}
+			for range size / 2 {
+				q.Dequeue()
+				q.Enqueue(zero)
+			}
+			for q.Len() > 0 {
+				q.Dequeue()
+			}
+		}
+		if d := time.Since(start); i == 0 || d < bestD {
+			best, bestD = i, d
+		}
+	}
+	return best
+}
@@ -0,0 +1,44 @@
+package queues
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBestQueueFor(t *testing.T) {
+	// Start from an empty cache, so that the test can be run more than once.
+	reset := func() {
+		bestCache.Clear()
+		bestRuns.Store(0)
+	}
+	reset()
+	t.Cleanup(reset)
+	type element struct{ a, b int }
+	before := bestRuns.Load()
+	first := BestQueueFor[element](1000)
+	if got, want := bestRuns.Load()-before, int64(1); got != want {
+		t.Fatalf("calibrations on first call: got %v want %v", got, want)
+	}
+	second := BestQueueFor[element](1000)
+	if got, want := bestRuns.Load()-before, int64(1); got != want {
+		t.Errorf("calibrations on second call: got %v want %v", got, want)
+	}
+	if reflect.TypeOf(first) != reflect.TypeOf(second) {
+		t.Errorf("got %T then %T, want the same implementation", first, second)
+	}
+	if first == second {
+		t.Errorf("got the same queue twice, want fresh ones")
+	}
+
+	// A different type needs its own calibration.
+	BestQueueFor[string](1000)
+	if got, want := bestRuns.Load()-before, int64(2); got != want {
+		t.Errorf("calibrations for a new type: got %v want %v", got, want)
+	}
+
+	q := BestQueueFor[int](10)
+	q.Enqueue(1)
+	if got := q.Dequeue(); got != 1 {
+		t.Errorf("Dequeue: got %v want 1", got)
+	}
+}
@@ -0,0 +1,121 @@
+package queues
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+var _ Queue[int] = &BlockingQueue[int]{}
+
+// BlockingQueue is a queue that is safe for concurrent use and whose Dequeue
+// blocks until an element is available.
+type BlockingQueue[T any] struct {
+	mu sync.Mutex
+	q  ringQueue[T]
+	// ready is closed when q is not empty.
+	ready chan struct{}
+}
+
+// NewBlockingQueue returns an empty BlockingQueue.
+func NewBlockingQueue[T any]() *BlockingQueue[T] {
+	return &BlockingQueue[T]{ready: make(chan struct{})}
+}
+
+func (bq *BlockingQueue[T]) Len() int {
+	bq.mu.Lock()
+	defer bq.mu.Unlock()
+	return bq.q.Len()
+}
+
+// ConcurrencySafe always returns true.
+func (bq *BlockingQueue[T]) ConcurrencySafe() bool {
+	return true
+}
+
+// Ends doesn't block if the queue is empty.
+func (bq *BlockingQueue[T]) Ends() (front, back T, ok bool) {
+	bq.mu.Lock()
+	defer bq.mu.Unlock()
+	return bq.q.Ends()
+}
+
+func (bq *BlockingQueue[T]) Enqueue(v T) {
+	bq.mu.Lock()
+	defer bq.mu.Unlock()
+	bq.q.Enqueue(v)
+	if bq.q.Len() == 1 {
+		close(bq.ready)
+	}
+}
+
+// tryDequeue returns the first element, or a channel to wait on if the queue is empty.
+func (bq *BlockingQueue[T]) tryDequeue() (v T, wait <-chan struct{}) {
+	bq.mu.Lock()
+	defer bq.mu.Unlock()
+	if bq.q.Len() == 0 {
+		return v, bq.ready
+	}
+	v = bq.q.Dequeue()
+	if bq.q.Len() == 0 {
+		bq.ready = make(chan struct{})
+	}
+	return v, nil
+}
+
+// Dequeue removes and returns the first element, waiting for one to be
+// enqueued if the queue is empty.
+func (bq *BlockingQueue[T]) Dequeue() T {
+	v, _ := bq.DequeueCtx(context.Background())
+	return v
+}
+
+// DequeueCtx is like Dequeue but it stops waiting when ctx is done, in which
+// case it returns the context error.
+func (bq *BlockingQueue[T]) DequeueCtx(ctx context.Context) (T, error) {
+	for {
+		v, wait := bq.tryDequeue()
+		if wait == nil {
+			return v, nil
+		}
+		select {
+		case <-wait:
+		case <-ctx.Done():
+			return v, ctx.Err()
+		}
+	}
+}
+
+// WaitNonEmpty blocks until the queue holds at least one element, without
+// removing it, or until ctx is done, in which case it returns the context error.
+// With multiple consumers, the element might be taken by another one before
+// the caller gets to dequeue it.
+func (bq *BlockingQueue[T]) WaitNonEmpty(ctx context.Context) error {
+	bq.mu.Lock()
+	ready := bq.ready
+	bq.mu.Unlock()
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// DequeueTimeout is like Dequeue but it waits at most d. It reports false if
+// no element was available in time.
+func (bq *BlockingQueue[T]) DequeueTimeout(d time.Duration) (T, bool) {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	for {
+		v, wait := bq.tryDequeue()
+		if wait == nil {
+			return v, true
+		}
+		select {
+		case <-wait:
+		case <-t.C:
+			return v, false
+		}
+	}
+}
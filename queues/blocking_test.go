@@ -0,0 +1,91 @@
+package queues
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBlockingQueue(t *testing.T) {
+	q := NewBlockingQueue[int]()
+	const producers, perProducer = 4, 1000
+	var wg sync.WaitGroup
+	for p := range producers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range perProducer {
+				q.Enqueue(p*perProducer + i)
+			}
+		}()
+	}
+	seen := make([]bool, producers*perProducer)
+	for range producers * perProducer {
+		seen[q.Dequeue()] = true
+	}
+	wg.Wait()
+	for i, ok := range seen {
+		if !ok {
+			t.Errorf("element %v was never dequeued", i)
+		}
+	}
+	if got := q.Len(); got != 0 {
+		t.Errorf("Len: got %v want 0", got)
+	}
+}
+
+func TestBlockingQueueCtx(t *testing.T) {
+	q := NewBlockingQueue[int]()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := q.DequeueCtx(ctx); err != context.DeadlineExceeded {
+		t.Errorf("DequeueCtx on empty queue: got %v want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestDequeueTimeout(t *testing.T) {
+	q := NewBlockingQueue[int]()
+	if v, ok := q.DequeueTimeout(10 * time.Millisecond); ok {
+		t.Errorf("DequeueTimeout on empty queue: got (%v, true) want false", v)
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		q.Enqueue(42)
+	}()
+	v, ok := q.DequeueTimeout(time.Minute)
+	if diff := cmp.Diff([]any{42, true}, []any{v, ok}); diff != "" {
+		t.Errorf("DequeueTimeout diff:\n%s", diff)
+	}
+}
+
+func TestWaitNonEmpty(t *testing.T) {
+	q := NewBlockingQueue[int]()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := q.WaitNonEmpty(ctx); err != context.DeadlineExceeded {
+		t.Errorf("WaitNonEmpty on empty queue: got %v want %v", err, context.DeadlineExceeded)
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		q.Enqueue(1)
+		q.Enqueue(2)
+	}()
+	if err := q.WaitNonEmpty(context.Background()); err != nil {
+		t.Fatalf("WaitNonEmpty: %v", err)
+	}
+	if got := q.Len(); got == 0 {
+		t.Fatalf("Len after WaitNonEmpty: got 0")
+	}
+	// Waiting again doesn't consume anything.
+	if err := q.WaitNonEmpty(context.Background()); err != nil {
+		t.Fatalf("WaitNonEmpty: %v", err)
+	}
+	if got := q.Dequeue(); got != 1 {
+		t.Errorf("Dequeue: got %v want 1", got)
+	}
+}
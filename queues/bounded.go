@@ -0,0 +1,192 @@
+package queues
+
+import "fmt"
+
+var _ Queue[int] = &BoundedQueue[int]{}
+
+// BoundedQueue is a ring backed queue that holds at most a fixed amount of elements.
+type BoundedQueue[T any] struct {
+	max      int
+	q        ringQueue[T]
+	overflow func(T)
+}
+
+// NewBoundedQueue returns an empty queue that holds at most capacity elements.
+// Use WithOverflow to handle the values rejected by Enqueue.
+func NewBoundedQueue[T any](capacity int, opts ...Option) *BoundedQueue[T] {
+	return &BoundedQueue[T]{max: capacity, overflow: overflowHandler[T](newOptions(opts))}
+}
+
+// overflowHandler returns the handler set with WithOverflow, if any.
+// It panics if it doesn't match the element type.
+func overflowHandler[T any](o options) func(T) {
+	if o.overflow == nil {
+		return nil
+	}
+	fn, ok := o.overflow.(func(T))
+	if !ok {
+		panic(fmt.Sprintf("overflow handler %T doesn't match the element type", o.overflow))
+	}
+	return fn
+}
+
+func (bq *BoundedQueue[T]) Len() int {
+	return bq.q.Len()
+}
+
+// Cap returns the maximum amount of elements the queue can hold.
+func (bq *BoundedQueue[T]) Cap() int {
+	return bq.max
+}
+
+func (bq *BoundedQueue[T]) Ends() (front, back T, ok bool) {
+	return bq.q.Ends()
+}
+
+func (bq *BoundedQueue[T]) Dequeue() T {
+	return bq.q.Dequeue()
+}
+
+// Enqueue adds v at the end of the queue. If the queue is full v is passed to
+// the overflow handler, if any, or discarded.
+func (bq *BoundedQueue[T]) Enqueue(v T) {
+	if !bq.TryEnqueue(v) && bq.overflow != nil {
+		bq.overflow(v)
+	}
+}
+
+// TryEnqueue adds v at the end of the queue, reporting false if the queue is full.
+func (bq *BoundedQueue[T]) TryEnqueue(v T) bool {
+	if bq.q.Len() >= bq.max {
+		return false
+	}
+	bq.q.Enqueue(v)
+	return true
+}
+
+var _ Queue[int] = &ByteBoundedQueue[int]{}
+
+// ByteBoundedQueue is a ring backed queue that holds elements up to a total
+// size, for elements whose size varies.
+type ByteBoundedQueue[T any] struct {
+	max, size int
+	sizeof    func(T) int
+	q         ringQueue[T]
+	overflow  func(T)
+}
+
+// NewByteBoundedQueue returns an empty queue that holds elements up to a total
+// of maxBytes, as computed by sizeof.
+// Use WithOverflow to handle the values rejected by Enqueue.
+func NewByteBoundedQueue[T any](maxBytes int, sizeof func(T) int, opts ...Option) *ByteBoundedQueue[T] {
+	return &ByteBoundedQueue[T]{
+		max:      maxBytes,
+		sizeof:   sizeof,
+		overflow: overflowHandler[T](newOptions(opts)),
+	}
+}
+
+func (bq *ByteBoundedQueue[T]) Len() int {
+	return bq.q.Len()
+}
+
+// Size returns the total size of the queued elements.
+func (bq *ByteBoundedQueue[T]) Size() int {
+	return bq.size
+}
+
+func (bq *ByteBoundedQueue[T]) Ends() (front, back T, ok bool) {
+	return bq.q.Ends()
+}
+
+func (bq *ByteBoundedQueue[T]) Dequeue() T {
+	v := bq.q.Dequeue()
+	bq.size -= bq.sizeof(v)
+	return v
+}
+
+// Enqueue adds v at the end of the queue. If v doesn't fit it is passed to the
+// overflow handler, if any, or discarded.
+func (bq *ByteBoundedQueue[T]) Enqueue(v T) {
+	if !bq.TryEnqueue(v) && bq.overflow != nil {
+		bq.overflow(v)
+	}
+}
+
+// TryEnqueue adds v at the end of the queue, reporting false if it would take
+// the total size past the limit.
+func (bq *ByteBoundedQueue[T]) TryEnqueue(v T) bool {
+	n := bq.sizeof(v)
+	if bq.size+n > bq.max {
+		return false
+	}
+	bq.q.Enqueue(v)
+	bq.size += n
+	return true
+}
+
+var _ Queue[int] = &PerKeyBoundedQueue[int, int]{}
+
+// PerKeyBoundedQueue is a ring backed queue that holds at most a fixed amount
+// of elements per key, so that no key can take over the queue.
+type PerKeyBoundedQueue[K comparable, V any] struct {
+	key      func(V) K
+	max      int
+	counts   map[K]int
+	q        ringQueue[V]
+	overflow func(V)
+}
+
+// NewPerKeyBoundedQueue returns an empty queue that holds at most maxPerKey
+// elements for each key, as computed by key.
+// Use WithOverflow to handle the values rejected by Enqueue.
+func NewPerKeyBoundedQueue[K comparable, V any](key func(V) K, maxPerKey int, opts ...Option) *PerKeyBoundedQueue[K, V] {
+	return &PerKeyBoundedQueue[K, V]{
+		key:      key,
+		max:      maxPerKey,
+		counts:   make(map[K]int),
+		overflow: overflowHandler[V](newOptions(opts)),
+	}
+}
+
+func (pq *PerKeyBoundedQueue[K, V]) Len() int {
+	return pq.q.Len()
+}
+
+// Count returns the amount of queued elements with key k.
+func (pq *PerKeyBoundedQueue[K, V]) Count(k K) int {
+	return pq.counts[k]
+}
+
+func (pq *PerKeyBoundedQueue[K, V]) Ends() (front, back V, ok bool) {
+	return pq.q.Ends()
+}
+
+func (pq *PerKeyBoundedQueue[K, V]) Dequeue() V {
+	v := pq.q.Dequeue()
+	k := pq.key(v)
+	if pq.counts[k]--; pq.counts[k] == 0 {
+		delete(pq.counts, k)
+	}
+	return v
+}
+
+// Enqueue adds v at the end of the queue. If its key is at the limit v is
+// passed to the overflow handler, if any, or discarded.
+func (pq *PerKeyBoundedQueue[K, V]) Enqueue(v V) {
+	if !pq.TryEnqueue(v) && pq.overflow != nil {
+		pq.overflow(v)
+	}
+}
+
+// TryEnqueue adds v at the end of the queue, reporting false if its key
+// already has the maximum amount of elements queued.
+func (pq *PerKeyBoundedQueue[K, V]) TryEnqueue(v V) bool {
+	k := pq.key(v)
+	if pq.counts[k] >= pq.max {
+		return false
+	}
+	pq.q.Enqueue(v)
+	pq.counts[k]++
+	return true
+}
@@ -0,0 +1,141 @@
+package queues
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBoundedQueue(t *testing.T) {
+	q := NewBoundedQueue[int](3)
+	var accepted []bool
+	for i := range 5 {
+		accepted = append(accepted, q.TryEnqueue(i))
+	}
+	if diff := cmp.Diff([]bool{true, true, true, false, false}, accepted); diff != "" {
+		t.Errorf("TryEnqueue diff:\n%s", diff)
+	}
+	q.Dequeue()
+	q.Enqueue(5)
+	q.Enqueue(6)
+	if diff := cmp.Diff([]int{1, 2, 5}, ToSlice[int](q)); diff != "" {
+		t.Errorf("contents diff:\n%s", diff)
+	}
+}
+
+func TestBoundedQueueOverflow(t *testing.T) {
+	var dropped []int
+	q := NewBoundedQueue[int](3, WithOverflow(func(v int) { dropped = append(dropped, v) }))
+	EnqueueMany[int](q, seq(0, 5))
+	q.Dequeue()
+	q.Enqueue(6)
+	q.Enqueue(7)
+	if diff := cmp.Diff([]int{3, 4, 5, 7}, dropped); diff != "" {
+		t.Errorf("dropped diff:\n%s", diff)
+	}
+	if diff := cmp.Diff([]int{1, 2, 6}, ToSlice[int](q)); diff != "" {
+		t.Errorf("contents diff:\n%s", diff)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("mismatched overflow handler did not panic")
+		}
+	}()
+	NewBoundedQueue[int](3, WithOverflow(func(string) {}))
+}
+
+func TestByteBoundedQueue(t *testing.T) {
+	var dropped []string
+	q := NewByteBoundedQueue(10, func(s string) int { return len(s) },
+		WithOverflow(func(s string) { dropped = append(dropped, s) }))
+	steps := []struct {
+		enqueue  string
+		dequeue  bool
+		want     bool
+		wantSize int
+	}{
+		{enqueue: "abcd", want: true, wantSize: 4},
+		{enqueue: "efg", want: true, wantSize: 7},
+		// Exactly at the limit.
+		{enqueue: "hij", want: true, wantSize: 10},
+		{enqueue: "k", want: false, wantSize: 10},
+		// Dequeuing "abcd" frees 4 bytes.
+		{dequeue: true, wantSize: 6},
+		{enqueue: "lmnop", want: false, wantSize: 6},
+		{enqueue: "lmno", want: true, wantSize: 10},
+		{enqueue: "", want: true, wantSize: 10},
+	}
+	for i, s := range steps {
+		if s.dequeue {
+			q.Dequeue()
+		} else if got := q.TryEnqueue(s.enqueue); got != s.want {
+			t.Errorf("step %v: TryEnqueue(%q): got %v want %v", i, s.enqueue, got, s.want)
+		}
+		if got := q.Size(); got != s.wantSize {
+			t.Errorf("step %v: Size: got %v want %v", i, got, s.wantSize)
+		}
+	}
+	q.Enqueue("q")
+	if diff := cmp.Diff([]string{"q"}, dropped); diff != "" {
+		t.Errorf("dropped diff:\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"efg", "hij", "lmno", ""}, DrainToSlice[string](q)); diff != "" {
+		t.Errorf("contents diff:\n%s", diff)
+	}
+	if got := q.Size(); got != 0 {
+		t.Errorf("Size after draining: got %v want 0", got)
+	}
+}
+
+func TestPerKeyBoundedQueue(t *testing.T) {
+	type task struct {
+		user string
+		id   int
+	}
+	var dropped []task
+	q := NewPerKeyBoundedQueue(func(t task) string { return t.user }, 2,
+		WithOverflow(func(t task) { dropped = append(dropped, t) }))
+	steps := []struct {
+		enqueue task
+		dequeue bool
+		want    bool
+	}{
+		{enqueue: task{"a", 1}, want: true},
+		{enqueue: task{"a", 2}, want: true},
+		// a is at the limit, b still accepts.
+		{enqueue: task{"a", 3}, want: false},
+		{enqueue: task{"b", 4}, want: true},
+		{enqueue: task{"b", 5}, want: true},
+		{enqueue: task{"b", 6}, want: false},
+		// Dequeueing a{1} makes room for one more a.
+		{dequeue: true},
+		{enqueue: task{"a", 7}, want: true},
+		{enqueue: task{"a", 8}, want: false},
+	}
+	for _, s := range steps {
+		if s.dequeue {
+			q.Dequeue()
+			continue
+		}
+		if got := q.TryEnqueue(s.enqueue); got != s.want {
+			t.Errorf("TryEnqueue(%v): got %v want %v", s.enqueue, got, s.want)
+		}
+	}
+	if got, want := q.Count("a"), 2; got != want {
+		t.Errorf(`Count("a"): got %v want %v`, got, want)
+	}
+	q.Enqueue(task{"a", 9})
+	if diff := cmp.Diff([]task{{"a", 9}}, dropped, cmp.AllowUnexported(task{})); diff != "" {
+		t.Errorf("dropped diff:\n%s", diff)
+	}
+	want := []task{{"a", 2}, {"b", 4}, {"b", 5}, {"a", 7}}
+	if diff := cmp.Diff(want, DrainToSlice[task](q), cmp.AllowUnexported(task{})); diff != "" {
+		t.Errorf("contents diff:\n%s", diff)
+	}
+	for _, k := range []string{"a", "b"} {
+		if got := q.Count(k); got != 0 {
+			t.Errorf("Count(%q) after draining: got %v want 0", k, got)
+		}
+	}
+}
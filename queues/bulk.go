@@ -0,0 +1,91 @@
+package queues
+
+// WithExactCapacity makes allocations size the backing store to exactly the
+// amount of elements requested instead of rounding it up to the next growth
+// step. This trades future reallocations for less memory slack.
+func WithExactCapacity() Option {
+	return func(o *options) {
+		o.exactCap = true
+	}
+}
+
+// reserver is implemented by queues that have a backing store that can be
+// preallocated.
+type reserver interface {
+	reserve(n int, exact bool)
+}
+
+var (
+	_ reserver = &sliceQueue[int]{}
+	_ reserver = &ringQueue[int]{}
+	_ reserver = newChanQueue[int]()
+)
+
+// Reserve makes sure q can hold n more elements without reallocating.
+// It is a no-op for queues that don't have a preallocated backing store.
+func Reserve[T any](q Queue[T], n int, opts ...Option) {
+	if r, ok := q.(reserver); ok {
+		r.reserve(n, newOptions(opts).exactCap)
+	}
+}
+
+// EnqueueMany adds all of vs at the end of q, allocating at most once.
+func EnqueueMany[T any](q Queue[T], vs []T, opts ...Option) {
+	Reserve(q, len(vs), opts...)
+	for _, v := range vs {
+		q.Enqueue(v)
+	}
+}
+
+// CopyFrom adds all elements of src at the end of dst in FIFO order, leaving
+// src unchanged. The two queues can have different implementations.
+func CopyFrom[T any](dst, src Queue[T], opts ...Option) {
+	EnqueueMany(dst, ToSlice(src), opts...)
+}
+
+// Concat moves all elements of src at the end of dst in FIFO order, leaving
+// src empty. It panics if dst and src are the same queue.
+// If AdoptFrom can move the backing store of src no element is copied,
+// otherwise they are dequeued from src and enqueued in dst one by one.
+func Concat[T any](dst, src Queue[T], opts ...Option) {
+	if dst == src {
+		panic("concat of a queue with itself")
+	}
+	if AdoptFrom(dst, src) {
+		return
+	}
+	Reserve(dst, src.Len(), opts...)
+	for src.Len() > 0 {
+		dst.Enqueue(src.Dequeue())
+	}
+}
+
+// AdoptFrom moves the backing store of src to dst in O(1), leaving src empty,
+// and reports whether it did.
+// It only does so if dst is empty and both are slice backed or both are ring
+// backed queues, otherwise it leaves both queues unchanged.
+func AdoptFrom[T any](dst, src Queue[T]) bool {
+	if dst.Len() != 0 {
+		return false
+	}
+	switch d := dst.(type) {
+	case *sliceQueue[T]:
+		if s, ok := src.(*sliceQueue[T]); ok && s != d {
+			d.adopt(s)
+			return true
+		}
+	case *ringQueue[T]:
+		if s, ok := src.(*ringQueue[T]); ok && s != d {
+			d.adopt(s)
+			return true
+		}
+	}
+	return false
+}
+
+// FromSlice returns a ring backed queue holding a copy of s.
+func FromSlice[T any](s []T, opts ...Option) Queue[T] {
+	var rq ringQueue[T]
+	EnqueueMany[T](&rq, s, opts...)
+	return &rq
+}
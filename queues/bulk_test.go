@@ -0,0 +1,257 @@
+package queues
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type capper interface {
+	Cap() int
+}
+
+func TestFromSlice(t *testing.T) {
+	s := []int{0, 1, 2, 3, 4}
+	tests := []struct {
+		name    string
+		opts    []Option
+		wantCap int
+	}{
+		{"default", nil, 8},
+		{"exact", []Option{WithExactCapacity()}, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := FromSlice(s, tt.opts...)
+			if got := q.(capper).Cap(); got != tt.wantCap {
+				t.Errorf("Cap: got %v want %v", got, tt.wantCap)
+			}
+			var got []int
+			for q.Len() > 0 {
+				got = append(got, q.Dequeue())
+			}
+			if diff := cmp.Diff(s, got); diff != "" {
+				t.Errorf("got %v want %v diff:\n%s", got, s, diff)
+			}
+		})
+	}
+}
+
+func TestEnqueueManyExact(t *testing.T) {
+	for _, i := range impls {
+		t.Run(i.name, func(t *testing.T) {
+			q := i.ctor()
+			q.Enqueue(-1)
+			EnqueueMany(q, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, WithExactCapacity())
+			if _, ok := q.(reserver); ok {
+				if got, want := q.(capper).Cap(), 11; got != want {
+					t.Errorf("Cap: got %v want %v", got, want)
+				}
+			}
+			if got, want := q.Len(), 11; got != want {
+				t.Errorf("Len: got %v want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestEnqueueManyBurst(t *testing.T) {
+	ctors := []struct {
+		name string
+		ctor func() Queue[int]
+	}{
+		{"slice", func() Queue[int] { return &sliceQueue[int]{} }},
+		{"ring", func() Queue[int] { return &ringQueue[int]{} }},
+		{"chan", func() Queue[int] { return newChanQueue[int]() }},
+	}
+	tests := []struct {
+		name        string
+		start, push int
+		wantCap     int
+	}{
+		// Doubling would need four steps, up to 128.
+		{"larger than a growth step", 1, 100, 101},
+		{"smaller than a growth step", 8, 3, 16},
+	}
+	for _, c := range ctors {
+		for _, tt := range tests {
+			t.Run(c.name+"/"+tt.name, func(t *testing.T) {
+				q := c.ctor()
+				EnqueueMany(q, seq(0, tt.start-1))
+				if got := q.(capper).Cap(); got != baseLen {
+					t.Fatalf("Cap before the burst: got %v want %v", got, baseLen)
+				}
+				EnqueueMany(q, seq(tt.start, tt.start+tt.push-1))
+				if got := q.(capper).Cap(); got != tt.wantCap {
+					t.Errorf("Cap after the burst: got %v want %v", got, tt.wantCap)
+				}
+				if diff := cmp.Diff(seq(0, tt.start+tt.push-1), DrainToSlice(q)); diff != "" {
+					t.Errorf("contents diff:\n%s", diff)
+				}
+			})
+		}
+	}
+}
+
+func TestReserveExact(t *testing.T) {
+	for _, q := range []Queue[int]{&sliceQueue[int]{}, &ringQueue[int]{}} {
+		Reserve(q, 1000, WithExactCapacity())
+		if got, want := q.(capper).Cap(), 1000; got != want {
+			t.Errorf("%T Cap: got %v want %v", q, got, want)
+		}
+		// Reserving less than the current capacity doesn't shrink.
+		Reserve(q, 10, WithExactCapacity())
+		if got, want := q.(capper).Cap(), 1000; got != want {
+			t.Errorf("%T Cap: got %v want %v", q, got, want)
+		}
+	}
+}
+
+/*
+BenchmarkEnqueueManyBurst/slice/one_by_one         	   12982	     83607 ns/op	  357648 B/op	      17 allocs/op
+BenchmarkEnqueueManyBurst/slice/enqueue_many       	   27675	     41544 ns/op	   82080 B/op	       3 allocs/op
+BenchmarkEnqueueManyBurst/ring/one_by_one          	   14442	     79644 ns/op	  262160 B/op	      13 allocs/op
+BenchmarkEnqueueManyBurst/ring/enqueue_many        	   24378	     51094 ns/op	   82080 B/op	       3 allocs/op
+BenchmarkEnqueueManyBurst/chan/one_by_one          	    1188	   1054025 ns/op	  291624 B/op	      13 allocs/op
+BenchmarkEnqueueManyBurst/chan/enqueue_many        	    5017	    244419 ns/op	   82184 B/op	       4 allocs/op
+*/
+func BenchmarkEnqueueManyBurst(b *testing.B) {
+	const burst = 10_000
+	vs := seq(0, burst-1)
+	ctors := []struct {
+		name string
+		ctor func() Queue[int]
+	}{
+		{"slice", func() Queue[int] { return &sliceQueue[int]{} }},
+		{"ring", func() Queue[int] { return &ringQueue[int]{} }},
+		{"chan", func() Queue[int] { return newChanQueue[int]() }},
+	}
+	for _, c := range ctors {
+		b.Run(c.name+"/one_by_one", func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				q := c.ctor()
+				for _, v := range vs {
+					q.Enqueue(v)
+				}
+			}
+		})
+		b.Run(c.name+"/enqueue_many", func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				EnqueueMany(c.ctor(), vs)
+			}
+		})
+	}
+}
+
+func TestCopyFrom(t *testing.T) {
+	src := NewRingQueue[int]()
+	// Wrap the ring so that the copy has to follow the FIFO order.
+	EnqueueMany(src, seq(0, 9))
+	for range 5 {
+		src.Dequeue()
+	}
+	EnqueueMany(src, seq(10, 12))
+
+	dst := NewSliceQueue[int]()
+	dst.Enqueue(-1)
+	CopyFrom(dst, src)
+	if diff := cmp.Diff(append([]int{-1}, seq(5, 12)...), ToSlice(dst)); diff != "" {
+		t.Errorf("dst diff:\n%s", diff)
+	}
+	if diff := cmp.Diff(seq(5, 12), ToSlice(src)); diff != "" {
+		t.Errorf("src was modified, diff:\n%s", diff)
+	}
+
+	// Copying a queue onto itself duplicates its elements.
+	CopyFrom(src, src)
+	if diff := cmp.Diff(append(seq(5, 12), seq(5, 12)...), ToSlice(src)); diff != "" {
+		t.Errorf("self copy diff:\n%s", diff)
+	}
+}
+
+func TestAdoptFrom(t *testing.T) {
+	t.Run("simple slice", func(t *testing.T) {
+		dst, src := &sliceQueue[int]{}, &sliceQueue[int]{}
+		EnqueueMany(src, seq(0, 9))
+		src.Dequeue()
+		buf := src.s
+		if !AdoptFrom[int](dst, src) {
+			t.Fatalf("AdoptFrom: got false want true")
+		}
+		if &dst.s[0] != &buf[0] || cap(dst.s) != cap(buf) {
+			t.Errorf("AdoptFrom copied the backing array")
+		}
+		if diff := cmp.Diff(seq(1, 9), ToSlice[int](dst)); diff != "" {
+			t.Errorf("dst diff:\n%s", diff)
+		}
+		checkEnds(t, src, 0, 0, false)
+		src.Enqueue(42)
+		checkEnds(t, src, 42, 42, true)
+	})
+	t.Run("ring slice", func(t *testing.T) {
+		dst, src := &ringQueue[int]{}, &ringQueue[int]{}
+		// Wrap the ring around.
+		EnqueueMany(src, seq(-5, 9))
+		for range 5 {
+			src.Dequeue()
+		}
+		EnqueueMany(src, seq(10, 12))
+		buf := src.buf
+		if !AdoptFrom[int](dst, src) {
+			t.Fatalf("AdoptFrom: got false want true")
+		}
+		if &dst.buf[0] != &buf[0] || len(dst.buf) != len(buf) {
+			t.Errorf("AdoptFrom copied the backing buffer")
+		}
+		if diff := cmp.Diff(seq(0, 12), ToSlice[int](dst)); diff != "" {
+			t.Errorf("dst diff:\n%s", diff)
+		}
+		checkEnds(t, src, 0, 0, false)
+		src.Enqueue(42)
+		checkEnds(t, src, 42, 42, true)
+	})
+	t.Run("copy-on-write clone", func(t *testing.T) {
+		dst, src := &ringQueue[int]{}, &ringQueue[int]{}
+		EnqueueMany(src, seq(0, 4))
+		clone := src.CloneCOW()
+		AdoptFrom[int](dst, src)
+		// Writing to dst must not affect the clone that shares its buffer.
+		ReplaceFront[int](dst, 42)
+		if diff := cmp.Diff(seq(0, 4), ToSlice(clone)); diff != "" {
+			t.Errorf("clone diff:\n%s", diff)
+		}
+	})
+	t.Run("fallback", func(t *testing.T) {
+		for _, tt := range []struct {
+			name     string
+			dst, src Queue[int]
+		}{
+			{"non-empty dst", FromSlice([]int{-1}), FromSlice(seq(0, 4))},
+			{"different types", &sliceQueue[int]{}, FromSlice(seq(0, 4))},
+		} {
+			want := append(ToSlice(tt.dst), seq(0, 4)...)
+			if AdoptFrom(tt.dst, tt.src) {
+				t.Errorf("%s: AdoptFrom: got true want false", tt.name)
+			}
+			if got := tt.src.Len(); got != 5 {
+				t.Errorf("%s: src Len after AdoptFrom: got %v want 5", tt.name, got)
+			}
+			Concat(tt.dst, tt.src)
+			if diff := cmp.Diff(want, ToSlice(tt.dst)); diff != "" {
+				t.Errorf("%s: Concat diff:\n%s", tt.name, diff)
+			}
+			checkEnds(t, tt.src, 0, 0, false)
+		}
+	})
+	t.Run("same queue", func(t *testing.T) {
+		q := FromSlice(seq(0, 4))
+		defer func() {
+			if recover() == nil {
+				t.Errorf("Concat of a queue with itself: got no panic")
+			}
+		}()
+		Concat(q, q)
+	})
+}
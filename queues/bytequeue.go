@@ -0,0 +1,153 @@
+package queues
+
+import "io"
+
+var (
+	_ io.ReadWriter = &ByteQueue{}
+	_ io.WriterTo   = &ByteQueue{}
+	_ io.ReaderFrom = &ByteQueue{}
+)
+
+// ByteQueue is a FIFO of bytes backed by a ring buffer.
+// Write enqueues bytes and Read dequeues them.
+// Differently from bytes.Buffer, consumed bytes at the front are reused instead
+// of being leaked until the next reallocation.
+// The zero value is an empty queue ready to use.
+type ByteQueue struct {
+	first, l int
+	buf      []byte
+}
+
+// Len returns the amount of bytes stored.
+func (bq *ByteQueue) Len() int {
+	return bq.l
+}
+
+func (bq *ByteQueue) swapBuf(n []byte) {
+	if bq.first+bq.l > len(bq.buf) {
+		skip := copy(n, bq.buf[bq.first:])
+		copy(n[skip:], bq.buf[:bq.l-skip])
+	} else {
+		copy(n, bq.buf[bq.first:bq.first+bq.l])
+	}
+	bq.first = 0
+	bq.buf = n
+}
+
+func (bq *ByteQueue) checkShrink() {
+	nl, ok := shouldShrink(bq.l, len(bq.buf))
+	if !ok {
+		return
+	}
+	bq.swapBuf(make([]byte, nl))
+}
+
+func (bq *ByteQueue) grow(need int) {
+	bq.swapBuf(make([]byte, growCap(len(bq.buf), need)))
+}
+
+// GrowBytes makes sure the queue can hold n more bytes without reallocating.
+// It is the ByteQueue analog of Reserve.
+func (bq *ByteQueue) GrowBytes(n int) {
+	if need := bq.l + n; need > len(bq.buf) {
+		bq.grow(need)
+	}
+}
+
+// Write enqueues all of p. It never returns an error.
+func (bq *ByteQueue) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if need := bq.l + len(p); need > len(bq.buf) {
+		bq.grow(need)
+	}
+	end := (bq.first + bq.l) % len(bq.buf)
+	n := copy(bq.buf[end:], p)
+	copy(bq.buf, p[n:])
+	bq.l += len(p)
+	return len(p), nil
+}
+
+// Read dequeues up to len(p) bytes into p.
+// Reading from an empty queue returns 0, nil instead of io.EOF, as more bytes
+// might be written later on.
+func (bq *ByteQueue) Read(p []byte) (int, error) {
+	n := min(len(p), bq.l)
+	if n == 0 {
+		return 0, nil
+	}
+	c := copy(p[:n], bq.buf[bq.first:])
+	copy(p[c:n], bq.buf)
+	bq.first = (bq.first + n) % len(bq.buf)
+	bq.l -= n
+	bq.checkShrink()
+	return n, nil
+}
+
+// Bytes returns the queued bytes without copying them, as two slices that
+// have to be read in order. second is empty unless the bytes wrap around the
+// end of the ring.
+// The slices alias the queue and are only valid until the next call that
+// modifies it. Use Discard to dequeue the bytes once they are consumed.
+func (bq *ByteQueue) Bytes() (first, second []byte) {
+	if bq.l == 0 {
+		return nil, nil
+	}
+	first = bq.buf[bq.first:min(bq.first+bq.l, len(bq.buf))]
+	return first, bq.buf[:bq.l-len(first)]
+}
+
+// Discard dequeues the first n bytes without reading them.
+// It panics if n is negative or greater than Len.
+func (bq *ByteQueue) Discard(n int) {
+	if n < 0 || n > bq.l {
+		panic("discard out of range")
+	}
+	if n == 0 {
+		return
+	}
+	bq.first = (bq.first + n) % len(bq.buf)
+	bq.l -= n
+	bq.checkShrink()
+}
+
+// WriteTo drains the queue into w.
+// This allows io.Copy to terminate when the queue is empty.
+func (bq *ByteQueue) WriteTo(w io.Writer) (int64, error) {
+	var tot int64
+	for bq.l > 0 {
+		chunk := bq.buf[bq.first:min(bq.first+bq.l, len(bq.buf))]
+		n, err := w.Write(chunk)
+		tot += int64(n)
+		bq.first = (bq.first + n) % len(bq.buf)
+		bq.l -= n
+		if err != nil {
+			return tot, err
+		}
+		if n < len(chunk) {
+			return tot, io.ErrShortWrite
+		}
+	}
+	bq.checkShrink()
+	return tot, nil
+}
+
+// ReadFrom enqueues everything read from r until io.EOF.
+func (bq *ByteQueue) ReadFrom(r io.Reader) (int64, error) {
+	var (
+		tot int64
+		b   [512]byte
+	)
+	for {
+		n, err := r.Read(b[:])
+		bq.Write(b[:n])
+		tot += int64(n)
+		if err == io.EOF {
+			return tot, nil
+		}
+		if err != nil {
+			return tot, err
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package queues
+
+import (
+	"bytes"
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestByteQueueWrap(t *testing.T) {
+	var bq ByteQueue
+	bq.Write([]byte("abcdef"))
+	b := make([]byte, 4)
+	if n, _ := bq.Read(b); n != 4 {
+		t.Fatalf("Read: got %v want %v", n, 4)
+	}
+	// Buffer is 8 bytes long, this wraps.
+	bq.Write([]byte("ghijk"))
+	if bq.first+bq.l <= len(bq.buf) {
+		t.Fatalf("queue did not wrap: first=%v len=%v buf=%v", bq.first, bq.l, len(bq.buf))
+	}
+	b = make([]byte, 10)
+	n, err := bq.Read(b)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if got, want := string(b[:n]), "efghijk"; got != want {
+		t.Errorf("Read: got %q want %q", got, want)
+	}
+	if n, err := bq.Read(b); n != 0 || err != nil {
+		t.Errorf("Read on empty: got (%v, %v) want (0, nil)", n, err)
+	}
+}
+
+func TestByteQueueCopy(t *testing.T) {
+	want := make([]byte, 10_000)
+	for i := range want {
+		want[i] = byte(i)
+	}
+	var bq ByteQueue
+	// Misalign the ring so that the copy crosses the boundary.
+	bq.Write([]byte("xyz"))
+	bq.Read(make([]byte, 3))
+	if _, err := io.Copy(&bq, bytes.NewReader(want)); err != nil {
+		t.Fatalf("io.Copy into queue: %v", err)
+	}
+	if got, want := bq.Len(), len(want); got != want {
+		t.Fatalf("Len: got %v want %v", got, want)
+	}
+	var got bytes.Buffer
+	if _, err := io.Copy(&got, &bq); err != nil {
+		t.Fatalf("io.Copy from queue: %v", err)
+	}
+	if diff := cmp.Diff(want, got.Bytes()); diff != "" {
+		t.Errorf("round trip diff:\n%s", diff)
+	}
+	if bq.Len() != 0 {
+		t.Errorf("Len after drain: got %v want 0", bq.Len())
+	}
+}
+
+func TestByteQueueBytes(t *testing.T) {
+	var bq ByteQueue
+	if first, second := bq.Bytes(); len(first)+len(second) != 0 {
+		t.Errorf("Bytes on empty queue: got (%q, %q) want empty", first, second)
+	}
+	bq.Write([]byte("abcdef"))
+	bq.Read(make([]byte, 4))
+	// Buffer is 8 bytes long, this wraps.
+	bq.Write([]byte("ghijk"))
+	first, second := bq.Bytes()
+	if len(second) == 0 {
+		t.Fatalf("Bytes: got (%q, %q), want two slices across the wrap", first, second)
+	}
+	if got, want := string(first)+string(second), "efghijk"; got != want {
+		t.Errorf("Bytes: got %q want %q", got, want)
+	}
+	steps := []struct {
+		discard int
+		want    string
+	}{
+		{0, "efghijk"},
+		{2, "ghijk"},
+		// Crosses the wrap boundary.
+		{3, "jk"},
+		{2, ""},
+	}
+	for _, s := range steps {
+		bq.Discard(s.discard)
+		first, second := bq.Bytes()
+		if got := string(first) + string(second); got != s.want {
+			t.Errorf("Bytes after Discard(%v): got %q want %q", s.discard, got, s.want)
+		}
+		if got, want := bq.Len(), len(s.want); got != want {
+			t.Errorf("Len after Discard(%v): got %v want %v", s.discard, got, want)
+		}
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Discard past the end did not panic")
+		}
+	}()
+	bq.Discard(1)
+}
+
+func TestByteQueueBytesNoWrap(t *testing.T) {
+	var bq ByteQueue
+	bq.Write([]byte("abc"))
+	first, second := bq.Bytes()
+	if string(first) != "abc" || len(second) != 0 {
+		t.Errorf("Bytes: got (%q, %q) want (%q, %q)", first, second, "abc", "")
+	}
+	if n := testing.AllocsPerRun(100, func() { bq.Bytes() }); n != 0 {
+		t.Errorf("Bytes allocated %v times", n)
+	}
+}
+
+func TestByteQueueGrowBytes(t *testing.T) {
+	var bq ByteQueue
+	// Wrap the ring, so that growing has to unwrap the readable region.
+	bq.Write([]byte("0123456789"))
+	bq.Read(make([]byte, 6))
+	bq.Write([]byte("abc"))
+
+	const n = 1 << 20
+	bq.GrowBytes(n)
+	buf := bq.buf
+	if len(buf) < bq.Len()+n {
+		t.Fatalf("capacity after GrowBytes(%v): got %v want at least %v", n, len(buf), bq.Len()+n)
+	}
+	data := bytes.Repeat([]byte("xyz"), n/3)
+	for chunk := range slices.Chunk(data, 4096) {
+		bq.Write(chunk)
+	}
+	if &bq.buf[0] != &buf[0] || len(bq.buf) != len(buf) {
+		t.Errorf("Write after GrowBytes reallocated the buffer")
+	}
+	var got bytes.Buffer
+	bq.WriteTo(&got)
+	if want := append([]byte("6789abc"), data...); !bytes.Equal(got.Bytes(), want) {
+		t.Errorf("contents after GrowBytes differ: got %d bytes want %d", got.Len(), len(want))
+	}
+
+	// Growing within the current capacity is a no-op.
+	bq.Write([]byte("a"))
+	buf = bq.buf
+	bq.GrowBytes(len(buf) - 1)
+	if &bq.buf[0] != &buf[0] {
+		t.Errorf("GrowBytes within capacity reallocated the buffer")
+	}
+}
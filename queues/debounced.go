@@ -0,0 +1,63 @@
+package queues
+
+import "time"
+
+var (
+	_ Queue[int]     = &DebouncedQueue[int]{}
+	_ unwrapper[int] = &DebouncedQueue[int]{}
+)
+
+// DebouncedQueue is a ring backed queue that coalesces bursts of enqueues:
+// an element enqueued within a window of the previous enqueue is merged into
+// the tail instead of being appended.
+// Helpers like ForEach and ToSlice rotate the underlying ring directly, so they
+// don't merge its elements.
+type DebouncedQueue[T any] struct {
+	q       ringQueue[T]
+	clock   Clock
+	window  time.Duration
+	combine func(a, b T) T
+	last    time.Time
+}
+
+// NewDebouncedQueue returns an empty DebouncedQueue that merges elements
+// enqueued less than window after the previous one with combine(tail, v).
+// Use WithClock to control the passing of time.
+func NewDebouncedQueue[T any](window time.Duration, combine func(a, b T) T, opts ...Option) *DebouncedQueue[T] {
+	return &DebouncedQueue[T]{
+		clock:   newOptions(opts).clock,
+		window:  window,
+		combine: combine,
+	}
+}
+
+func (dq *DebouncedQueue[T]) Len() int {
+	return dq.q.Len()
+}
+
+func (dq *DebouncedQueue[T]) Dequeue() T {
+	return dq.q.Dequeue()
+}
+
+func (dq *DebouncedQueue[T]) Ends() (front, back T, ok bool) {
+	return dq.q.Ends()
+}
+
+func (dq *DebouncedQueue[T]) unwrap() Queue[T] {
+	return &dq.q
+}
+
+// Enqueue appends v, or merges it into the tail if the previous enqueue
+// happened within the window. Since the window restarts at every enqueue, a
+// steady stream of close enqueues keeps being merged into a single element.
+// Once the tail has been dequeued there is nothing to merge into, and v is
+// appended.
+func (dq *DebouncedQueue[T]) Enqueue(v T) {
+	now := dq.clock.Now()
+	if l := dq.q.Len(); l > 0 && now.Sub(dq.last) < dq.window {
+		dq.q.set(l-1, dq.combine(dq.q.at(l-1), v))
+	} else {
+		dq.q.Enqueue(v)
+	}
+	dq.last = now
+}
@@ -0,0 +1,82 @@
+package queues
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDebouncedQueue(t *testing.T) {
+	const ms = time.Millisecond
+	sum := func(a, b int) int { return a + b }
+	tests := []struct {
+		name string
+		// gaps[i] is how long to wait before enqueuing i+1.
+		gaps []time.Duration
+		want []int
+	}{
+		{"single", []time.Duration{0}, []int{1}},
+		{"all within window", []time.Duration{0, 5 * ms, 5 * ms, 5 * ms}, []int{10}},
+		{"all outside window", []time.Duration{0, 10 * ms, 20 * ms}, []int{1, 2, 3}},
+		{"boundary is outside", []time.Duration{0, 9 * ms, 10 * ms}, []int{3, 3}},
+		{"bursts", []time.Duration{0, 1 * ms, 1 * ms, 50 * ms, 1 * ms, 50 * ms}, []int{6, 9, 6}},
+		{"window restarts at every enqueue", []time.Duration{0, 9 * ms, 9 * ms, 9 * ms}, []int{10}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &fakeClock{now: time.Unix(0, 0)}
+			q := NewDebouncedQueue(10*ms, sum, WithClock(clock))
+			for i, gap := range tt.gaps {
+				clock.Advance(gap)
+				q.Enqueue(i + 1)
+			}
+			if diff := cmp.Diff(tt.want, DrainToSlice[int](q)); diff != "" {
+				t.Errorf("Dequeue diff:\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("tail dequeued", func(t *testing.T) {
+		clock := &fakeClock{now: time.Unix(0, 0)}
+		q := NewDebouncedQueue(10*ms, sum, WithClock(clock))
+		q.Enqueue(1)
+		if got := q.Dequeue(); got != 1 {
+			t.Errorf("Dequeue: got %v want 1", got)
+		}
+		// There is nothing to merge into, so this starts a new element.
+		q.Enqueue(2)
+		q.Enqueue(3)
+		if diff := cmp.Diff([]int{5}, DrainToSlice[int](q)); diff != "" {
+			t.Errorf("Dequeue diff:\n%s", diff)
+		}
+	})
+}
+
+func TestDebouncedQueueRotation(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	q := NewDebouncedQueue(time.Second, func(a, b int) int { return a + b }, WithClock(clock))
+	for v := range 3 {
+		clock.Advance(time.Minute)
+		q.Enqueue(v + 1)
+	}
+	// The helpers run within the window of the last enqueue, and must not
+	// merge the rotated elements.
+	if diff := cmp.Diff([]int{1, 2, 3}, ToSlice[int](q)); diff != "" {
+		t.Errorf("ToSlice diff:\n%s", diff)
+	}
+	var got []int
+	ForEach[int](q, func(v int) bool {
+		got = append(got, v)
+		return true
+	})
+	if diff := cmp.Diff([]int{1, 2, 3}, got); diff != "" {
+		t.Errorf("ForEach diff:\n%s", diff)
+	}
+	if got := q.Len(); got != 3 {
+		t.Errorf("Len after rotating: got %v want 3", got)
+	}
+	if !QueueContains[int](q, 2) {
+		t.Errorf("QueueContains(2): got false want true")
+	}
+}
@@ -0,0 +1,57 @@
+package queues
+
+import "time"
+
+type delayed[T any] struct {
+	v     T
+	ready time.Time
+}
+
+// DelayQueue holds elements until a given time, and hands them out in the
+// order in which they become ready. Elements with the same ready time are
+// handed out in FIFO order.
+// It doesn't implement Queue, as Dequeue can fail when elements are queued but
+// none is ready yet.
+type DelayQueue[T any] struct {
+	q     Queue[delayed[T]]
+	clock Clock
+}
+
+// NewDelayQueue returns an empty DelayQueue.
+// Use WithClock to control the passing of time.
+func NewDelayQueue[T any](opts ...Option) *DelayQueue[T] {
+	return &DelayQueue[T]{
+		q: NewStablePriorityQueue(func(a, b delayed[T]) bool {
+			return a.ready.Before(b.ready)
+		}),
+		clock: newOptions(opts).clock,
+	}
+}
+
+// Len returns the amount of elements queued, including the ones that are not
+// ready yet.
+func (dq *DelayQueue[T]) Len() int {
+	return dq.q.Len()
+}
+
+// EnqueueAt adds v to the queue, to be dequeued once ready has passed.
+func (dq *DelayQueue[T]) EnqueueAt(v T, ready time.Time) {
+	dq.q.Enqueue(delayed[T]{v: v, ready: ready})
+}
+
+// Dequeue removes and returns the element that became ready first, reporting
+// false if no element is ready yet.
+func (dq *DelayQueue[T]) Dequeue() (t T, ok bool) {
+	next, _, ok := dq.q.Ends()
+	if !ok || next.ready.After(dq.clock.Now()) {
+		return t, false
+	}
+	return dq.q.Dequeue().v, true
+}
+
+// NextReady returns the time at which the next element becomes ready, which
+// might be in the past. It reports false if the queue is empty.
+func (dq *DelayQueue[T]) NextReady() (time.Time, bool) {
+	next, _, ok := dq.q.Ends()
+	return next.ready, ok
+}
@@ -0,0 +1,64 @@
+package queues
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDelayQueue(t *testing.T) {
+	start := time.Unix(0, 0)
+	clock := &fakeClock{now: start}
+	q := NewDelayQueue[string](WithClock(clock))
+	if _, ok := q.Dequeue(); ok {
+		t.Errorf("Dequeue on empty queue: got ok want !ok")
+	}
+	if _, ok := q.NextReady(); ok {
+		t.Errorf("NextReady on empty queue: got ok want !ok")
+	}
+
+	q.EnqueueAt("c", start.Add(3*time.Second))
+	q.EnqueueAt("a", start.Add(1*time.Second))
+	q.EnqueueAt("b1", start.Add(2*time.Second))
+	q.EnqueueAt("b2", start.Add(2*time.Second))
+	q.EnqueueAt("now", start)
+
+	// dequeueDue dequeues all the elements that are due.
+	dequeueDue := func() []string {
+		var got []string
+		for {
+			v, ok := q.Dequeue()
+			if !ok {
+				return got
+			}
+			got = append(got, v)
+		}
+	}
+	steps := []struct {
+		advance   time.Duration
+		want      []string
+		wantNext  time.Duration
+		wantAnyOK bool
+	}{
+		{0, []string{"now"}, 1 * time.Second, true},
+		{500 * time.Millisecond, nil, 1 * time.Second, true},
+		{500 * time.Millisecond, []string{"a"}, 2 * time.Second, true},
+		// Elements due at the same time come out in FIFO order, and
+		// elements that became due in the meantime in time order.
+		{5 * time.Second, []string{"b1", "b2", "c"}, 0, false},
+	}
+	for i, s := range steps {
+		clock.Advance(s.advance)
+		if diff := cmp.Diff(s.want, dequeueDue()); diff != "" {
+			t.Errorf("step %v: dequeued diff:\n%s", i, diff)
+		}
+		next, ok := q.NextReady()
+		if ok != s.wantAnyOK || (ok && next.Sub(start) != s.wantNext) {
+			t.Errorf("step %v: NextReady: got (%v, %v) want (%v, %v)", i, next.Sub(start), ok, s.wantNext, s.wantAnyOK)
+		}
+	}
+	if got := q.Len(); got != 0 {
+		t.Errorf("Len after draining: got %v want 0", got)
+	}
+}
@@ -0,0 +1,208 @@
+package queues
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Codec encodes and decodes queue elements to and from bytes.
+type Codec[T any] interface {
+	Encode(t T) ([]byte, error)
+	Decode(b []byte) (T, error)
+}
+
+// GobCodec is a Codec that uses encoding/gob.
+type GobCodec[T any] struct{}
+
+func (GobCodec[T]) Encode(t T) ([]byte, error) {
+	var b bytes.Buffer
+	if err := gob.NewEncoder(&b).Encode(t); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func (GobCodec[T]) Decode(b []byte) (t T, err error) {
+	err = gob.NewDecoder(bytes.NewReader(b)).Decode(&t)
+	return t, err
+}
+
+// File
+
+var _ Queue[int] = &fileQueue[int]{}
+
+// recordHeader is the size of the length prefix of every record.
+const recordHeader = 4
+
+type fileQueue[T any] struct {
+	len      int
+	off, end int64
+	// last is the offset of the last record, valid only if len>0.
+	last    int64
+	data    *os.File
+	offFile *os.File
+	codec   Codec[T]
+}
+
+// NewFileQueue opens or creates a queue persisted at path.
+// Elements are appended to the file at path as length-prefixed records and the
+// read offset is persisted in a sibling file with an ".off" suffix, so that a
+// queue reopened after a restart continues from where it left off.
+// If the last record was only partially written it is discarded.
+//
+// Since the Queue interface doesn't allow to return errors, Enqueue and Dequeue
+// panic on I/O or encoding failures.
+// The returned Queue implements io.Closer.
+func NewFileQueue[T any](path string, codec Codec[T]) (Queue[T], error) {
+	data, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	offFile, err := os.OpenFile(path+".off", os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		data.Close()
+		return nil, err
+	}
+	fq := &fileQueue[T]{data: data, offFile: offFile, codec: codec}
+	if err := fq.recover(); err != nil {
+		fq.Close()
+		return nil, fmt.Errorf("recovering %q: %w", path, err)
+	}
+	return fq, nil
+}
+
+// recover reads the persisted offset, counts the pending records and truncates
+// a trailing incomplete record, if any.
+func (fq *fileQueue[T]) recover() error {
+	var b [8]byte
+	switch _, err := fq.offFile.ReadAt(b[:], 0); {
+	case err == nil:
+		fq.off = int64(binary.BigEndian.Uint64(b[:]))
+	case errors.Is(err, io.EOF):
+		// Fresh queue, or the offset was never persisted.
+	default:
+		return err
+	}
+	st, err := fq.data.Stat()
+	if err != nil {
+		return err
+	}
+	size := st.Size()
+	// The data file might have been truncated after it was emptied but before
+	// the offset was reset.
+	fq.off = min(fq.off, size)
+	fq.end = fq.off
+	for {
+		n, err := fq.readHeader(fq.end)
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		next := fq.end + recordHeader + int64(n)
+		if next > size {
+			break
+		}
+		fq.last = fq.end
+		fq.end = next
+		fq.len++
+	}
+	if fq.end < size {
+		return fq.data.Truncate(fq.end)
+	}
+	return nil
+}
+
+func (fq *fileQueue[T]) readHeader(at int64) (uint32, error) {
+	var h [recordHeader]byte
+	if _, err := fq.data.ReadAt(h[:], at); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32(h[:]), nil
+}
+
+func (fq *fileQueue[T]) persistOff() error {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(fq.off))
+	_, err := fq.offFile.WriteAt(b[:], 0)
+	return err
+}
+
+func (fq *fileQueue[T]) Len() int {
+	return fq.len
+}
+
+// readRecord decodes the record at the given offset and returns it along with
+// its encoded size.
+func (fq *fileQueue[T]) readRecord(at int64) (T, uint32) {
+	n, err := fq.readHeader(at)
+	if err != nil {
+		panic(err)
+	}
+	rec := make([]byte, n)
+	if _, err := fq.data.ReadAt(rec, at+recordHeader); err != nil {
+		panic(err)
+	}
+	v, err := fq.codec.Decode(rec)
+	if err != nil {
+		panic(err)
+	}
+	return v, n
+}
+
+func (fq *fileQueue[T]) Ends() (front, back T, ok bool) {
+	if fq.len == 0 {
+		return front, back, false
+	}
+	front, _ = fq.readRecord(fq.off)
+	back, _ = fq.readRecord(fq.last)
+	return front, back, true
+}
+
+func (fq *fileQueue[T]) Dequeue() T {
+	if fq.len == 0 {
+		panic(ErrEmptyQueue)
+	}
+	v, n := fq.readRecord(fq.off)
+	fq.len--
+	fq.off += recordHeader + int64(n)
+	if fq.len == 0 {
+		// Reclaim disk space. The data is truncated first so that a crash
+		// before the offset is reset doesn't replay consumed records.
+		if err := fq.data.Truncate(0); err != nil {
+			panic(err)
+		}
+		fq.off, fq.end = 0, 0
+	}
+	if err := fq.persistOff(); err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func (fq *fileQueue[T]) Enqueue(v T) {
+	rec, err := fq.codec.Encode(v)
+	if err != nil {
+		panic(err)
+	}
+	b := make([]byte, recordHeader+len(rec))
+	binary.BigEndian.PutUint32(b, uint32(len(rec)))
+	copy(b[recordHeader:], rec)
+	if _, err := fq.data.WriteAt(b, fq.end); err != nil {
+		panic(err)
+	}
+	fq.last = fq.end
+	fq.end += int64(len(b))
+	fq.len++
+}
+
+// Close closes the underlying files.
+func (fq *fileQueue[T]) Close() error {
+	return errors.Join(fq.data.Close(), fq.offFile.Close())
+}
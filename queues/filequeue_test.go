@@ -0,0 +1,77 @@
+package queues
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFileQueue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue")
+	open := func() Queue[int] {
+		t.Helper()
+		q, err := NewFileQueue(path, GobCodec[int]{})
+		if err != nil {
+			t.Fatalf("NewFileQueue: %v", err)
+		}
+		return q
+	}
+	closeQueue := func(q Queue[int]) {
+		t.Helper()
+		if err := q.(io.Closer).Close(); err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+	}
+
+	q := open()
+	for i := range 5 {
+		q.Enqueue(i)
+	}
+	if got, want := q.Dequeue(), 0; got != want {
+		t.Errorf("Dequeue: got %v want %v", got, want)
+	}
+	if got, want := q.Dequeue(), 1; got != want {
+		t.Errorf("Dequeue: got %v want %v", got, want)
+	}
+	closeQueue(q)
+
+	// Simulate a crash in the middle of writing a record.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte{0, 0, 0, 42, 1, 2})
+	f.Close()
+
+	q = open()
+	if got, want := q.Len(), 3; got != want {
+		t.Fatalf("Len after reopen: got %v want %v", got, want)
+	}
+	checkEnds(t, q, 2, 4, true)
+	q.Enqueue(5)
+	var got []int
+	for q.Len() > 0 {
+		got = append(got, q.Dequeue())
+	}
+	if diff := cmp.Diff([]int{2, 3, 4, 5}, got); diff != "" {
+		t.Errorf("got %v diff:\n%s", got, diff)
+	}
+	closeQueue(q)
+
+	// Draining the queue reclaims the space on disk.
+	st, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Size() != 0 {
+		t.Errorf("file size after drain: got %v want 0", st.Size())
+	}
+	q = open()
+	defer closeQueue(q)
+	if got := q.Len(); got != 0 {
+		t.Errorf("Len after drain and reopen: got %v want 0", got)
+	}
+}
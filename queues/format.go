@@ -0,0 +1,132 @@
+package queues
+
+import (
+	"fmt"
+	"iter"
+	"strings"
+)
+
+// formatEnds is the amount of elements shown at each end of long queues.
+const formatEnds = 4
+
+// formatQueue formats a queue of length l as
+// "Queue[impl=<impl> len=<l> cap=<c>: [<elems>]]", omitting the capacity if c
+// is negative. If l is more than 2*formatEnds only the elements at the ends are
+// shown, around an ellipsis.
+// elems must yield the elements in FIFO order without modifying the queue.
+func formatQueue[T any](impl string, l, c int, elems iter.Seq[T]) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Queue[impl=%s len=%d", impl, l)
+	if c >= 0 {
+		fmt.Fprintf(&b, " cap=%d", c)
+	}
+	b.WriteString(": [")
+	i := 0
+	for v := range elems {
+		switch {
+		case l > 2*formatEnds && i == formatEnds:
+			b.WriteString(" ...")
+		case l > 2*formatEnds && i > formatEnds && i < l-formatEnds:
+		default:
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			fmt.Fprint(&b, v)
+		}
+		i++
+	}
+	b.WriteString("]]")
+	return b.String()
+}
+
+// sliceSeq returns an iterator over s.
+func sliceSeq[T any](s []T) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for _, v := range s {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// listSeq returns an iterator over the list starting at head.
+func listSeq[T any](head *elem[T]) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for e := head; e != nil; e = e.next {
+			if !yield(e.v) {
+				return
+			}
+		}
+	}
+}
+
+func (sq *sliceQueue[T]) String() string {
+	return formatQueue("slice", sq.Len(), sq.Cap(), sliceSeq(sq.s))
+}
+
+func (sq *linkedListQueue[T]) String() string {
+	return formatQueue("linkedlist", sq.len, -1, listSeq(sq.head))
+}
+
+func (sq *linkedListPooledQueue[T]) String() string {
+	return formatQueue("pooledlist", sq.len, -1, listSeq(sq.head))
+}
+
+func (sq *linkedListBatchedQueue[T]) String() string {
+	return formatQueue("batchedlist", sq.len, -1, listSeq(sq.head))
+}
+
+func (sq *slabQueue[T]) String() string {
+	return formatQueue("slab", sq.len, -1, func(yield func(T) bool) {
+		for i := range sq.slabs.Len() {
+			s := sq.slabs.at(i)
+			start, end := 0, len(s)
+			if i == 0 {
+				start = sq.r
+			}
+			if i == sq.slabs.Len()-1 {
+				end = sq.w
+			}
+			for _, v := range s[start:end] {
+				if !yield(v) {
+					return
+				}
+			}
+		}
+	})
+}
+
+// String rotates the channel to read its elements, so it must not be called
+// concurrently with other operations on the queue.
+func (cq *chanQueue[T]) String() string {
+	return formatQueue("chan", cq.Len(), cq.Cap(), func(yield func(T) bool) {
+		n, cont := len(*cq), true
+		// Complete the rotation even if yield stops early.
+		for range n {
+			v := <-*cq
+			*cq <- v
+			cont = cont && yield(v)
+		}
+	})
+}
+
+func (sq *ringQueue[T]) String() string {
+	return formatQueue("ring", sq.l, sq.Cap(), func(yield func(T) bool) {
+		for i := range sq.l {
+			if !yield(sq.at(i)) {
+				return
+			}
+		}
+	})
+}
+
+func (mq *mapQueue[T]) String() string {
+	return formatQueue("map", mq.Len(), -1, func(yield func(T) bool) {
+		for k := mq.first; k != mq.last; k++ {
+			if !yield(mq.mem[k]) {
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,80 @@
+package queues
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestString(t *testing.T) {
+	format := regexp.MustCompile(`^Queue\[impl=\w+ len=(\d+)( cap=(\d+))?: \[(.*)\]\]$`)
+	tests := []struct {
+		name   string
+		values []int
+		want   string
+	}{
+		{"empty", nil, ""},
+		{"small", seq(1, 3), "1 2 3"},
+		{"at the limit", seq(0, 2*formatEnds-1), "0 1 2 3 4 5 6 7"},
+		{"truncated", seq(0, 99), "0 1 2 3 ... 96 97 98 99"},
+	}
+	for _, i := range impls {
+		t.Run(i.name, func(t *testing.T) {
+			for _, tt := range tests {
+				q := newWrapped(i.ctor, tt.values)
+				front, back, ok := q.Ends()
+				var capBefore int
+				if c, isCapper := q.(capper); isCapper {
+					capBefore = c.Cap()
+				}
+
+				s := fmt.Sprint(q)
+				m := format.FindStringSubmatch(s)
+				if m == nil {
+					t.Fatalf("%s: String: %q doesn't match %v", tt.name, s, format)
+				}
+				if got, want := m[1], fmt.Sprint(len(tt.values)); got != want {
+					t.Errorf("%s: len in %q: got %v want %v", tt.name, s, got, want)
+				}
+				if got := m[4]; got != tt.want {
+					t.Errorf("%s: elements in %q: got %q want %q", tt.name, s, got, tt.want)
+				}
+				if c, isCapper := q.(capper); isCapper {
+					if got, want := m[3], fmt.Sprint(c.Cap()); got != want {
+						t.Errorf("%s: cap in %q: got %v want %v", tt.name, s, got, want)
+					}
+					if c.Cap() != capBefore {
+						t.Errorf("%s: Cap after String: got %v want %v", tt.name, c.Cap(), capBefore)
+					}
+				}
+
+				// String leaves the queue unchanged.
+				checkEnds(t, q, front, back, ok)
+				if diff := cmp.Diff(tt.values, ToSlice(q), cmpEmpty); diff != "" {
+					t.Errorf("%s: contents after String diff:\n%s", tt.name, diff)
+				}
+			}
+		})
+	}
+}
+
+func TestStringFormat(t *testing.T) {
+	q := &ringQueue[string]{}
+	q.reserve(8, true)
+	EnqueueMany(q, []string{"s", "t", "u", "v", "x", "y", "a", "b"})
+	for range 6 {
+		q.Dequeue()
+	}
+	q.Enqueue("c")
+	if q.first+q.l <= len(q.buf) {
+		t.Fatalf("ring did not wrap: first=%v len=%v buf=%v", q.first, q.l, len(q.buf))
+	}
+	if got, want := q.String(), "Queue[impl=ring len=3 cap=8: [a b c]]"; got != want {
+		t.Errorf("String: got %q want %q", got, want)
+	}
+	if got, want := newMapQueue[string]().String(), "Queue[impl=map len=0: []]"; got != want {
+		t.Errorf("String of empty map queue: got %q want %q", got, want)
+	}
+}
@@ -0,0 +1,587 @@
+package queues
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// Pair holds two values.
+type Pair[A, B any] struct {
+	First  A
+	Second B
+}
+
+// Zip dequeues from a and b in lockstep until either is empty, and returns a
+// queue of the resulting pairs. Surplus elements are left in the longer queue.
+func Zip[A, B any](a Queue[A], b Queue[B]) Queue[Pair[A, B]] {
+	var r sliceQueue[Pair[A, B]]
+	r.reserve(min(a.Len(), b.Len()), true)
+	for a.Len() > 0 && b.Len() > 0 {
+		r.Enqueue(Pair[A, B]{a.Dequeue(), b.Dequeue()})
+	}
+	return &r
+}
+
+// WindowJoin dequeues from a and b, which must be sorted by the timestamps
+// returned by tsA and tsB, and pairs elements whose timestamps are at most
+// tolerance apart. Each element is paired at most once: when the fronts don't
+// match, the one that is behind is dropped, as it can't match any later
+// element of the other queue. It stops when either queue is empty, leaving
+// the remaining elements in the other one.
+func WindowJoin[A, B any](a Queue[A], b Queue[B], tsA func(A) time.Time, tsB func(B) time.Time, tolerance time.Duration) Queue[Pair[A, B]] {
+	var r sliceQueue[Pair[A, B]]
+	for a.Len() > 0 && b.Len() > 0 {
+		fa, _, _ := a.Ends()
+		fb, _, _ := b.Ends()
+		ta, tb := tsA(fa), tsB(fb)
+		switch d := ta.Sub(tb); {
+		case d < -tolerance:
+			a.Dequeue()
+		case d > tolerance:
+			b.Dequeue()
+		default:
+			r.Enqueue(Pair[A, B]{a.Dequeue(), b.Dequeue()})
+		}
+	}
+	return &r
+}
+
+// unwrapper is implemented by wrappers that store their elements unchanged in
+// an inner queue, but whose Enqueue or Dequeue don't just pass elements
+// through, for example because they filter, merge or pace them.
+// Helpers that rotate queues or put elements back go through the inner queue
+// instead, so that the wrapper doesn't see rotated elements as new or consumed
+// ones.
+type unwrapper[T any] interface {
+	unwrap() Queue[T]
+}
+
+// storage returns the innermost queue that holds the elements of q.
+func storage[T any](q Queue[T]) Queue[T] {
+	for {
+		u, ok := q.(unwrapper[T])
+		if !ok {
+			return q
+		}
+		q = u.unwrap()
+	}
+}
+
+// indexer is implemented by queues that would record rotated elements as new
+// ones, and that can instead read their i-th element in place.
+type indexer[T any] interface {
+	nth(i int) T
+}
+
+// ForEach calls fn on every element of q in FIFO order, until fn returns false.
+// The queue is left unchanged, but it is rotated while iterating, so fn must
+// not access q. If fn panics the rotation is completed before the panic
+// propagates, so q is left unchanged in that case too.
+// Queues that keep state about their elements, like ReplayableQueue, are
+// iterated in place instead.
+func ForEach[T any](q Queue[T], fn func(T) bool) {
+	q = storage(q)
+	if ix, ok := q.(indexer[T]); ok {
+		for i := range q.Len() {
+			if !fn(ix.nth(i)) {
+				return
+			}
+		}
+		return
+	}
+	n, done := q.Len(), 0
+	defer func() {
+		for ; done < n; done++ {
+			q.Enqueue(q.Dequeue())
+		}
+	}()
+	cont := true
+	for done < n {
+		v := q.Dequeue()
+		q.Enqueue(v)
+		done++
+		if cont {
+			cont = fn(v)
+		}
+	}
+}
+
+// Map returns a new queue with the result of fn on every element of q, in
+// FIFO order. q is left unchanged.
+func Map[T, U any](q Queue[T], fn func(T) U) Queue[U] {
+	var r ringQueue[U]
+	r.reserve(q.Len(), true)
+	ForEach(q, func(v T) bool {
+		r.Enqueue(fn(v))
+		return true
+	})
+	return &r
+}
+
+// Filter returns a new queue with the elements of q for which keep returns
+// true, in FIFO order. q is left unchanged.
+// The result is sized for all elements of q to avoid reallocations, and it
+// shrinks as it is drained.
+func Filter[T any](q Queue[T], keep func(T) bool) Queue[T] {
+	var r ringQueue[T]
+	r.reserve(q.Len(), true)
+	ForEach(q, func(v T) bool {
+		if keep(v) {
+			r.Enqueue(v)
+		}
+		return true
+	})
+	return &r
+}
+
+// Reduce folds the elements of q in FIFO order, starting from init.
+// q is left unchanged.
+func Reduce[T, A any](q Queue[T], init A, fn func(acc A, v T) A) A {
+	acc := init
+	ForEach(q, func(v T) bool {
+		acc = fn(acc, v)
+		return true
+	})
+	return acc
+}
+
+// QueueContains reports whether v is in q, leaving q unchanged.
+// Comparisons stop at the first match, but q still has to be rotated in full.
+func QueueContains[T comparable](q Queue[T], v T) bool {
+	found := false
+	ForEach(q, func(e T) bool {
+		found = e == v
+		return !found
+	})
+	return found
+}
+
+// Any reports whether pred returns true for at least one element of q,
+// leaving q unchanged. pred is not called after the first match.
+func Any[T any](q Queue[T], pred func(T) bool) bool {
+	found := false
+	ForEach(q, func(v T) bool {
+		found = pred(v)
+		return !found
+	})
+	return found
+}
+
+// Every reports whether pred returns true for all elements of q, leaving q
+// unchanged. pred is not called after the first mismatch.
+// It is true for an empty queue.
+func Every[T any](q Queue[T], pred func(T) bool) bool {
+	return !Any(q, func(v T) bool { return !pred(v) })
+}
+
+// EqualUnordered reports whether a and b hold the same elements the same
+// amount of times, regardless of their order. Both queues are left unchanged.
+func EqualUnordered[T comparable](a, b Queue[T]) bool {
+	return EqualUnorderedFunc(a, b, func(v T) T { return v })
+}
+
+// EqualUnorderedFunc is like EqualUnordered, but it compares the keys computed
+// by key, so it can be used with types that are not comparable.
+func EqualUnorderedFunc[T any, K comparable](a, b Queue[T], key func(T) K) bool {
+	if a.Len() != b.Len() {
+		return false
+	}
+	counts := make(map[K]int)
+	ForEach(a, func(v T) bool {
+		counts[key(v)]++
+		return true
+	})
+	equal := true
+	ForEach(b, func(v T) bool {
+		k := key(v)
+		counts[k]--
+		equal = counts[k] >= 0
+		return equal
+	})
+	return equal
+}
+
+// Dedup wraps inner so that enqueueing a value equal to the last one in the
+// queue is a no-op.
+func Dedup[T comparable](inner Queue[T]) Queue[T] {
+	return DedupFunc(inner, func(a, b T) bool { return a == b })
+}
+
+// DedupFunc is like Dedup but it uses eq to compare elements, so it can be used
+// with types that are not comparable.
+// Helpers like ForEach and ToSlice rotate inner directly, so rotated elements
+// are never dropped as duplicates.
+func DedupFunc[T any](inner Queue[T], eq func(a, b T) bool) Queue[T] {
+	return &dedupQueue[T]{inner: inner, eq: eq}
+}
+
+var (
+	_ Queue[int]     = &dedupQueue[int]{}
+	_ unwrapper[int] = &dedupQueue[int]{}
+)
+
+type dedupQueue[T any] struct {
+	inner Queue[T]
+	eq    func(a, b T) bool
+	// last is the last element in the queue, valid only if inner is not empty.
+	last T
+}
+
+func (dq *dedupQueue[T]) Len() int {
+	return dq.inner.Len()
+}
+
+func (dq *dedupQueue[T]) Ends() (front, back T, ok bool) {
+	return dq.inner.Ends()
+}
+
+func (dq *dedupQueue[T]) Dequeue() T {
+	return dq.inner.Dequeue()
+}
+
+func (dq *dedupQueue[T]) unwrap() Queue[T] {
+	return dq.inner
+}
+
+func (dq *dedupQueue[T]) Enqueue(v T) {
+	if dq.inner.Len() > 0 && dq.eq(dq.last, v) {
+		return
+	}
+	dq.last = v
+	dq.inner.Enqueue(v)
+}
+
+// Partition drains q into two new queues: match holds the elements for which
+// pred returns true and rest holds the others. Relative order is preserved.
+// Like for Filter, both results are sized for all elements of q.
+// The elements are only removed from q once pred was called on all of them, so
+// if pred panics q is left unchanged.
+func Partition[T any](q Queue[T], pred func(T) bool) (match, rest Queue[T]) {
+	var m, r ringQueue[T]
+	m.reserve(q.Len(), true)
+	r.reserve(q.Len(), true)
+	ForEach(q, func(v T) bool {
+		if pred(v) {
+			m.Enqueue(v)
+		} else {
+			r.Enqueue(v)
+		}
+		return true
+	})
+	for q.Len() > 0 {
+		q.Dequeue()
+	}
+	return &m, &r
+}
+
+// WriteTo drains q into w, writing each element as encoded by encode, and
+// returns the amount of bytes written.
+// It stops at the first encoding or write error, in which case the element
+// that failed and all the ones after it are left in q.
+func WriteTo[T any](q Queue[T], w io.Writer, encode func(T) ([]byte, error)) (n int64, err error) {
+	for {
+		v, _, ok := q.Ends()
+		if !ok {
+			return n, nil
+		}
+		b, err := encode(v)
+		if err != nil {
+			return n, err
+		}
+		m, err := w.Write(b)
+		n += int64(m)
+		if err == nil && m < len(b) {
+			err = io.ErrShortWrite
+		}
+		if err != nil {
+			return n, err
+		}
+		q.Dequeue()
+	}
+}
+
+// DequeueLen removes the element at the front of q and returns it along with
+// the amount of elements left in q.
+// Like Dequeue, it panics if q is empty.
+func DequeueLen[T any](q Queue[T]) (t T, remaining int) {
+	t = q.Dequeue()
+	return t, q.Len()
+}
+
+// matchDequeuer is implemented by queues that can remove elements from the
+// middle without rotating.
+type matchDequeuer[T any] interface {
+	DequeueMatching(pred func(T) bool) (T, bool)
+}
+
+var (
+	_ matchDequeuer[int] = &sliceQueue[int]{}
+	_ matchDequeuer[int] = &ringQueue[int]{}
+	_ matchDequeuer[int] = &linkedListQueue[int]{}
+	_ matchDequeuer[int] = &linkedListPooledQueue[int]{}
+)
+
+// DequeueMatching removes and returns the first element of q for which pred
+// returns true, preserving the order of the others. It reports false if there
+// is none. pred is not called after the first match.
+// It takes O(Len) time: slice, ring and linked list queues remove the element
+// in place, others are rotated in full.
+// If pred panics q is left unchanged.
+func DequeueMatching[T any](q Queue[T], pred func(T) bool) (t T, ok bool) {
+	q = storage(q)
+	if md, isMD := q.(matchDequeuer[T]); isMD {
+		return md.DequeueMatching(pred)
+	}
+	// Like in ForEach, complete the rotation if pred panics, putting back the
+	// element it was called on.
+	n, done := q.Len(), 0
+	var v T
+	inFlight := false
+	defer func() {
+		if inFlight {
+			q.Enqueue(v)
+			done++
+		}
+		for ; done < n; done++ {
+			q.Enqueue(q.Dequeue())
+		}
+	}()
+	for ; done < n; done++ {
+		v, inFlight = q.Dequeue(), true
+		if !ok && pred(v) {
+			t, ok = v, true
+		} else {
+			q.Enqueue(v)
+		}
+		inFlight = false
+	}
+	return t, ok
+}
+
+// TrimFront dequeues elements from the front of q until at most n are left,
+// and returns how many were removed.
+func TrimFront[T any](q Queue[T], n int) int {
+	trimmed := 0
+	for ; q.Len() > max(n, 0); trimmed++ {
+		q.Dequeue()
+	}
+	return trimmed
+}
+
+// DropWhile removes elements from the front of q while pred returns true for
+// them, stopping at the first for which it returns false, and returns how many
+// were removed. The removed elements are discarded without being collected.
+// It peeks at every element with Ends before removing it, which takes O(1)
+// for all the queues in this package except the chan backed one.
+func DropWhile[T any](q Queue[T], pred func(T) bool) int {
+	if dw, ok := q.(interface{ DropWhile(func(T) bool) int }); ok {
+		return dw.DropWhile(pred)
+	}
+	n := 0
+	for {
+		front, _, ok := q.Ends()
+		if !ok || !pred(front) {
+			return n
+		}
+		q.Dequeue()
+		n++
+	}
+}
+
+// backTrimmer is implemented by queues that can remove elements from the back
+// without rotating.
+type backTrimmer interface {
+	TrimBack(n int) int
+}
+
+var (
+	_ backTrimmer = &sliceQueue[int]{}
+	_ backTrimmer = &ringQueue[int]{}
+)
+
+// TrimBack removes the most recently enqueued elements of q until at most n
+// are left, and returns how many were removed.
+// Slice and ring queues drop the elements in place in O(removed) time, others
+// are rotated in full.
+func TrimBack[T any](q Queue[T], n int) int {
+	q = storage(q)
+	if bt, ok := q.(backTrimmer); ok {
+		return bt.TrimBack(n)
+	}
+	l := q.Len()
+	n = max(n, 0)
+	if l <= n {
+		return 0
+	}
+	for i := range l {
+		v := q.Dequeue()
+		if i < n {
+			q.Enqueue(v)
+		}
+	}
+	return l - n
+}
+
+// PeekBack returns the most recently enqueued element of q without removing
+// it. ok is false if q is empty.
+func PeekBack[T any](q Queue[T]) (t T, ok bool) {
+	_, t, ok = q.Ends()
+	return t, ok
+}
+
+// endReplacer is implemented by queues that can replace their first and last
+// elements in place.
+type endReplacer[T any] interface {
+	ReplaceFront(v T) (T, bool)
+	ReplaceBack(v T) (T, bool)
+}
+
+var (
+	_ endReplacer[int] = &sliceQueue[int]{}
+	_ endReplacer[int] = &ringQueue[int]{}
+	_ endReplacer[int] = &linkedListQueue[int]{}
+	_ endReplacer[int] = &linkedListPooledQueue[int]{}
+	_ endReplacer[int] = &linkedListBatchedQueue[int]{}
+	_ endReplacer[int] = &slabQueue[int]{}
+	_ endReplacer[int] = &mapQueue[int]{}
+)
+
+// ReplaceFront replaces the first element of q with v and returns the old one.
+// ok is false, and q is left untouched, if q is empty.
+// It takes O(1) time for the queues of this package, except for the chan
+// backed one, and O(Len) for others, which are rotated in full.
+// Wrappers that filter or transform enqueued elements, like
+// NewTransformingQueue, are bypassed: v is stored as is.
+func ReplaceFront[T any](q Queue[T], v T) (old T, ok bool) {
+	q = storage(q)
+	if er, isER := q.(endReplacer[T]); isER {
+		return er.ReplaceFront(v)
+	}
+	if q.Len() == 0 {
+		return old, false
+	}
+	old = q.Dequeue()
+	enqueueFront(q, v)
+	return old, true
+}
+
+// ReplaceBack replaces the last element of q with v and returns the old one.
+// ok is false, and q is left untouched, if q is empty.
+// Like ReplaceFront, it rotates queues that can't replace elements in place.
+func ReplaceBack[T any](q Queue[T], v T) (old T, ok bool) {
+	q = storage(q)
+	if er, isER := q.(endReplacer[T]); isER {
+		return er.ReplaceBack(v)
+	}
+	l := q.Len()
+	if l == 0 {
+		return old, false
+	}
+	for range l - 1 {
+		q.Enqueue(q.Dequeue())
+	}
+	old = q.Dequeue()
+	q.Enqueue(v)
+	return old, true
+}
+
+// DequeueUntilError dequeues the elements of q and passes them to fn until q is
+// empty or fn returns an error. The element that fn failed on is put back at
+// the front of q, so that it can be retried, and the error is returned along
+// with the amount of elements that were processed successfully.
+// Putting the element back costs O(Len). If fn panics the element is put back
+// as well before the panic propagates.
+func DequeueUntilError[T any](q Queue[T], fn func(T) error) (processed int, err error) {
+	for q.Len() > 0 {
+		v := q.Dequeue()
+		if err := callOrRestore(q, v, fn); err != nil {
+			enqueueFront(q, v)
+			return processed, err
+		}
+		processed++
+	}
+	return processed, nil
+}
+
+// callOrRestore calls fn on v, which was just dequeued from q, and puts v back
+// at the front of q if fn panics.
+func callOrRestore[T any](q Queue[T], v T, fn func(T) error) error {
+	done := false
+	defer func() {
+		if !done {
+			enqueueFront(q, v)
+		}
+	}()
+	err := fn(v)
+	done = true
+	return err
+}
+
+// DrainWithRetry dequeues the elements of q and passes them to fn until q is
+// empty. When fn fails it is retried up to maxRetries times on the same
+// element, waiting backoff(attempt) before each retry, where attempt starts
+// from 1.
+// If the retries are exhausted, or ctx is done before an element or a retry,
+// the element is put back at the front of q and the error is returned.
+// The element is also put back if fn panics, before the panic propagates.
+// Use WithClock to control the passing of time.
+func DrainWithRetry[T any](ctx context.Context, q Queue[T], fn func(T) error, maxRetries int, backoff func(attempt int) time.Duration, opts ...Option) error {
+	clock := newOptions(opts).clock
+	for q.Len() > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		v := q.Dequeue()
+		err := callOrRestore(q, v, fn)
+		for attempt := 1; err != nil && attempt <= maxRetries; attempt++ {
+			if err = ctx.Err(); err != nil {
+				break
+			}
+			select {
+			case <-ctx.Done():
+				err = ctx.Err()
+			case <-clock.After(backoff(attempt)):
+				err = callOrRestore(q, v, fn)
+			}
+		}
+		if err != nil {
+			enqueueFront(q, v)
+			return err
+		}
+	}
+	return nil
+}
+
+// DequeueGrouped drains q and groups its elements by the key computed by key.
+// Elements with the same key keep their relative order.
+func DequeueGrouped[K comparable, T any](q Queue[T], key func(T) K) map[K][]T {
+	groups := make(map[K][]T)
+	for q.Len() > 0 {
+		v := q.Dequeue()
+		k := key(v)
+		groups[k] = append(groups[k], v)
+	}
+	return groups
+}
+
+// DrainTo moves up to limit elements from src to dst, in FIFO order, and returns
+// how many were moved.
+// If dst has a TryEnqueue(T) bool method, like BoundedQueue, DrainTo stops as
+// soon as dst rejects an element, leaving it at the front of src.
+func DrainTo[T any](src, dst Queue[T], limit int) int {
+	try, bounded := dst.(interface{ TryEnqueue(T) bool })
+	n := 0
+	for ; n < limit && src.Len() > 0; n++ {
+		v := src.Dequeue()
+		if !bounded {
+			dst.Enqueue(v)
+			continue
+		}
+		if !try.TryEnqueue(v) {
+			enqueueFront(src, v)
+			break
+		}
+	}
+	return n
+}
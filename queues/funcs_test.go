@@ -0,0 +1,979 @@
+package queues
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+var cmpEmpty = cmpopts.EquateEmpty()
+
+func TestZip(t *testing.T) {
+	type pair = Pair[int, string]
+	tests := []struct {
+		name      string
+		a         []int
+		b         []string
+		want      []pair
+		wantRestA []int
+		wantRestB []string
+	}{
+		{
+			name: "equal length",
+			a:    []int{1, 2, 3},
+			b:    []string{"a", "b", "c"},
+			want: []pair{{1, "a"}, {2, "b"}, {3, "c"}},
+		},
+		{
+			name:      "longer first",
+			a:         []int{1, 2, 3, 4},
+			b:         []string{"a", "b"},
+			want:      []pair{{1, "a"}, {2, "b"}},
+			wantRestA: []int{3, 4},
+		},
+		{
+			name:      "longer second",
+			a:         []int{1},
+			b:         []string{"a", "b", "c"},
+			want:      []pair{{1, "a"}},
+			wantRestB: []string{"b", "c"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := FromSlice(tt.a), FromSlice(tt.b)
+			got := ToSlice(Zip(a, b))
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Zip diff:\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantRestA, ToSlice(a), cmpEmpty); diff != "" {
+				t.Errorf("leftovers in a diff:\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantRestB, ToSlice(b), cmpEmpty); diff != "" {
+				t.Errorf("leftovers in b diff:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWindowJoin(t *testing.T) {
+	type pair = Pair[int, int]
+	// Elements are their own timestamps, in seconds.
+	ts := func(v int) time.Time { return time.Unix(int64(v), 0) }
+	tests := []struct {
+		name      string
+		a, b      []int
+		want      []pair
+		wantRestA []int
+		wantRestB []int
+	}{
+		{
+			name: "exact matches",
+			a:    []int{1, 2, 3},
+			b:    []int{1, 2, 3},
+			want: []pair{{1, 1}, {2, 2}, {3, 3}},
+		},
+		{
+			name: "within tolerance",
+			a:    []int{10, 20, 30},
+			b:    []int{12, 18, 32},
+			want: []pair{{10, 12}, {20, 18}, {30, 32}},
+		},
+		{
+			name: "interleaved with drops",
+			a:    []int{1, 10, 20, 21, 40},
+			b:    []int{5, 11, 22, 35, 41},
+			want: []pair{{10, 11}, {20, 22}, {40, 41}},
+		},
+		{
+			name:      "a runs out",
+			a:         []int{1, 2},
+			b:         []int{2, 3, 50, 60},
+			want:      []pair{{1, 2}, {2, 3}},
+			wantRestB: []int{50, 60},
+		},
+		{
+			name:      "b runs out",
+			a:         []int{1, 100, 101},
+			b:         []int{50},
+			wantRestA: []int{100, 101},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := FromSlice(tt.a), FromSlice(tt.b)
+			got := ToSlice(WindowJoin(a, b, ts, ts, 2*time.Second))
+			if diff := cmp.Diff(tt.want, got, cmpEmpty); diff != "" {
+				t.Errorf("WindowJoin diff:\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantRestA, ToSlice(a), cmpEmpty); diff != "" {
+				t.Errorf("leftovers in a diff:\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantRestB, ToSlice(b), cmpEmpty); diff != "" {
+				t.Errorf("leftovers in b diff:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestForEach(t *testing.T) {
+	for _, i := range impls {
+		t.Run(i.name, func(t *testing.T) {
+			q := i.ctor()
+			EnqueueMany(q, seq(0, 9))
+			var got []int
+			ForEach(q, func(v int) bool {
+				got = append(got, v)
+				return v < 4
+			})
+			if diff := cmp.Diff(seq(0, 4), got); diff != "" {
+				t.Errorf("visited diff:\n%s", diff)
+			}
+			if diff := cmp.Diff(seq(0, 9), ToSlice(q)); diff != "" {
+				t.Errorf("queue was modified, diff:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestForEachPanic(t *testing.T) {
+	callbacks := []struct {
+		name string
+		run  func(q Queue[int])
+	}{
+		{"ForEach", func(q Queue[int]) {
+			ForEach(q, func(v int) bool {
+				if v == 3 {
+					panic("boom")
+				}
+				return true
+			})
+		}},
+		{"Filter", func(q Queue[int]) {
+			Filter(q, func(v int) bool {
+				if v == 7 {
+					panic("boom")
+				}
+				return true
+			})
+		}},
+		{"Reduce", func(q Queue[int]) {
+			Reduce(q, 0, func(acc, v int) int { return acc / (5 - v) })
+		}},
+		{"DequeueMatching", func(q Queue[int]) {
+			DequeueMatching(q, func(v int) bool {
+				if v == 4 {
+					panic("boom")
+				}
+				return false
+			})
+		}},
+		{"Partition", func(q Queue[int]) {
+			Partition(q, func(v int) bool {
+				if v == 6 {
+					panic("boom")
+				}
+				return v%2 == 0
+			})
+		}},
+	}
+	for _, i := range impls {
+		for _, cb := range callbacks {
+			t.Run(i.name+"/"+cb.name, func(t *testing.T) {
+				q := i.ctor()
+				EnqueueMany(q, seq(0, 9))
+				func() {
+					defer func() {
+						if recover() == nil {
+							t.Fatalf("callback did not panic")
+						}
+					}()
+					cb.run(q)
+				}()
+				if err := CheckInvariants(q); err != nil {
+					t.Errorf("CheckInvariants: %v", err)
+				}
+				if diff := cmp.Diff(seq(0, 9), ToSlice(q)); diff != "" {
+					t.Errorf("queue was modified, diff:\n%s", diff)
+				}
+			})
+		}
+	}
+}
+
+func TestMap(t *testing.T) {
+	q := FromSlice(seq(0, 4))
+	got := ToSlice(Map(q, strconv.Itoa))
+	if diff := cmp.Diff([]string{"0", "1", "2", "3", "4"}, got); diff != "" {
+		t.Errorf("Map diff:\n%s", diff)
+	}
+	if diff := cmp.Diff(seq(0, 4), ToSlice(q)); diff != "" {
+		t.Errorf("input was modified, diff:\n%s", diff)
+	}
+}
+
+func TestTransformPresize(t *testing.T) {
+	const size = 10_000
+	q := FromSlice(seq(0, size-1))
+	// There is no growth hook, so this counts allocations: one for the result
+	// queue and at most one for its backing store.
+	tests := []struct {
+		name string
+		fn   func()
+		want float64
+	}{
+		{"Map", func() { Map(q, func(v int) int { return v * 2 }) }, 2},
+		{"Filter", func() { Filter(q, func(v int) bool { return v%2 == 0 }) }, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := testing.AllocsPerRun(10, tt.fn); got > tt.want {
+				t.Errorf("allocations: got %v want at most %v", got, tt.want)
+			}
+		})
+	}
+	t.Run("Partition", func(t *testing.T) {
+		in := FromSlice(seq(0, size-1))
+		even, odd := Partition(in, func(v int) bool { return v%2 == 0 })
+		for _, r := range []Queue[int]{even, odd} {
+			if got := r.(capper).Cap(); got != size {
+				t.Errorf("Cap: got %v want %v", got, size)
+			}
+		}
+	})
+}
+
+func TestFilter(t *testing.T) {
+	q := FromSlice(seq(0, 9))
+	got := ToSlice(Filter(q, func(v int) bool { return v%3 == 0 }))
+	if diff := cmp.Diff([]int{0, 3, 6, 9}, got); diff != "" {
+		t.Errorf("Filter diff:\n%s", diff)
+	}
+	if diff := cmp.Diff(seq(0, 9), ToSlice(q)); diff != "" {
+		t.Errorf("input was modified, diff:\n%s", diff)
+	}
+}
+
+func TestReduce(t *testing.T) {
+	q := FromSlice(seq(1, 10))
+	if got, want := Reduce(q, 0, func(acc, v int) int { return acc + v }), 55; got != want {
+		t.Errorf("sum: got %v want %v", got, want)
+	}
+	got := Reduce(q, "", func(acc string, v int) string { return acc + strconv.Itoa(v) })
+	if want := "12345678910"; got != want {
+		t.Errorf("concat: got %q want %q", got, want)
+	}
+	if got, want := Reduce(&ringQueue[int]{}, 42, func(acc, v int) int { return acc + v }), 42; got != want {
+		t.Errorf("empty: got %v want %v", got, want)
+	}
+	if diff := cmp.Diff(seq(1, 10), ToSlice(q)); diff != "" {
+		t.Errorf("input was modified, diff:\n%s", diff)
+	}
+}
+
+func TestQueueContains(t *testing.T) {
+	tests := []struct {
+		name string
+		q    []int
+		v    int
+		want bool
+	}{
+		{"empty", nil, 1, false},
+		{"front", seq(0, 9), 0, true},
+		{"middle", seq(0, 9), 5, true},
+		{"back", seq(0, 9), 9, true},
+		{"absent", seq(0, 9), 10, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := FromSlice(tt.q)
+			if got := QueueContains(q, tt.v); got != tt.want {
+				t.Errorf("QueueContains(%v, %v): got %v want %v", tt.q, tt.v, got, tt.want)
+			}
+			if diff := cmp.Diff(tt.q, ToSlice(q), cmpEmpty); diff != "" {
+				t.Errorf("queue was modified, diff:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAnyEvery(t *testing.T) {
+	even := func(v int) bool { return v%2 == 0 }
+	tests := []struct {
+		name      string
+		q         []int
+		wantAny   bool
+		wantEvery bool
+	}{
+		{"empty", nil, false, true},
+		{"all true", []int{0, 2, 4}, true, true},
+		{"all false", []int{1, 3, 5}, false, false},
+		{"mixed", []int{1, 2, 3}, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := FromSlice(tt.q)
+			if got := Any(q, even); got != tt.wantAny {
+				t.Errorf("Any(%v, even): got %v want %v", tt.q, got, tt.wantAny)
+			}
+			if got := Every(q, even); got != tt.wantEvery {
+				t.Errorf("Every(%v, even): got %v want %v", tt.q, got, tt.wantEvery)
+			}
+			if diff := cmp.Diff(tt.q, ToSlice(q), cmpEmpty); diff != "" {
+				t.Errorf("queue was modified, diff:\n%s", diff)
+			}
+		})
+	}
+
+	// Both stop calling pred once the result is known.
+	q := FromSlice([]int{1, 2, 3, 4})
+	calls := 0
+	Any(q, func(v int) bool { calls++; return v == 2 })
+	if calls != 2 {
+		t.Errorf("Any pred calls: got %v want 2", calls)
+	}
+	calls = 0
+	Every(q, func(v int) bool { calls++; return v < 3 })
+	if calls != 3 {
+		t.Errorf("Every pred calls: got %v want 3", calls)
+	}
+}
+
+/*
+The lookup benchmarks show maps overtaking slice scans of ints between 16 and
+32 elements. Rotating a queue costs tens of times more than a slice scan, so
+QueueContains is only meant for small queues.
+
+BenchmarkQueueContains/16         	 6224107	       187.7 ns/op	       0 B/op	       0 allocs/op
+BenchmarkQueueContains/32         	 3098007	       365.2 ns/op	       0 B/op	       0 allocs/op
+BenchmarkQueueContains/64         	 1471941	       766.6 ns/op	       0 B/op	       0 allocs/op
+*/
+func BenchmarkQueueContains(b *testing.B) {
+	for _, size := range []int{16, 32, 64} {
+		b.Run(strconv.Itoa(size), func(b *testing.B) {
+			q := FromSlice(seq(0, size-1))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for range b.N {
+				QueueContains(q, size/2)
+			}
+		})
+	}
+}
+
+func TestEqualUnordered(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []int
+		want bool
+	}{
+		{"empty", nil, nil, true},
+		{"equal", []int{1, 2, 3}, []int{1, 2, 3}, true},
+		{"different order", []int{1, 2, 2, 3}, []int{2, 3, 1, 2}, true},
+		{"different counts", []int{1, 2, 2, 3}, []int{1, 2, 3, 3}, false},
+		{"different lengths", []int{1, 2}, []int{1, 2, 2}, false},
+		{"different elements", []int{1, 2}, []int{1, 4}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := FromSlice(tt.a), FromSlice(tt.b)
+			if got := EqualUnordered(a, b); got != tt.want {
+				t.Errorf("EqualUnordered(%v, %v): got %v want %v", tt.a, tt.b, got, tt.want)
+			}
+			// Non comparable elements, keyed by their only value.
+			wrap := func(s []int) Queue[[]int] {
+				var r ringQueue[[]int]
+				for _, v := range s {
+					r.Enqueue([]int{v})
+				}
+				return &r
+			}
+			if got := EqualUnorderedFunc(wrap(tt.a), wrap(tt.b), func(v []int) int { return v[0] }); got != tt.want {
+				t.Errorf("EqualUnorderedFunc(%v, %v): got %v want %v", tt.a, tt.b, got, tt.want)
+			}
+			if diff := cmp.Diff(tt.b, ToSlice(b), cmpEmpty); diff != "" {
+				t.Errorf("queue was modified, diff:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDedup(t *testing.T) {
+	q := Dedup[int](&ringQueue[int]{})
+	EnqueueMany(q, []int{1, 1, 2, 2, 2, 1, 3, 3})
+	if diff := cmp.Diff([]int{1, 2, 1, 3}, ToSlice(q)); diff != "" {
+		t.Errorf("Dedup diff:\n%s", diff)
+	}
+	for q.Len() > 0 {
+		q.Dequeue()
+	}
+	// Once the queue is empty there is nothing to compare with.
+	q.Enqueue(3)
+	if diff := cmp.Diff([]int{3}, ToSlice(q)); diff != "" {
+		t.Errorf("Dedup after drain diff:\n%s", diff)
+	}
+}
+
+func TestDedupRotation(t *testing.T) {
+	q := Dedup[int](&ringQueue[int]{})
+	EnqueueMany(q, []int{1, 2, 1})
+	// The front is rotated behind a back equal to it, and must not be dropped.
+	for range 2 {
+		if diff := cmp.Diff([]int{1, 2, 1}, ToSlice(q)); diff != "" {
+			t.Errorf("ToSlice diff:\n%s", diff)
+		}
+	}
+	n := 0
+	ForEach(q, func(int) bool {
+		n++
+		return true
+	})
+	if n != 3 {
+		t.Errorf("ForEach: got %v elements want 3", n)
+	}
+	if got := q.Len(); got != 3 {
+		t.Errorf("Len after rotating: got %v want 3", got)
+	}
+}
+
+func TestDedupFunc(t *testing.T) {
+	type event struct {
+		tags []string
+		// seen changes every time and is ignored by the comparison.
+		seen int
+	}
+	eq := func(a, b event) bool { return slices.Equal(a.tags, b.tags) }
+	q := DedupFunc[event](&ringQueue[event]{}, eq)
+	q.Enqueue(event{tags: []string{"a"}, seen: 1})
+	q.Enqueue(event{tags: []string{"a"}, seen: 2})
+	q.Enqueue(event{tags: []string{"a", "b"}, seen: 3})
+	q.Enqueue(event{tags: []string{"a"}, seen: 4})
+	var got []int
+	for q.Len() > 0 {
+		got = append(got, q.Dequeue().seen)
+	}
+	if diff := cmp.Diff([]int{1, 3, 4}, got); diff != "" {
+		t.Errorf("DedupFunc diff:\n%s", diff)
+	}
+}
+
+func TestPartition(t *testing.T) {
+	for _, i := range impls {
+		t.Run(i.name, func(t *testing.T) {
+			q := i.ctor()
+			EnqueueMany(q, seq(0, 9))
+			even, odd := Partition(q, func(v int) bool { return v%2 == 0 })
+			if diff := cmp.Diff([]int{0, 2, 4, 6, 8}, ToSlice(even)); diff != "" {
+				t.Errorf("match diff:\n%s", diff)
+			}
+			if diff := cmp.Diff([]int{1, 3, 5, 7, 9}, ToSlice(odd)); diff != "" {
+				t.Errorf("rest diff:\n%s", diff)
+			}
+			if q.Len() != 0 {
+				t.Errorf("input Len: got %v want 0", q.Len())
+			}
+		})
+	}
+}
+
+// failingWriter fails all writes after the first ok ones.
+type failingWriter struct {
+	bytes.Buffer
+	ok int
+}
+
+func (fw *failingWriter) Write(p []byte) (int, error) {
+	if fw.ok == 0 {
+		return 0, errors.New("write failed")
+	}
+	fw.ok--
+	return fw.Buffer.Write(p)
+}
+
+func TestWriteTo(t *testing.T) {
+	errTooBig := errors.New("too big")
+	encode := func(v int) ([]byte, error) {
+		if v > 100 {
+			return nil, errTooBig
+		}
+		return []byte(strconv.Itoa(v) + ","), nil
+	}
+
+	q := FromSlice(seq(8, 12))
+	var buf bytes.Buffer
+	n, err := WriteTo(q, &buf, encode)
+	if err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	if got, want := buf.String(), "8,9,10,11,12,"; got != want || n != int64(len(want)) {
+		t.Errorf("WriteTo: got (%q, %v) want (%q, %v)", got, n, want, len(want))
+	}
+	if got := q.Len(); got != 0 {
+		t.Errorf("Len after WriteTo: got %v want 0", got)
+	}
+
+	q = FromSlice([]int{1, 2, 300, 4})
+	buf.Reset()
+	if _, err := WriteTo(q, &buf, encode); err != errTooBig {
+		t.Errorf("WriteTo with encoding error: got %v want %v", err, errTooBig)
+	}
+	if got, want := buf.String(), "1,2,"; got != want {
+		t.Errorf("written before encoding error: got %q want %q", got, want)
+	}
+	if diff := cmp.Diff([]int{300, 4}, ToSlice(q)); diff != "" {
+		t.Errorf("remaining after encoding error diff:\n%s", diff)
+	}
+
+	q = FromSlice(seq(1, 4))
+	fw := &failingWriter{ok: 2}
+	if _, err := WriteTo(q, fw, encode); err == nil {
+		t.Errorf("WriteTo with write error: got nil error")
+	}
+	if got, want := fw.String(), "1,2,"; got != want {
+		t.Errorf("written before write error: got %q want %q", got, want)
+	}
+	if diff := cmp.Diff([]int{3, 4}, ToSlice(q)); diff != "" {
+		t.Errorf("remaining after write error diff:\n%s", diff)
+	}
+}
+
+func TestDequeueLen(t *testing.T) {
+	for _, i := range impls {
+		t.Run(i.name, func(t *testing.T) {
+			q := i.ctor()
+			EnqueueMany(q, seq(0, 4))
+			for want := range 5 {
+				v, remaining := DequeueLen(q)
+				if v != want || remaining != 4-want {
+					t.Errorf("DequeueLen: got (%v, %v) want (%v, %v)", v, remaining, want, 4-want)
+				}
+			}
+		})
+	}
+}
+
+func TestDequeueMatching(t *testing.T) {
+	tests := []struct {
+		name   string
+		target int
+		want   []int
+		wantOK bool
+	}{
+		{"front", 0, seq(1, 19), true},
+		{"middle", 7, append(seq(0, 6), seq(8, 19)...), true},
+		{"back", 19, seq(0, 18), true},
+		{"no match", 42, seq(0, 19), false},
+	}
+	for _, i := range impls {
+		t.Run(i.name, func(t *testing.T) {
+			for _, tt := range tests {
+				q := newWrapped(i.ctor, seq(0, 19))
+				got, ok := DequeueMatching(q, func(v int) bool { return v == tt.target })
+				if ok != tt.wantOK || (ok && got != tt.target) {
+					t.Errorf("%s: DequeueMatching: got (%v, %v) want (%v, %v)", tt.name, got, ok, tt.target, tt.wantOK)
+				}
+				if diff := cmp.Diff(tt.want, ToSlice(q)); diff != "" {
+					t.Errorf("%s: remaining diff:\n%s", tt.name, diff)
+				}
+				checkEnds(t, q, tt.want[0], tt.want[len(tt.want)-1], true)
+				if err := CheckInvariants(q); err != nil {
+					t.Errorf("%s: CheckInvariants: %v", tt.name, err)
+				}
+			}
+			q := i.ctor()
+			if _, ok := DequeueMatching(q, func(int) bool { return true }); ok {
+				t.Errorf("DequeueMatching on empty queue: got ok want !ok")
+			}
+			// Removing the only element leaves a working empty queue.
+			q.Enqueue(1)
+			if _, ok := DequeueMatching(q, func(v int) bool { return v == 1 }); !ok {
+				t.Errorf("DequeueMatching on the only element: got !ok want ok")
+			}
+			checkEnds(t, q, 0, 0, false)
+			q.Enqueue(2)
+			checkEnds(t, q, 2, 2, true)
+		})
+	}
+}
+
+func TestDropWhile(t *testing.T) {
+	tests := []struct {
+		name        string
+		pred        func(int) bool
+		wantDropped int
+		want        []int
+	}{
+		{"leading run", func(v int) bool { return v < 5 }, 5, seq(5, 19)},
+		{"stops at first failure", func(v int) bool { return v%2 == 0 }, 1, seq(1, 19)},
+		{"none", func(v int) bool { return v > 0 }, 0, seq(0, 19)},
+		{"all", func(int) bool { return true }, 20, nil},
+	}
+	for _, i := range impls {
+		t.Run(i.name, func(t *testing.T) {
+			for _, tt := range tests {
+				q := newWrapped(i.ctor, seq(0, 19))
+				if got := DropWhile(q, tt.pred); got != tt.wantDropped {
+					t.Errorf("%s: dropped: got %v want %v", tt.name, got, tt.wantDropped)
+				}
+				if diff := cmp.Diff(tt.want, ToSlice(q), cmpEmpty); diff != "" {
+					t.Errorf("%s: remaining diff:\n%s", tt.name, diff)
+				}
+				if err := CheckInvariants(q); err != nil {
+					t.Errorf("%s: CheckInvariants: %v", tt.name, err)
+				}
+				// The queue is still usable after dropping.
+				q.Enqueue(42)
+				if _, back, _ := q.Ends(); back != 42 {
+					t.Errorf("%s: back after Enqueue: got %v want 42", tt.name, back)
+				}
+			}
+		})
+	}
+}
+
+func TestTrim(t *testing.T) {
+	tests := []struct {
+		name        string
+		trim        func(Queue[int], int) int
+		n           int
+		wantTrimmed int
+		want        []int
+	}{
+		{"front", TrimFront[int], 5, 15, seq(15, 19)},
+		{"front to empty", TrimFront[int], 0, 20, nil},
+		{"front negative", TrimFront[int], -1, 20, nil},
+		{"front no-op", TrimFront[int], 30, 0, seq(0, 19)},
+		{"back", TrimBack[int], 5, 15, seq(0, 4)},
+		{"back to empty", TrimBack[int], 0, 20, nil},
+		{"back negative", TrimBack[int], -1, 20, nil},
+		{"back no-op", TrimBack[int], 20, 0, seq(0, 19)},
+	}
+	for _, i := range impls {
+		t.Run(i.name, func(t *testing.T) {
+			for _, tt := range tests {
+				q := newWrapped(i.ctor, seq(0, 19))
+				if got := tt.trim(q, tt.n); got != tt.wantTrimmed {
+					t.Errorf("%s: trimmed: got %v want %v", tt.name, got, tt.wantTrimmed)
+				}
+				if diff := cmp.Diff(tt.want, ToSlice(q), cmpEmpty); diff != "" {
+					t.Errorf("%s: remaining diff:\n%s", tt.name, diff)
+				}
+				if len(tt.want) == 0 {
+					checkEnds(t, q, 0, 0, false)
+				} else {
+					checkEnds(t, q, tt.want[0], tt.want[len(tt.want)-1], true)
+				}
+				if err := CheckInvariants(q); err != nil {
+					t.Errorf("%s: CheckInvariants: %v", tt.name, err)
+				}
+				// The queue is still usable after trimming.
+				q.Enqueue(42)
+				if _, back, _ := q.Ends(); back != 42 {
+					t.Errorf("%s: back after Enqueue: got %v want 42", tt.name, back)
+				}
+			}
+		})
+	}
+}
+
+func TestReplaceEnds(t *testing.T) {
+	for _, i := range impls {
+		t.Run(i.name, func(t *testing.T) {
+			q := i.ctor()
+			if _, ok := ReplaceFront(q, 1); ok {
+				t.Errorf("ReplaceFront on empty queue: got ok want !ok")
+			}
+			if _, ok := ReplaceBack(q, 1); ok {
+				t.Errorf("ReplaceBack on empty queue: got ok want !ok")
+			}
+			checkEnds(t, q, 0, 0, false)
+			q = newWrapped(i.ctor, seq(0, 9))
+			if old, ok := ReplaceFront(q, 100); !ok || old != 0 {
+				t.Errorf("ReplaceFront: got (%v, %v) want (0, true)", old, ok)
+			}
+			if old, ok := ReplaceBack(q, 109); !ok || old != 9 {
+				t.Errorf("ReplaceBack: got (%v, %v) want (9, true)", old, ok)
+			}
+			checkEnds(t, q, 100, 109, true)
+			want := append(append([]int{100}, seq(1, 8)...), 109)
+			if diff := cmp.Diff(want, ToSlice(q)); diff != "" {
+				t.Errorf("contents diff:\n%s", diff)
+			}
+			// With a single element both ends are the same.
+			TrimBack(q, 1)
+			if old, ok := ReplaceBack(q, 7); !ok || old != 100 {
+				t.Errorf("ReplaceBack on single element: got (%v, %v) want (100, true)", old, ok)
+			}
+			checkEnds(t, q, 7, 7, true)
+			if got := q.Dequeue(); got != 7 {
+				t.Errorf("Dequeue: got %v want 7", got)
+			}
+		})
+	}
+}
+
+func TestPeekBack(t *testing.T) {
+	for _, i := range impls {
+		t.Run(i.name, func(t *testing.T) {
+			q := i.ctor()
+			if _, ok := PeekBack(q); ok {
+				t.Errorf("PeekBack on empty queue: got ok want !ok")
+			}
+			q.Enqueue(1)
+			if got, ok := PeekBack(q); got != 1 || !ok {
+				t.Errorf("PeekBack on single element: got (%v, %v) want (1, true)", got, ok)
+			}
+			EnqueueMany(q, seq(2, 20))
+			q.Dequeue()
+			if got, ok := PeekBack(q); got != 20 || !ok {
+				t.Errorf("PeekBack: got (%v, %v) want (20, true)", got, ok)
+			}
+			if diff := cmp.Diff(seq(2, 20), ToSlice(q)); diff != "" {
+				t.Errorf("PeekBack modified the queue, diff:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDequeueUntilError(t *testing.T) {
+	errThird := errors.New("third")
+	for _, i := range impls {
+		t.Run(i.name, func(t *testing.T) {
+			q := i.ctor()
+			EnqueueMany(q, seq(1, 5))
+			var consumed []int
+			processed, err := DequeueUntilError(q, func(v int) error {
+				if v == 3 {
+					return errThird
+				}
+				consumed = append(consumed, v)
+				return nil
+			})
+			if processed != 2 || err != errThird {
+				t.Errorf("DequeueUntilError: got (%v, %v) want (2, %v)", processed, err, errThird)
+			}
+			if diff := cmp.Diff([]int{1, 2}, consumed); diff != "" {
+				t.Errorf("consumed diff:\n%s", diff)
+			}
+			if diff := cmp.Diff([]int{3, 4, 5}, ToSlice(q)); diff != "" {
+				t.Errorf("remaining diff:\n%s", diff)
+			}
+
+			// Once the handler succeeds the queue is drained.
+			processed, err = DequeueUntilError(q, func(int) error { return nil })
+			if processed != 3 || err != nil || q.Len() != 0 {
+				t.Errorf("DequeueUntilError retry: got (%v, %v) and Len %v want (3, nil) and Len 0", processed, err, q.Len())
+			}
+		})
+	}
+}
+
+func TestDequeueUntilErrorPanic(t *testing.T) {
+	for _, i := range impls {
+		t.Run(i.name, func(t *testing.T) {
+			q := i.ctor()
+			EnqueueMany(q, seq(1, 5))
+			func() {
+				defer func() {
+					if recover() == nil {
+						t.Fatalf("DequeueUntilError did not panic")
+					}
+				}()
+				DequeueUntilError(q, func(v int) error {
+					if v == 3 {
+						panic("boom")
+					}
+					return nil
+				})
+			}()
+			if diff := cmp.Diff([]int{3, 4, 5}, ToSlice(q)); diff != "" {
+				t.Errorf("remaining diff:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDrainWithRetry(t *testing.T) {
+	errTransient := errors.New("transient")
+	backoff := func(attempt int) time.Duration { return time.Duration(attempt) * time.Second }
+
+	t.Run("succeeds after retries", func(t *testing.T) {
+		clock := &fakeClock{now: time.Unix(0, 0)}
+		q := FromSlice([]int{1, 2, 3})
+		attempts := map[int]int{}
+		var processed []int
+		err := DrainWithRetry(context.Background(), q, func(v int) error {
+			attempts[v]++
+			if v == 2 && attempts[v] <= 2 {
+				return errTransient
+			}
+			processed = append(processed, v)
+			return nil
+		}, 3, backoff, WithClock(clock))
+		if err != nil {
+			t.Fatalf("DrainWithRetry: %v", err)
+		}
+		if diff := cmp.Diff([]int{1, 2, 3}, processed); diff != "" {
+			t.Errorf("processed diff:\n%s", diff)
+		}
+		if diff := cmp.Diff(map[int]int{1: 1, 2: 3, 3: 1}, attempts); diff != "" {
+			t.Errorf("attempts diff:\n%s", diff)
+		}
+		// Two retries waited 1s and 2s.
+		if got, want := clock.Now().Sub(time.Unix(0, 0)), 3*time.Second; got != want {
+			t.Errorf("time spent backing off: got %v want %v", got, want)
+		}
+	})
+
+	t.Run("retries exhausted", func(t *testing.T) {
+		clock := &fakeClock{now: time.Unix(0, 0)}
+		q := FromSlice([]int{1, 2, 3})
+		calls := 0
+		err := DrainWithRetry(context.Background(), q, func(v int) error {
+			if v == 2 {
+				calls++
+				return errTransient
+			}
+			return nil
+		}, 2, backoff, WithClock(clock))
+		if !errors.Is(err, errTransient) {
+			t.Errorf("DrainWithRetry: got error %v want %v", err, errTransient)
+		}
+		if calls != 3 {
+			t.Errorf("calls for the failing element: got %v want 3", calls)
+		}
+		if diff := cmp.Diff([]int{2, 3}, ToSlice(q)); diff != "" {
+			t.Errorf("remaining diff:\n%s", diff)
+		}
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		for _, failures := range []int{0, 2} {
+			clock := &fakeClock{now: time.Unix(0, 0)}
+			q := FromSlice([]int{1, 2, 3})
+			calls := 0
+			func() {
+				defer func() {
+					if recover() == nil {
+						t.Fatalf("DrainWithRetry did not panic after %v failures", failures)
+					}
+				}()
+				DrainWithRetry(context.Background(), q, func(v int) error {
+					if v != 2 {
+						return nil
+					}
+					if calls++; calls <= failures {
+						return errTransient
+					}
+					panic("boom")
+				}, 5, backoff, WithClock(clock))
+			}()
+			if diff := cmp.Diff([]int{2, 3}, ToSlice(q)); diff != "" {
+				t.Errorf("remaining after %v failures diff:\n%s", failures, diff)
+			}
+		}
+	})
+
+	t.Run("context canceled", func(t *testing.T) {
+		clock := &fakeClock{now: time.Unix(0, 0)}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		q := FromSlice([]int{1, 2, 3})
+		calls := 0
+		err := DrainWithRetry(ctx, q, func(v int) error {
+			calls++
+			cancel()
+			return errTransient
+		}, 5, backoff, WithClock(clock))
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("DrainWithRetry: got error %v want %v", err, context.Canceled)
+		}
+		if calls != 1 {
+			t.Errorf("calls: got %v want 1", calls)
+		}
+		if diff := cmp.Diff([]int{1, 2, 3}, ToSlice(q)); diff != "" {
+			t.Errorf("remaining diff:\n%s", diff)
+		}
+	})
+}
+
+func TestDequeueGrouped(t *testing.T) {
+	q := FromSlice([]int{5, 2, 8, 1, 3, 4, 7})
+	got := DequeueGrouped(q, func(v int) string {
+		if v%2 == 0 {
+			return "even"
+		}
+		return "odd"
+	})
+	want := map[string][]int{
+		"even": {2, 8, 4},
+		"odd":  {5, 1, 3, 7},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("got %v want %v diff:\n%s", got, want, diff)
+	}
+	if got := q.Len(); got != 0 {
+		t.Errorf("Len after DequeueGrouped: got %v want 0", got)
+	}
+}
+
+func TestDrainTo(t *testing.T) {
+	tests := []struct {
+		name     string
+		dst      func() Queue[int]
+		max      int
+		wantN    int
+		wantDst  []int
+		wantRest []int
+	}{
+		{
+			name:     "unbounded",
+			dst:      func() Queue[int] { return &ringQueue[int]{} },
+			max:      4,
+			wantN:    4,
+			wantDst:  []int{0, 1, 2, 3},
+			wantRest: []int{4, 5, 6, 7, 8, 9},
+		},
+		{
+			name: "bounded",
+			dst: func() Queue[int] {
+				q := NewBoundedQueue[int](5)
+				q.Enqueue(-1)
+				return q
+			},
+			max:      8,
+			wantN:    4,
+			wantDst:  []int{-1, 0, 1, 2, 3},
+			wantRest: []int{4, 5, 6, 7, 8, 9},
+		},
+		{
+			name:     "more than available",
+			dst:      func() Queue[int] { return &ringQueue[int]{} },
+			max:      20,
+			wantN:    10,
+			wantDst:  seq(0, 9),
+			wantRest: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src, dst := FromSlice(seq(0, 9)), tt.dst()
+			if got := DrainTo(src, dst, tt.max); got != tt.wantN {
+				t.Errorf("DrainTo: got %v want %v", got, tt.wantN)
+			}
+			if diff := cmp.Diff(tt.wantDst, ToSlice(dst)); diff != "" {
+				t.Errorf("dst diff:\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantRest, ToSlice(src), cmpEmpty); diff != "" {
+				t.Errorf("src diff:\n%s", diff)
+			}
+		})
+	}
+}
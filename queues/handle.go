@@ -0,0 +1,76 @@
+package queues
+
+// Handle identifies an element enqueued in a HandleQueue.
+type Handle uint64
+
+// HandleQueue is a FIFO queue whose elements can be removed from any position
+// through the Handle returned when they were enqueued.
+// Removed elements are discarded from the queue order when they reach the
+// front, or all at once when they outnumber the live ones, so Remove and
+// Dequeue are O(1) amortized and the order never holds more than twice the
+// live elements.
+type HandleQueue[T any] struct {
+	next  Handle
+	order ringQueue[Handle]
+	live  map[Handle]T
+}
+
+// NewHandleQueue returns an empty HandleQueue.
+func NewHandleQueue[T any]() *HandleQueue[T] {
+	return &HandleQueue[T]{live: make(map[Handle]T)}
+}
+
+// Len returns the amount of elements that were not dequeued nor removed.
+func (hq *HandleQueue[T]) Len() int {
+	return len(hq.live)
+}
+
+// Enqueue adds v at the end of the queue and returns its handle.
+func (hq *HandleQueue[T]) Enqueue(v T) Handle {
+	h := hq.next
+	hq.next++
+	hq.live[h] = v
+	hq.order.Enqueue(h)
+	return h
+}
+
+// Dequeue removes and returns the first element that was not removed.
+func (hq *HandleQueue[T]) Dequeue() T {
+	if len(hq.live) == 0 {
+		panic(ErrEmptyQueue)
+	}
+	for {
+		h := hq.order.Dequeue()
+		if v, ok := hq.live[h]; ok {
+			delete(hq.live, h)
+			return v
+		}
+	}
+}
+
+// Remove removes the element identified by h and returns it.
+// It reports false if the element was already dequeued or removed.
+func (hq *HandleQueue[T]) Remove(h Handle) (T, bool) {
+	v, ok := hq.live[h]
+	if ok {
+		delete(hq.live, h)
+		if hq.order.Len() > 2*len(hq.live) {
+			hq.compact()
+		}
+	}
+	return v, ok
+}
+
+// compact drops the handles of removed elements from the order.
+func (hq *HandleQueue[T]) compact() {
+	for range hq.order.Len() {
+		if h := hq.order.Dequeue(); hq.contains(h) {
+			hq.order.Enqueue(h)
+		}
+	}
+}
+
+func (hq *HandleQueue[T]) contains(h Handle) bool {
+	_, ok := hq.live[h]
+	return ok
+}
@@ -0,0 +1,57 @@
+package queues
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestHandleQueue(t *testing.T) {
+	q := NewHandleQueue[string]()
+	handles := map[string]Handle{}
+	for _, v := range []string{"a", "b", "c", "d", "e"} {
+		handles[v] = q.Enqueue(v)
+	}
+	if v, ok := q.Remove(handles["c"]); !ok || v != "c" {
+		t.Errorf("Remove(c): got (%q, %v) want (%q, true)", v, ok, "c")
+	}
+	if _, ok := q.Remove(handles["c"]); ok {
+		t.Errorf("Remove(c) twice: got true want false")
+	}
+	if v, ok := q.Remove(handles["a"]); !ok || v != "a" {
+		t.Errorf("Remove(a): got (%q, %v) want (%q, true)", v, ok, "a")
+	}
+	if got, want := q.Len(), 3; got != want {
+		t.Errorf("Len: got %v want %v", got, want)
+	}
+	if got := q.Dequeue(); got != "b" {
+		t.Errorf("Dequeue: got %q want %q", got, "b")
+	}
+	if _, ok := q.Remove(handles["b"]); ok {
+		t.Errorf("Remove of dequeued element: got true want false")
+	}
+	q.Enqueue("f")
+	var got []string
+	for q.Len() > 0 {
+		got = append(got, q.Dequeue())
+	}
+	if diff := cmp.Diff([]string{"d", "e", "f"}, got); diff != "" {
+		t.Errorf("got %v diff:\n%s", got, diff)
+	}
+}
+
+func TestHandleQueueCompacts(t *testing.T) {
+	q := NewHandleQueue[int]()
+	// The first element stays at the front, so removed ones never reach it.
+	q.Enqueue(-1)
+	for v := range 1000 {
+		q.Remove(q.Enqueue(v))
+		if got, limit := q.order.Len(), 2*q.Len(); got > limit {
+			t.Fatalf("order Len after %v removals: got %v want at most %v", v+1, got, limit)
+		}
+	}
+	q.Enqueue(1000)
+	if diff := cmp.Diff([]int{-1, 1000}, []int{q.Dequeue(), q.Dequeue()}); diff != "" {
+		t.Errorf("dequeued diff:\n%s", diff)
+	}
+}
@@ -0,0 +1,76 @@
+package queues
+
+import "container/heap"
+
+var _ heap.Interface = &lessHeap[int]{}
+
+// lessHeap is a generic min-heap to be used with container/heap.
+// The minimum is the element for which less reports true against all others.
+type lessHeap[T any] struct {
+	s    []T
+	less func(a, b T) bool
+}
+
+func (h *lessHeap[T]) Len() int           { return len(h.s) }
+func (h *lessHeap[T]) Less(i, j int) bool { return h.less(h.s[i], h.s[j]) }
+func (h *lessHeap[T]) Swap(i, j int)      { h.s[i], h.s[j] = h.s[j], h.s[i] }
+
+func (h *lessHeap[T]) Push(x any) {
+	h.s = append(h.s, x.(T))
+}
+
+func (h *lessHeap[T]) Pop() any {
+	var zero T
+	v := h.s[len(h.s)-1]
+	h.s[len(h.s)-1] = zero
+	h.s = h.s[:len(h.s)-1]
+	return v
+}
+
+var (
+	_ heap.Interface = &HeapAdapter[int]{}
+	_ Queue[int]     = &HeapAdapter[int]{}
+)
+
+// HeapAdapter is a slice backed min-heap that can be used both with
+// container/heap and as a Queue.
+// Callers using the heap.Interface methods directly must go through
+// container/heap, as Push and Pop don't maintain the heap invariants on their
+// own, while Enqueue and Dequeue do.
+// Like for the queue returned by NewStablePriorityQueue, helpers that rotate
+// the queue, like ForEach and ToSlice, must not be used on it.
+type HeapAdapter[T any] struct {
+	lessHeap[T]
+}
+
+// NewHeapAdapter returns an empty HeapAdapter ordered by less.
+func NewHeapAdapter[T any](less func(a, b T) bool) *HeapAdapter[T] {
+	return &HeapAdapter[T]{lessHeap[T]{less: less}}
+}
+
+// Dequeue removes and returns the minimum element.
+func (h *HeapAdapter[T]) Dequeue() T {
+	if h.Len() == 0 {
+		panic(ErrEmptyQueue)
+	}
+	return heap.Pop(&h.lessHeap).(T)
+}
+
+func (h *HeapAdapter[T]) Enqueue(v T) {
+	heap.Push(&h.lessHeap, v)
+}
+
+// Ends returns the minimum and the maximum elements, which are the ones that
+// would be dequeued first and last. Finding the latter requires a linear scan.
+func (h *HeapAdapter[T]) Ends() (front, back T, ok bool) {
+	if h.Len() == 0 {
+		return front, back, false
+	}
+	back = h.s[0]
+	for _, v := range h.s[1:] {
+		if h.less(back, v) {
+			back = v
+		}
+	}
+	return h.s[0], back, true
+}
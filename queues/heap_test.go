@@ -0,0 +1,51 @@
+package queues
+
+import (
+	"container/heap"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestHeapAdapter(t *testing.T) {
+	less := func(a, b int) bool { return a < b }
+	in := []int{5, 3, 8, 1, 9, 2, 7}
+
+	h := NewHeapAdapter(less)
+	for _, v := range in {
+		heap.Push(h, v)
+	}
+	if got, want := h.Len(), len(in); got != want {
+		t.Errorf("Len: got %v want %v", got, want)
+	}
+	front, back, ok := h.Ends()
+	if front != 1 || back != 9 || !ok {
+		t.Errorf("Ends: got (%v, %v, %v) want (1, 9, true)", front, back, ok)
+	}
+	var got []int
+	for range 3 {
+		got = append(got, heap.Pop(h).(int))
+	}
+	// Mixing the two APIs must keep the heap consistent.
+	h.Enqueue(4)
+	heap.Push(h, 0)
+	for h.Len() > 0 {
+		got = append(got, h.Dequeue())
+	}
+	if diff := cmp.Diff([]int{1, 2, 3, 0, 4, 5, 7, 8, 9}, got); diff != "" {
+		t.Errorf("heap order diff:\n%s", diff)
+	}
+
+	// heap.Init works on elements added with Push.
+	h = NewHeapAdapter(less)
+	for _, v := range in {
+		h.Push(v)
+	}
+	heap.Init(h)
+	if diff := cmp.Diff([]int{1, 2, 3, 5, 7, 8, 9}, DrainToSlice[int](h)); diff != "" {
+		t.Errorf("heap order after Init diff:\n%s", diff)
+	}
+	if _, _, ok := h.Ends(); ok {
+		t.Errorf("Ends on empty heap: got ok want !ok")
+	}
+}
@@ -0,0 +1,206 @@
+package queues
+
+import (
+	"iter"
+	"slices"
+	"time"
+)
+
+// OpKind identifies a queue operation.
+type OpKind int
+
+const (
+	OpEnqueue OpKind = iota
+	OpDequeue
+)
+
+func (k OpKind) String() string {
+	switch k {
+	case OpEnqueue:
+		return "enqueue"
+	case OpDequeue:
+		return "dequeue"
+	}
+	return "unknown"
+}
+
+// CapEvent describes a change of capacity of a queue.
+type CapEvent struct {
+	// Op is the operation that caused the change.
+	Op             OpKind
+	OldCap, NewCap int
+	// Len is the length of the queue after the operation.
+	Len int
+}
+
+var _ Queue[int] = &InstrumentedQueue[int]{}
+
+// Stats holds counters of the operations performed on a queue.
+type Stats struct {
+	Enqueued, Dequeued uint64
+}
+
+// InstrumentedQueue wraps a Queue to observe its behavior.
+// Like all queues in this package, it is not safe for concurrent use.
+type InstrumentedQueue[T any] struct {
+	inner  Queue[T]
+	capper interface{ Cap() int }
+	cap    int
+	events []CapEvent
+
+	clock   Clock
+	stats   Stats
+	enqRate rateCounter
+	deqRate rateCounter
+	// lenBuckets are the upper bounds of the buckets counted in lenHist,
+	// which has one more slot for lengths above all of them.
+	lenBuckets []int
+	lenHist    []uint64
+}
+
+// NewInstrumentedQueue wraps inner.
+// Capacity changes are only recorded if inner has a Cap() int method.
+// Use WithClock to control the time used to compute rates and WithLenHistogram
+// to count lengths.
+func NewInstrumentedQueue[T any](inner Queue[T], opts ...Option) *InstrumentedQueue[T] {
+	o := newOptions(opts)
+	iq := &InstrumentedQueue[T]{inner: inner, clock: o.clock}
+	if o.lenBuckets != nil {
+		iq.lenBuckets = slices.Clone(o.lenBuckets)
+		iq.lenHist = make([]uint64, len(o.lenBuckets)+1)
+	}
+	if c, ok := inner.(interface{ Cap() int }); ok {
+		iq.capper = c
+		iq.cap = c.Cap()
+	}
+	return iq
+}
+
+func (iq *InstrumentedQueue[T]) Len() int {
+	return iq.inner.Len()
+}
+
+func (iq *InstrumentedQueue[T]) Ends() (front, back T, ok bool) {
+	return iq.inner.Ends()
+}
+
+func (iq *InstrumentedQueue[T]) Dequeue() T {
+	v := iq.inner.Dequeue()
+	iq.stats.Dequeued++
+	iq.deqRate.add(iq.clock.Now())
+	iq.observe(OpDequeue)
+	return v
+}
+
+func (iq *InstrumentedQueue[T]) Enqueue(v T) {
+	iq.inner.Enqueue(v)
+	iq.stats.Enqueued++
+	iq.enqRate.add(iq.clock.Now())
+	iq.observe(OpEnqueue)
+}
+
+// Stats returns the operations counters since creation or the last Reset.
+func (iq *InstrumentedQueue[T]) Stats() Stats {
+	return iq.stats
+}
+
+// EnqueueRate returns the enqueues per second over the last 10 seconds,
+// not counting the current, incomplete, second.
+func (iq *InstrumentedQueue[T]) EnqueueRate() float64 {
+	return iq.enqRate.rate(iq.clock.Now())
+}
+
+// DequeueRate returns the dequeues per second over the last 10 seconds,
+// not counting the current, incomplete, second.
+func (iq *InstrumentedQueue[T]) DequeueRate() float64 {
+	return iq.deqRate.rate(iq.clock.Now())
+}
+
+// Reset zeroes the stats, the rates and the length histogram.
+func (iq *InstrumentedQueue[T]) Reset() {
+	iq.stats = Stats{}
+	clear(iq.lenHist)
+	iq.enqRate = rateCounter{}
+	iq.deqRate = rateCounter{}
+}
+
+// LenHistogram returns how many operations left the queue with a length in
+// each bucket passed to WithLenHistogram, since creation or the last Reset.
+// The i-th count is for lengths in (buckets[i-1], buckets[i]], and an extra
+// last count is for lengths above all the bounds.
+// It returns nil if the queue was created without WithLenHistogram.
+func (iq *InstrumentedQueue[T]) LenHistogram() []uint64 {
+	return slices.Clone(iq.lenHist)
+}
+
+func (iq *InstrumentedQueue[T]) observe(op OpKind) {
+	l := iq.inner.Len()
+	if iq.lenHist != nil {
+		b, _ := slices.BinarySearch(iq.lenBuckets, l)
+		iq.lenHist[b]++
+	}
+	if iq.capper == nil {
+		return
+	}
+	if c := iq.capper.Cap(); c != iq.cap {
+		iq.events = append(iq.events, CapEvent{Op: op, OldCap: iq.cap, NewCap: c, Len: l})
+		iq.cap = c
+	}
+}
+
+// CapacityEvents yields the capacity changes recorded since the last call,
+// removing them from the wrapper as they are consumed.
+// It must not be called concurrently with other operations on the queue.
+func (iq *InstrumentedQueue[T]) CapacityEvents() iter.Seq[CapEvent] {
+	return func(yield func(CapEvent) bool) {
+		for len(iq.events) > 0 {
+			e := iq.events[0]
+			iq.events = iq.events[1:]
+			if !yield(e) {
+				return
+			}
+		}
+		iq.events = nil
+	}
+}
+
+const (
+	rateBucket = time.Second
+	rateWindow = 10 * rateBucket
+	// One more bucket for the current, incomplete, one.
+	rateBuckets = int64(rateWindow/rateBucket) + 1
+)
+
+// rateCounter counts events in a ring of time buckets, so that the window slides.
+type rateCounter struct {
+	counts [rateBuckets]uint64
+	// current is the index of the bucket for the current time, in units of
+	// rateBucket since the Unix epoch.
+	current int64
+}
+
+// advance moves the window forward to now, clearing stale buckets.
+func (rc *rateCounter) advance(now time.Time) int64 {
+	idx := now.UnixNano() / int64(rateBucket)
+	if idx <= rc.current {
+		return rc.current
+	}
+	for i := max(rc.current+1, idx-rateBuckets+1); i <= idx; i++ {
+		rc.counts[i%rateBuckets] = 0
+	}
+	rc.current = idx
+	return idx
+}
+
+func (rc *rateCounter) add(now time.Time) {
+	rc.counts[rc.advance(now)%rateBuckets]++
+}
+
+func (rc *rateCounter) rate(now time.Time) float64 {
+	idx := rc.advance(now)
+	var tot uint64
+	for i := idx - rateBuckets + 1; i < idx; i++ {
+		tot += rc.counts[(i%rateBuckets+rateBuckets)%rateBuckets]
+	}
+	return float64(tot) / rateWindow.Seconds()
+}
@@ -0,0 +1,119 @@
+package queues
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestCapacityEvents(t *testing.T) {
+	for _, i := range impls {
+		t.Run(i.name, func(t *testing.T) {
+			q := NewInstrumentedQueue(i.ctor())
+			// Grow and shrink workload.
+			for v := range 1000 {
+				q.Enqueue(v)
+			}
+			grow := slices.Collect(q.CapacityEvents())
+			for q.Len() > 0 {
+				q.Dequeue()
+			}
+			shrink := slices.Collect(q.CapacityEvents())
+
+			if _, ok := q.inner.(interface{ Cap() int }); !ok {
+				if len(grow)+len(shrink) > 0 {
+					t.Errorf("got events for a queue without capacity: %v %v", grow, shrink)
+				}
+				return
+			}
+			if len(grow) == 0 || len(shrink) == 0 {
+				t.Fatalf("got %d grow and %d shrink events, want some of both", len(grow), len(shrink))
+			}
+			for n, e := range grow {
+				if e.Op != OpEnqueue || e.NewCap <= e.OldCap || n > 0 && e.OldCap != grow[n-1].NewCap {
+					t.Errorf("grow event %d is not monotonic: %+v", n, e)
+				}
+			}
+			for n, e := range shrink {
+				if e.Op != OpDequeue || e.NewCap >= e.OldCap || n > 0 && e.OldCap != shrink[n-1].NewCap {
+					t.Errorf("shrink event %d is not decreasing: %+v", n, e)
+				}
+			}
+			if more := slices.Collect(q.CapacityEvents()); len(more) != 0 {
+				t.Errorf("events were not consumed: %v", more)
+			}
+		})
+	}
+}
+
+func TestInstrumentedRates(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	q := NewInstrumentedQueue[int](&ringQueue[int]{}, WithClock(clock))
+	for range 20 {
+		for range 5 {
+			q.Enqueue(1)
+		}
+		for range 2 {
+			q.Dequeue()
+		}
+		clock.Advance(time.Second)
+	}
+	near := func(got, want float64) bool { return math.Abs(got-want) < 0.01 }
+	if got, want := q.EnqueueRate(), 5.0; !near(got, want) {
+		t.Errorf("EnqueueRate: got %v want %v", got, want)
+	}
+	if got, want := q.DequeueRate(), 2.0; !near(got, want) {
+		t.Errorf("DequeueRate: got %v want %v", got, want)
+	}
+	if got, want := q.Stats(), (Stats{Enqueued: 100, Dequeued: 40}); got != want {
+		t.Errorf("Stats: got %+v want %+v", got, want)
+	}
+
+	// Half the window without operations halves the rate.
+	clock.Advance(5 * time.Second)
+	if got, want := q.EnqueueRate(), 2.5; !near(got, want) {
+		t.Errorf("EnqueueRate after idling: got %v want %v", got, want)
+	}
+	clock.Advance(time.Hour)
+	if got := q.EnqueueRate(); got != 0 {
+		t.Errorf("EnqueueRate after a long idle: got %v want 0", got)
+	}
+
+	q.Enqueue(1)
+	q.Reset()
+	clock.Advance(time.Second)
+	if got := q.EnqueueRate(); got != 0 {
+		t.Errorf("EnqueueRate after Reset: got %v want 0", got)
+	}
+	if got := q.Stats(); got != (Stats{}) {
+		t.Errorf("Stats after Reset: got %+v want zero", got)
+	}
+}
+
+func TestLenHistogram(t *testing.T) {
+	q := NewInstrumentedQueue[int](&ringQueue[int]{}, WithLenHistogram([]int{0, 2, 4, 8}))
+	// Lengths after each operation: 1 to 5, 4 to 2, then 3 to 12.
+	for v := range 5 {
+		q.Enqueue(v)
+	}
+	for range 3 {
+		q.Dequeue()
+	}
+	for v := range 10 {
+		q.Enqueue(v)
+	}
+	if got, want := q.LenHistogram(), []uint64{0, 3, 6, 5, 4}; !slices.Equal(got, want) {
+		t.Errorf("LenHistogram: got %v want %v", got, want)
+	}
+	q.Reset()
+	if got, want := q.LenHistogram(), []uint64{0, 0, 0, 0, 0}; !slices.Equal(got, want) {
+		t.Errorf("LenHistogram after Reset: got %v want %v", got, want)
+	}
+
+	q = NewInstrumentedQueue[int](&ringQueue[int]{})
+	q.Enqueue(1)
+	if got := q.LenHistogram(); got != nil {
+		t.Errorf("LenHistogram without WithLenHistogram: got %v want nil", got)
+	}
+}
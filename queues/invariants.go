@@ -0,0 +1,77 @@
+package queues
+
+import (
+	"fmt"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// ToSlice returns the elements of q in FIFO order, leaving q unchanged.
+func ToSlice[T any](q Queue[T]) []T {
+	s := make([]T, 0, q.Len())
+	ForEach(q, func(v T) bool {
+		s = append(s, v)
+		return true
+	})
+	return s
+}
+
+// Diff returns a human-readable report of the differences between the FIFO
+// contents of got and want, or an empty string if they match. Both queues are
+// left unchanged.
+// Elements are compared with cmp.Diff and opts. Like cmp.Diff, it panics if T
+// has unexported fields, unless opts includes an option to handle them, like
+// cmp.AllowUnexported or cmpopts.IgnoreUnexported.
+func Diff[T any](got, want Queue[T], opts ...cmp.Option) string {
+	return cmp.Diff(ToSlice(want), ToSlice(got), opts...)
+}
+
+// CheckInvariants validates the internal consistency of q, as far as it is
+// observable from the outside.
+func CheckInvariants[T any](q Queue[T]) error {
+	l := q.Len()
+	if l < 0 {
+		return fmt.Errorf("negative length: %d", l)
+	}
+	if got := len(ToSlice(q)); got != l {
+		return fmt.Errorf("Len() is %d but %d elements are stored", l, got)
+	}
+	if got := q.Len(); got != l {
+		return fmt.Errorf("Len() changed from %d to %d after a non-destructive read", l, got)
+	}
+	if c, ok := q.(interface{ Cap() int }); ok && c.Cap() < l {
+		return fmt.Errorf("Cap() is %d, which is less than Len() %d", c.Cap(), l)
+	}
+	if v, ok := q.(interface{ Validate() error }); ok {
+		return v.Validate()
+	}
+	return nil
+}
+
+// Validate checks the consistency of the ring indices. It is cheap enough to
+// be run periodically, and an error means there is a bug in the resize or wrap
+// logic.
+func (sq *ringQueue[T]) Validate() error {
+	if sq.l < 0 || sq.l > len(sq.buf) {
+		return fmt.Errorf("ring length %d is outside of [0, %d]", sq.l, len(sq.buf))
+	}
+	if len(sq.buf) == 0 {
+		if sq.first != 0 {
+			return fmt.Errorf("ring start is %d with an empty buffer", sq.first)
+		}
+		return nil
+	}
+	if sq.first < 0 || sq.first >= len(sq.buf) {
+		return fmt.Errorf("ring start %d is outside of [0, %d)", sq.first, len(sq.buf))
+	}
+	return nil
+}
+
+// Validate checks that the map holds exactly the keys between the first and
+// last indices.
+func (mq *mapQueue[T]) Validate() error {
+	if span := mq.last - mq.first; uint64(len(mq.mem)) != span {
+		return fmt.Errorf("map holds %d elements but indices span %d (first %d, last %d)", len(mq.mem), span, mq.first, mq.last)
+	}
+	return nil
+}
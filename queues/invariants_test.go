@@ -0,0 +1,190 @@
+package queues
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestToSlice(t *testing.T) {
+	for _, i := range impls {
+		t.Run(i.name, func(t *testing.T) {
+			q := i.ctor()
+			for v := range 20 {
+				q.Enqueue(v)
+			}
+			for range 5 {
+				q.Dequeue()
+			}
+			want := seq(5, 19)
+			if diff := cmp.Diff(want, ToSlice(q)); diff != "" {
+				t.Errorf("ToSlice diff:\n%s", diff)
+			}
+			var got []int
+			for q.Len() > 0 {
+				got = append(got, q.Dequeue())
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("ToSlice altered the queue, diff:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	for _, i := range impls {
+		t.Run(i.name, func(t *testing.T) {
+			got, want := i.ctor(), NewSliceQueue[int]()
+			EnqueueMany(got, seq(0, 9))
+			EnqueueMany(want, seq(0, 9))
+			if diff := Diff(got, want); diff != "" {
+				t.Errorf("Diff on matching queues: got\n%s\nwant empty", diff)
+			}
+			want.Dequeue()
+			want.Enqueue(42)
+			diff := Diff(got, want)
+			if diff == "" {
+				t.Fatalf("Diff on mismatched queues: got empty want a report")
+			}
+			if !strings.Contains(diff, "42") {
+				t.Errorf("Diff on mismatched queues: got\n%s\nwant it to mention 42", diff)
+			}
+			if diff := cmp.Diff(seq(0, 9), ToSlice(got)); diff != "" {
+				t.Errorf("Diff altered the queue, diff:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDiffUnexported(t *testing.T) {
+	type point struct{ x, y int }
+	got, want := &ringQueue[point]{}, &ringQueue[point]{}
+	got.Enqueue(point{1, 2})
+	want.Enqueue(point{1, 3})
+	if diff := Diff[point](got, want, cmp.AllowUnexported(point{})); !strings.Contains(diff, "3") {
+		t.Errorf("Diff with AllowUnexported: got\n%s\nwant it to mention 3", diff)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Diff on unexported fields without options did not panic")
+		}
+	}()
+	Diff[point](got, want)
+}
+
+// FuzzQueue interprets every byte below 128 as an enqueue of that value and
+// every other byte as a dequeue.
+func FuzzQueue(f *testing.F) {
+	bakMin := minShrink
+	bakBase := baseLen
+	defer func() {
+		minShrink = bakMin
+		baseLen = bakBase
+	}()
+	minShrink = 2
+	baseLen = 2
+
+	// Grow past a few reallocations, dequeue enough to wrap around the ring
+	// and then drain to trigger shrinks.
+	tricky := []byte{1, 2, 3, 4, 5, 200, 200, 200, 6, 7, 8, 9, 10, 11, 12, 13, 14}
+	for range 14 {
+		tricky = append(tricky, 200)
+	}
+	tricky = append(tricky, 15, 200, 16)
+	f.Add(tricky)
+	f.Add([]byte{200, 1, 200, 200, 2})
+
+	f.Fuzz(func(t *testing.T, ops []byte) {
+		for _, i := range impls {
+			q := i.ctor()
+			var ref []int
+			for n, op := range ops {
+				if op < 128 {
+					q.Enqueue(int(op))
+					ref = append(ref, int(op))
+				} else if len(ref) > 0 {
+					if got, want := q.Dequeue(), ref[0]; got != want {
+						t.Fatalf("%s: op %d: Dequeue got %v want %v", i.name, n, got, want)
+					}
+					ref = ref[1:]
+				}
+				if got, want := q.Len(), len(ref); got != want {
+					t.Fatalf("%s: op %d: Len got %v want %v", i.name, n, got, want)
+				}
+				if err := CheckInvariants(q); err != nil {
+					t.Fatalf("%s: op %d: %v", i.name, n, err)
+				}
+			}
+			if diff := cmp.Diff(ref, ToSlice(q)); len(ref) > 0 && diff != "" {
+				t.Errorf("%s: contents diff:\n%s", i.name, diff)
+			}
+		}
+	})
+}
+
+func TestValidate(t *testing.T) {
+	type validator interface {
+		Validate() error
+	}
+	newRing := func() *ringQueue[int] {
+		q := &ringQueue[int]{}
+		EnqueueMany[int](q, seq(0, 9))
+		q.Dequeue()
+		return q
+	}
+	newMap := func() *mapQueue[int] {
+		q := newMapQueue[int]()
+		EnqueueMany[int](q, seq(0, 9))
+		q.Dequeue()
+		return q
+	}
+	tests := []struct {
+		name    string
+		q       func() validator
+		wantErr string
+	}{
+		{"healthy ring", func() validator { return newRing() }, ""},
+		{"empty ring", func() validator { return &ringQueue[int]{} }, ""},
+		{"ring length too big", func() validator {
+			q := newRing()
+			q.l = len(q.buf) + 1
+			return q
+		}, "ring length"},
+		{"negative ring length", func() validator {
+			q := newRing()
+			q.l = -1
+			return q
+		}, "ring length"},
+		{"ring start past buffer", func() validator {
+			q := newRing()
+			q.first = len(q.buf)
+			return q
+		}, "ring start"},
+		{"ring start with empty buffer", func() validator {
+			return &ringQueue[int]{first: 3}
+		}, "ring start"},
+		{"healthy map", func() validator { return newMap() }, ""},
+		{"map index drift", func() validator {
+			q := newMap()
+			q.first++
+			return q
+		}, "indices span"},
+		{"map missing element", func() validator {
+			q := newMap()
+			delete(q.mem, q.last-1)
+			return q
+		}, "indices span"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.q().Validate()
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("Validate: got %v want nil", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("Validate: got %v want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package queues
+
+import "iter"
+
+// All returns an iterator over the elements of q in FIFO order.
+// Like ForEach, it leaves q unchanged but rotates it while iterating, so q must
+// not be accessed during the iteration.
+func All[T any](q Queue[T]) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		ForEach(q, yield)
+	}
+}
+
+// Items returns an iterator over the positions and elements of q in FIFO
+// order, like slices.All does for slices. q is left unchanged.
+// Like for All, q is rotated while iterating, and accessing it during the
+// iteration has undefined results.
+func Items[T any](q Queue[T]) iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		i := 0
+		ForEach(q, func(v T) bool {
+			cont := yield(i, v)
+			i++
+			return cont
+		})
+	}
+}
+
+// Collect returns the elements of q in FIFO order, leaving q unchanged.
+// It is equivalent to slices.Collect(All(q)), use DrainToSlice to also empty q.
+func Collect[T any](q Queue[T]) []T {
+	return ToSlice(q)
+}
+
+// DrainToSlice dequeues all the elements of q and returns them in FIFO order.
+func DrainToSlice[T any](q Queue[T]) []T {
+	s := make([]T, 0, q.Len())
+	for q.Len() > 0 {
+		s = append(s, q.Dequeue())
+	}
+	return s
+}
+
+// FromSeq returns a ring backed queue holding the values yielded by seq.
+func FromSeq[T any](seq iter.Seq[T]) Queue[T] {
+	var rq ringQueue[T]
+	for v := range seq {
+		rq.Enqueue(v)
+	}
+	return &rq
+}
+
+// Flatten returns an iterator over the elements of the queues in q, in FIFO
+// order. Neither q nor the inner queues are modified, but they are rotated
+// while iterating, so none of them must be accessed during the iteration.
+func Flatten[T any](q Queue[Queue[T]]) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		cont := true
+		ForEach(q, func(inner Queue[T]) bool {
+			ForEach(inner, func(v T) bool {
+				cont = yield(v)
+				return cont
+			})
+			return cont
+		})
+	}
+}
+
+// AllErr returns an iterator that dequeues the elements of q and yields each
+// of them along with the result of validate, so that failures can be handled
+// per element without stopping the drain.
+func AllErr[T any](q Queue[T], validate func(T) error) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for q.Len() > 0 {
+			v := q.Dequeue()
+			if !yield(v, validate(v)) {
+				return
+			}
+		}
+	}
+}
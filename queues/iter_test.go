@@ -0,0 +1,129 @@
+package queues
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCollectRoundTrip(t *testing.T) {
+	for _, s := range [][]int{nil, {1}, seq(0, 99)} {
+		q := FromSeq(slices.Values(s))
+		if diff := cmp.Diff(s, Collect(q), cmpEmpty); diff != "" {
+			t.Errorf("Collect(FromSeq(%v)) diff:\n%s", s, diff)
+		}
+		if diff := cmp.Diff(s, slices.Collect(All(q)), cmpEmpty); diff != "" {
+			t.Errorf("slices.Collect(All(q)) diff:\n%s", diff)
+		}
+		if got, want := q.Len(), len(s); got != want {
+			t.Errorf("Len after Collect: got %v want %v", got, want)
+		}
+		if diff := cmp.Diff(s, DrainToSlice(q), cmpEmpty); diff != "" {
+			t.Errorf("DrainToSlice diff:\n%s", diff)
+		}
+		if got := q.Len(); got != 0 {
+			t.Errorf("Len after DrainToSlice: got %v want 0", got)
+		}
+	}
+}
+
+func TestAllBreak(t *testing.T) {
+	q := FromSlice(seq(0, 9))
+	var got []int
+	for v := range All(q) {
+		if v == 3 {
+			break
+		}
+		got = append(got, v)
+	}
+	if diff := cmp.Diff(seq(0, 2), got); diff != "" {
+		t.Errorf("got %v diff:\n%s", got, diff)
+	}
+	if diff := cmp.Diff(seq(0, 9), Collect(q)); diff != "" {
+		t.Errorf("queue modified by early break, diff:\n%s", diff)
+	}
+}
+
+func TestItems(t *testing.T) {
+	for _, i := range impls {
+		t.Run(i.name, func(t *testing.T) {
+			q := i.ctor()
+			EnqueueMany(q, seq(0, 14))
+			for range 5 {
+				q.Dequeue()
+			}
+			var gotIdx, gotVals []int
+			for i, v := range Items(q) {
+				gotIdx = append(gotIdx, i)
+				gotVals = append(gotVals, v)
+			}
+			if diff := cmp.Diff(seq(0, 9), gotIdx); diff != "" {
+				t.Errorf("indices diff:\n%s", diff)
+			}
+			if diff := cmp.Diff(seq(5, 14), gotVals); diff != "" {
+				t.Errorf("values diff:\n%s", diff)
+			}
+			// Breaking early still leaves the queue unchanged.
+			for i := range Items(q) {
+				if i == 3 {
+					break
+				}
+			}
+			if diff := cmp.Diff(seq(5, 14), ToSlice(q)); diff != "" {
+				t.Errorf("Items modified the queue, diff:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	batches := FromSlice([]Queue[int]{
+		FromSlice(seq(0, 2)),
+		FromSlice[int](nil),
+		FromSlice(seq(3, 3)),
+		FromSlice(seq(4, 9)),
+	})
+	if diff := cmp.Diff(seq(0, 9), slices.Collect(Flatten(batches))); diff != "" {
+		t.Errorf("Flatten diff:\n%s", diff)
+	}
+	var got []int
+	for v := range Flatten(batches) {
+		if v == 5 {
+			break
+		}
+		got = append(got, v)
+	}
+	if diff := cmp.Diff(seq(0, 4), got); diff != "" {
+		t.Errorf("Flatten with break diff:\n%s", diff)
+	}
+	if got, want := batches.Len(), 4; got != want {
+		t.Errorf("outer Len: got %v want %v", got, want)
+	}
+	if diff := cmp.Diff(seq(0, 9), slices.Collect(Flatten(batches))); diff != "" {
+		t.Errorf("Flatten after break diff:\n%s", diff)
+	}
+}
+
+func TestAllErr(t *testing.T) {
+	errOdd := errors.New("odd")
+	q := FromSlice(seq(0, 5))
+	var got []string
+	for v, err := range AllErr(q, func(v int) error {
+		if v%2 == 1 {
+			return fmt.Errorf("%d: %w", v, errOdd)
+		}
+		return nil
+	}) {
+		got = append(got, fmt.Sprintf("%d %t", v, errors.Is(err, errOdd)))
+	}
+	want := []string{"0 false", "1 true", "2 false", "3 true", "4 false", "5 true"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("got %v want %v diff:\n%s", got, want, diff)
+	}
+	if got := q.Len(); got != 0 {
+		t.Errorf("Len after AllErr: got %v want 0", got)
+	}
+}
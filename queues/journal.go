@@ -0,0 +1,68 @@
+package queues
+
+var _ Queue[int] = &journaledQueue[int]{}
+
+// Op is an operation recorded in a Journal, with the value that was enqueued or
+// dequeued.
+type Op[T any] struct {
+	Kind  OpKind
+	Value T
+}
+
+// Journal is the sequence of operations performed on a journaled queue.
+type Journal[T any] struct {
+	ops []Op[T]
+}
+
+// Ops returns the recorded operations, oldest first.
+// The returned slice must not be modified.
+func (j *Journal[T]) Ops() []Op[T] {
+	return j.ops
+}
+
+type journaledQueue[T any] struct {
+	inner Queue[T]
+	j     *Journal[T]
+}
+
+// NewJournaledQueue wraps inner to record every Enqueue and Dequeue in the
+// returned Journal, so that the sequence can be reproduced with Replay.
+// The journal grows with every operation, so this is meant for debugging.
+func NewJournaledQueue[T any](inner Queue[T]) (Queue[T], *Journal[T]) {
+	j := &Journal[T]{}
+	return &journaledQueue[T]{inner: inner, j: j}, j
+}
+
+func (jq *journaledQueue[T]) Len() int {
+	return jq.inner.Len()
+}
+
+func (jq *journaledQueue[T]) Ends() (front, back T, ok bool) {
+	return jq.inner.Ends()
+}
+
+func (jq *journaledQueue[T]) Dequeue() T {
+	v := jq.inner.Dequeue()
+	jq.j.ops = append(jq.j.ops, Op[T]{Kind: OpDequeue, Value: v})
+	return v
+}
+
+func (jq *journaledQueue[T]) Enqueue(v T) {
+	jq.inner.Enqueue(v)
+	jq.j.ops = append(jq.j.ops, Op[T]{Kind: OpEnqueue, Value: v})
+}
+
+// Replay applies the operations in j to a queue returned by ctor and returns
+// it. Dequeued values are discarded, as they are determined by the queue.
+func Replay[T any](j *Journal[T], ctor func() Queue[T]) Queue[T] {
+	q := ctor()
+	for _, op := range j.ops {
+		switch op.Kind {
+		case OpEnqueue:
+			q.Enqueue(op.Value)
+		case OpDequeue:
+			q.Dequeue()
+		}
+	}
+	return q
+}
@@ -0,0 +1,41 @@
+package queues
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestJournaledQueue(t *testing.T) {
+	q, j := NewJournaledQueue[int](&ringQueue[int]{})
+	q.Enqueue(1)
+	q.Enqueue(2)
+	q.Dequeue()
+	q.Enqueue(3)
+	q.Enqueue(4)
+	q.Dequeue()
+	// Non-mutating calls are not recorded.
+	q.Ends()
+	q.Len()
+
+	want := []Op[int]{
+		{OpEnqueue, 1},
+		{OpEnqueue, 2},
+		{OpDequeue, 1},
+		{OpEnqueue, 3},
+		{OpEnqueue, 4},
+		{OpDequeue, 2},
+	}
+	if diff := cmp.Diff(want, j.Ops()); diff != "" {
+		t.Errorf("journal diff:\n%s", diff)
+	}
+
+	for _, impl := range impls {
+		t.Run("replay into "+impl.name, func(t *testing.T) {
+			r := Replay(j, impl.ctor)
+			if diff := cmp.Diff(ToSlice(q), DrainToSlice(r)); diff != "" {
+				t.Errorf("replayed contents diff:\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package queues
+
+var _ Queue[int] = &KeyedQueue[int, int]{}
+
+// KeyedQueue is a queue that holds at most one value per key.
+// Enqueueing a value whose key is already queued replaces the queued value,
+// which keeps its original position.
+type KeyedQueue[K comparable, V any] struct {
+	key   func(V) K
+	order ringQueue[K]
+	vals  map[K]V
+}
+
+// NewKeyedQueue returns an empty KeyedQueue that uses key to compute the key
+// of each value.
+func NewKeyedQueue[K comparable, V any](key func(V) K) *KeyedQueue[K, V] {
+	return &KeyedQueue[K, V]{key: key, vals: make(map[K]V)}
+}
+
+func (kq *KeyedQueue[K, V]) Len() int {
+	return kq.order.Len()
+}
+
+func (kq *KeyedQueue[K, V]) Ends() (front, back V, ok bool) {
+	f, b, ok := kq.order.Ends()
+	if !ok {
+		return front, back, false
+	}
+	return kq.vals[f], kq.vals[b], true
+}
+
+func (kq *KeyedQueue[K, V]) Dequeue() V {
+	k := kq.order.Dequeue()
+	v := kq.vals[k]
+	delete(kq.vals, k)
+	return v
+}
+
+// Enqueue adds v at the end of the queue, or replaces the queued value with
+// the same key.
+func (kq *KeyedQueue[K, V]) Enqueue(v V) {
+	k := kq.key(v)
+	if _, ok := kq.vals[k]; !ok {
+		kq.order.Enqueue(k)
+	}
+	kq.vals[k] = v
+}
@@ -0,0 +1,35 @@
+package queues
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestKeyedQueue(t *testing.T) {
+	type update struct {
+		key string
+		val int
+	}
+	q := NewKeyedQueue(func(u update) string { return u.key })
+	for _, u := range []update{{"a", 1}, {"b", 1}, {"a", 2}, {"c", 1}, {"a", 3}} {
+		q.Enqueue(u)
+	}
+	if got, want := q.Len(), 3; got != want {
+		t.Errorf("Len: got %v want %v", got, want)
+	}
+	if got, want := q.Dequeue(), (update{"a", 3}); got != want {
+		t.Errorf("Dequeue: got %v want %v", got, want)
+	}
+	// Once dequeued, the key is new again.
+	q.Enqueue(update{"a", 4})
+	q.Enqueue(update{"b", 2})
+	var got []update
+	for q.Len() > 0 {
+		got = append(got, q.Dequeue())
+	}
+	want := []update{{"b", 2}, {"c", 1}, {"a", 4}}
+	if diff := cmp.Diff(want, got, cmp.AllowUnexported(update{})); diff != "" {
+		t.Errorf("got %v want %v diff:\n%s", got, want, diff)
+	}
+}
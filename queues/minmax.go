@@ -0,0 +1,65 @@
+package queues
+
+import "cmp"
+
+var _ Queue[int] = &MinMaxQueue[int]{}
+
+// MinMaxQueue is a FIFO queue that reports the minimum and maximum of its
+// elements in O(1).
+// Alongside the elements it keeps two monotonic deques: one of the elements
+// that can still become the minimum, in increasing order, and one of those that
+// can still become the maximum, in decreasing order. Enqueue and Dequeue are
+// amortized O(1).
+type MinMaxQueue[T cmp.Ordered] struct {
+	q, mins, maxs ringQueue[T]
+}
+
+// NewMinMaxQueue returns an empty MinMaxQueue.
+func NewMinMaxQueue[T cmp.Ordered]() *MinMaxQueue[T] {
+	return &MinMaxQueue[T]{}
+}
+
+func (mq *MinMaxQueue[T]) Len() int {
+	return mq.q.Len()
+}
+
+func (mq *MinMaxQueue[T]) Ends() (front, back T, ok bool) {
+	return mq.q.Ends()
+}
+
+// Min returns the smallest element, or false if the queue is empty.
+func (mq *MinMaxQueue[T]) Min() (T, bool) {
+	v, _, ok := mq.mins.Ends()
+	return v, ok
+}
+
+// Max returns the largest element, or false if the queue is empty.
+func (mq *MinMaxQueue[T]) Max() (T, bool) {
+	v, _, ok := mq.maxs.Ends()
+	return v, ok
+}
+
+func (mq *MinMaxQueue[T]) Dequeue() T {
+	v := mq.q.Dequeue()
+	if m, _, _ := mq.mins.Ends(); m == v {
+		mq.mins.Dequeue()
+	}
+	if m, _, _ := mq.maxs.Ends(); m == v {
+		mq.maxs.Dequeue()
+	}
+	return v
+}
+
+func (mq *MinMaxQueue[T]) Enqueue(v T) {
+	mq.q.Enqueue(v)
+	// Elements that are enqueued before v and are greater than it will leave
+	// the queue before it, so they can never be the minimum.
+	for _, b, ok := mq.mins.Ends(); ok && b > v; _, b, ok = mq.mins.Ends() {
+		mq.mins.RemoveAt(mq.mins.Len() - 1)
+	}
+	mq.mins.Enqueue(v)
+	for _, b, ok := mq.maxs.Ends(); ok && b < v; _, b, ok = mq.maxs.Ends() {
+		mq.maxs.RemoveAt(mq.maxs.Len() - 1)
+	}
+	mq.maxs.Enqueue(v)
+}
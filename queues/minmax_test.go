@@ -0,0 +1,48 @@
+package queues
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestMinMaxQueue(t *testing.T) {
+	q := NewMinMaxQueue[int]()
+	if _, ok := q.Min(); ok {
+		t.Errorf("Min on empty queue: got ok")
+	}
+	if _, ok := q.Max(); ok {
+		t.Errorf("Max on empty queue: got ok")
+	}
+	r := rand.New(rand.NewSource(1))
+	var mirror []int
+	for step := range 5000 {
+		// Lean towards enqueues, with a small range of values to get
+		// duplicates.
+		if len(mirror) == 0 || r.Intn(3) > 0 {
+			v := r.Intn(50)
+			q.Enqueue(v)
+			mirror = append(mirror, v)
+		} else {
+			got := q.Dequeue()
+			if got != mirror[0] {
+				t.Fatalf("step %v: Dequeue: got %v want %v", step, got, mirror[0])
+			}
+			mirror = mirror[1:]
+		}
+		gotMin, okMin := q.Min()
+		gotMax, okMax := q.Max()
+		if len(mirror) == 0 {
+			if okMin || okMax {
+				t.Fatalf("step %v: Min/Max on empty queue: got ok", step)
+			}
+			continue
+		}
+		if want := slices.Min(mirror); !okMin || gotMin != want {
+			t.Fatalf("step %v: Min: got (%v, %v) want %v", step, gotMin, okMin, want)
+		}
+		if want := slices.Max(mirror); !okMax || gotMax != want {
+			t.Fatalf("step %v: Max: got (%v, %v) want %v", step, gotMax, okMax, want)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package queues
+
+var _ Queue[int] = &monotonicQueue[int]{}
+
+type monotonicQueue[T any] struct {
+	q    ringQueue[T]
+	less func(a, b T) bool
+}
+
+// NewMonotonicQueue returns a queue that only accepts elements that are not
+// less than its tail, which is useful to catch out-of-order producers early.
+// Any element is accepted when the queue is empty.
+// Enqueue panics on a violation, while the TryEnqueue(T) bool method of the
+// returned queue reports it instead.
+// Helpers that rotate the queue, like ForEach and ToSlice, violate the order
+// and must not be used on it.
+func NewMonotonicQueue[T any](less func(a, b T) bool) Queue[T] {
+	return &monotonicQueue[T]{less: less}
+}
+
+func (mq *monotonicQueue[T]) Len() int {
+	return mq.q.Len()
+}
+
+func (mq *monotonicQueue[T]) Ends() (front, back T, ok bool) {
+	return mq.q.Ends()
+}
+
+func (mq *monotonicQueue[T]) Dequeue() T {
+	return mq.q.Dequeue()
+}
+
+// TryEnqueue adds v at the end of the queue, or reports false if v is less than
+// the tail.
+func (mq *monotonicQueue[T]) TryEnqueue(v T) bool {
+	if _, tail, ok := mq.q.Ends(); ok && mq.less(v, tail) {
+		return false
+	}
+	mq.q.Enqueue(v)
+	return true
+}
+
+func (mq *monotonicQueue[T]) Enqueue(v T) {
+	if !mq.TryEnqueue(v) {
+		panic("enqueue would violate the monotonic order")
+	}
+}
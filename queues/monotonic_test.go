@@ -0,0 +1,43 @@
+package queues
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMonotonicQueue(t *testing.T) {
+	q := NewMonotonicQueue(func(a, b int) bool { return a < b })
+	try := q.(interface{ TryEnqueue(int) bool })
+	tests := []struct {
+		v    int
+		want bool
+	}{
+		// The first element is always accepted.
+		{5, true},
+		{5, true},
+		{7, true},
+		{6, false},
+		{-1, false},
+		{8, true},
+	}
+	for _, tt := range tests {
+		if got := try.TryEnqueue(tt.v); got != tt.want {
+			t.Errorf("TryEnqueue(%v): got %v want %v", tt.v, got, tt.want)
+		}
+	}
+	if diff := cmp.Diff([]int{5, 5, 7, 8}, DrainToSlice(q)); diff != "" {
+		t.Errorf("contents diff:\n%s", diff)
+	}
+
+	// Once empty, any element is accepted again.
+	if !try.TryEnqueue(1) {
+		t.Errorf("TryEnqueue(1) on empty queue: got false")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("out of order Enqueue did not panic")
+		}
+	}()
+	q.Enqueue(0)
+}
@@ -0,0 +1,83 @@
+package queues
+
+import "sync"
+
+var (
+	_ Queue[int]           = &NotifyQueue[int]{}
+	_ ConcurrentQueue[int] = &NotifyQueue[int]{}
+)
+
+// NotifyQueue is a queue that is safe for concurrent use and that wakes a
+// single consumer once per batch instead of once per element.
+// The consumer is expected to wait on Ready, drain the queue and then wait
+// again.
+type NotifyQueue[T any] struct {
+	mu sync.Mutex
+	q  ringQueue[T]
+	// ready holds a pending signal, if any.
+	ready chan struct{}
+}
+
+// NewNotifyQueue returns an empty NotifyQueue.
+func NewNotifyQueue[T any]() *NotifyQueue[T] {
+	return &NotifyQueue[T]{ready: make(chan struct{}, 1)}
+}
+
+func (nq *NotifyQueue[T]) Len() int {
+	nq.mu.Lock()
+	defer nq.mu.Unlock()
+	return nq.q.Len()
+}
+
+// ConcurrencySafe always returns true.
+func (nq *NotifyQueue[T]) ConcurrencySafe() bool {
+	return true
+}
+
+func (nq *NotifyQueue[T]) Ends() (front, back T, ok bool) {
+	nq.mu.Lock()
+	defer nq.mu.Unlock()
+	return nq.q.Ends()
+}
+
+// Enqueue adds v at the end of the queue, signaling Ready if the queue was
+// empty.
+func (nq *NotifyQueue[T]) Enqueue(v T) {
+	nq.mu.Lock()
+	defer nq.mu.Unlock()
+	nq.q.Enqueue(v)
+	if nq.q.Len() > 1 {
+		return
+	}
+	select {
+	case nq.ready <- struct{}{}:
+	default:
+		// A signal is already pending.
+	}
+}
+
+func (nq *NotifyQueue[T]) Dequeue() T {
+	nq.mu.Lock()
+	defer nq.mu.Unlock()
+	return nq.q.Dequeue()
+}
+
+// Drain removes and returns all the elements in the queue.
+func (nq *NotifyQueue[T]) Drain() []T {
+	nq.mu.Lock()
+	defer nq.mu.Unlock()
+	s := make([]T, 0, nq.q.Len())
+	for nq.q.Len() > 0 {
+		s = append(s, nq.q.Dequeue())
+	}
+	return s
+}
+
+// Ready returns a channel that receives a value when the queue goes from empty
+// to non-empty. Signals are coalesced: at most one is pending at any time, so
+// a consumer that drains the queue after every receive never misses elements.
+// Elements enqueued after a signal but before the drain are taken by that
+// drain, so the following signal might find the queue already empty.
+func (nq *NotifyQueue[T]) Ready() <-chan struct{} {
+	return nq.ready
+}
@@ -0,0 +1,74 @@
+package queues
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNotifyQueue(t *testing.T) {
+	q := NewNotifyQueue[int]()
+	select {
+	case <-q.Ready():
+		t.Fatalf("Ready signaled on empty queue")
+	default:
+	}
+	q.Enqueue(1)
+	q.Enqueue(2)
+	<-q.Ready()
+	select {
+	case <-q.Ready():
+		t.Errorf("Ready signaled twice for a single transition")
+	default:
+	}
+	checkEnds(t, q, 1, 2, true)
+	if got := q.Dequeue(); got != 1 {
+		t.Errorf("Dequeue: got %v want 1", got)
+	}
+	q.Drain()
+	q.Enqueue(3)
+	select {
+	case <-q.Ready():
+	default:
+		t.Errorf("Ready did not signal after the queue was drained and refilled")
+	}
+}
+
+func TestNotifyQueueBursts(t *testing.T) {
+	q := NewNotifyQueue[int]()
+	const bursts, perBurst = 100, 50
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for b := range bursts {
+			for i := range perBurst {
+				q.Enqueue(b*perBurst + i)
+			}
+			if b%10 == 0 {
+				time.Sleep(time.Millisecond)
+			}
+		}
+	}()
+	var got []int
+	wakeups := 0
+	for len(got) < bursts*perBurst {
+		select {
+		case <-q.Ready():
+		case <-time.After(10 * time.Second):
+			t.Fatalf("consumer missed a signal after %v elements", len(got))
+		}
+		wakeups++
+		got = append(got, q.Drain()...)
+	}
+	wg.Wait()
+	for i, v := range got {
+		if v != i {
+			t.Fatalf("element %v: got %v want %v", i, v, i)
+		}
+	}
+	if q.Len() != 0 {
+		t.Errorf("Len after drain: got %v want 0", q.Len())
+	}
+	t.Logf("%v elements in %v wakeups", len(got), wakeups)
+}
@@ -0,0 +1,99 @@
+package queues
+
+// Option configures optional behaviors.
+type Option func(*options)
+
+type options struct {
+	exactCap    bool
+	autoCompact float64
+
+	shrinkHysteresis int
+	minCap           int
+	slowGrowthAbove  int
+	counters         bool
+	keepOnRecycle    bool
+	clock            Clock
+	lenBuckets       []int
+	// overflow is a func(T) for the element type of the queue.
+	overflow any
+}
+
+func newOptions(opts []Option) options {
+	o := options{clock: systemClock{}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithAutoCompact makes slice backed queues compact as soon as the slots left
+// behind by dequeues exceed the given fraction of their capacity.
+func WithAutoCompact(fraction float64) Option {
+	return func(o *options) {
+		o.autoCompact = fraction
+	}
+}
+
+// WithShrinkHysteresis makes slice and ring queues shrink only after their
+// length has stayed below the shrink threshold for the given amount of
+// consecutive dequeues. When they do, they shrink to fit the peak length seen
+// in that period.
+func WithShrinkHysteresis(dequeues int) Option {
+	return func(o *options) {
+		o.shrinkHysteresis = dequeues
+	}
+}
+
+// WithMinCapacity makes slice and ring queues never shrink their capacity below
+// n, so that a working set of that size stays allocated.
+func WithMinCapacity(n int) Option {
+	return func(o *options) {
+		o.minCap = n
+	}
+}
+
+// WithHybridGrowth makes slice and ring queues grow their capacity by 1.5x
+// instead of 2x once it reaches threshold, like Go does for slices. This reduces
+// the slack of large queues at the cost of more frequent copies.
+func WithHybridGrowth(threshold int) Option {
+	return func(o *options) {
+		o.slowGrowthAbove = threshold
+	}
+}
+
+// WithCounters makes slice, ring and pooled queues count the elements enqueued
+// and dequeued, which their Counters method reports. Sampling the counters
+// allows computing rates without wrapping the queue in an InstrumentedQueue.
+func WithCounters() Option {
+	return func(o *options) {
+		o.counters = true
+	}
+}
+
+// WithZeroOnRecycle controls whether pooled queues clear the values of the
+// nodes they return to the pool, which is the default. Clearing prevents the
+// pool from keeping dequeued values alive. It can be disabled for element
+// types that hold no pointers, for which the write is pure overhead.
+func WithZeroOnRecycle(enabled bool) Option {
+	return func(o *options) {
+		o.keepOnRecycle = !enabled
+	}
+}
+
+// WithOverflow makes bounded queues call fn with the values that are rejected
+// by Enqueue because the queue is full.
+// The type of the handler must match the element type of the queue.
+func WithOverflow[T any](fn func(dropped T)) Option {
+	return func(o *options) {
+		o.overflow = fn
+	}
+}
+
+// WithLenHistogram makes InstrumentedQueue count how many operations left the
+// queue with a length in each bucket, which its LenHistogram method reports.
+// buckets holds the inclusive upper bounds of the buckets in ascending order.
+func WithLenHistogram(buckets []int) Option {
+	return func(o *options) {
+		o.lenBuckets = buckets
+	}
+}
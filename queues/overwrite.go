@@ -0,0 +1,78 @@
+package queues
+
+var _ Queue[int] = &OverwriteRing[int]{}
+
+// OverwriteRing is a fixed capacity ring queue that, when full, drops its
+// oldest element to make room for new ones.
+type OverwriteRing[T any] struct {
+	first, l int
+	buf      []T
+	dropped  uint64
+}
+
+// NewOverwriteRing returns an empty OverwriteRing holding at most capacity elements.
+func NewOverwriteRing[T any](capacity int) *OverwriteRing[T] {
+	if capacity <= 0 {
+		panic("overwrite ring capacity must be positive")
+	}
+	return &OverwriteRing[T]{buf: make([]T, capacity)}
+}
+
+func (or *OverwriteRing[T]) Len() int {
+	return or.l
+}
+
+func (or *OverwriteRing[T]) Cap() int {
+	return len(or.buf)
+}
+
+func (or *OverwriteRing[T]) Dequeue() T {
+	if or.l == 0 {
+		panic(ErrEmptyQueue)
+	}
+	var zero T
+	v := or.buf[or.first]
+	or.buf[or.first] = zero
+	or.first = (or.first + 1) % len(or.buf)
+	or.l--
+	return v
+}
+
+func (or *OverwriteRing[T]) Ends() (front, back T, ok bool) {
+	if or.l == 0 {
+		return front, back, false
+	}
+	return or.buf[or.first], or.buf[(or.first+or.l-1)%len(or.buf)], true
+}
+
+// Enqueue adds v at the end of the queue, dropping the first element if the
+// queue is full.
+func (or *OverwriteRing[T]) Enqueue(v T) {
+	or.EnqueuePop(v)
+}
+
+// EnqueuePop adds v at the end of the queue and, if the queue was full,
+// returns the first element that was dropped to make room for it.
+func (or *OverwriteRing[T]) EnqueuePop(v T) (evicted T, had bool) {
+	if or.l == len(or.buf) {
+		evicted = or.buf[or.first]
+		or.buf[or.first] = v
+		or.first = (or.first + 1) % len(or.buf)
+		or.dropped++
+		return evicted, true
+	}
+	or.buf[(or.first+or.l)%len(or.buf)] = v
+	or.l++
+	return evicted, false
+}
+
+// Dropped returns how many elements were dropped to make room for new ones
+// since the ring was created or since the last call to ResetDropped.
+func (or *OverwriteRing[T]) Dropped() uint64 {
+	return or.dropped
+}
+
+// ResetDropped resets the counter returned by Dropped.
+func (or *OverwriteRing[T]) ResetDropped() {
+	or.dropped = 0
+}
@@ -0,0 +1,70 @@
+package queues
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestOverwriteRing(t *testing.T) {
+	q := NewOverwriteRing[int](4)
+	for i := range 3 {
+		q.Enqueue(i)
+	}
+	if got := q.Dropped(); got != 0 {
+		t.Errorf("Dropped before filling: got %v want 0", got)
+	}
+	for i := 3; i < 10; i++ {
+		q.Enqueue(i)
+	}
+	if got, want := q.Dropped(), uint64(6); got != want {
+		t.Errorf("Dropped: got %v want %v", got, want)
+	}
+	if diff := cmp.Diff([]int{6, 7, 8, 9}, ToSlice[int](q)); diff != "" {
+		t.Errorf("contents diff:\n%s", diff)
+	}
+
+	q.ResetDropped()
+	q.Dequeue()
+	q.Enqueue(10)
+	if got := q.Dropped(); got != 0 {
+		t.Errorf("Dropped after reset with room: got %v want 0", got)
+	}
+	q.Enqueue(11)
+	if got, want := q.Dropped(), uint64(1); got != want {
+		t.Errorf("Dropped after reset: got %v want %v", got, want)
+	}
+	var got []int
+	for q.Len() > 0 {
+		got = append(got, q.Dequeue())
+	}
+	if diff := cmp.Diff([]int{8, 9, 10, 11}, got); diff != "" {
+		t.Errorf("got %v diff:\n%s", got, diff)
+	}
+}
+
+func TestOverwriteRingEnqueuePop(t *testing.T) {
+	q := NewOverwriteRing[int](3)
+	for i := range 3 {
+		if got, had := q.EnqueuePop(i); had {
+			t.Errorf("EnqueuePop(%v) with room: got (%v, true) want (0, false)", i, got)
+		}
+	}
+	for i := 3; i < 8; i++ {
+		front, _, _ := q.Ends()
+		got, had := q.EnqueuePop(i)
+		if !had || got != front {
+			t.Errorf("EnqueuePop(%v) on full ring: got (%v, %v) want (%v, true)", i, got, had, front)
+		}
+	}
+	if got, want := q.Dropped(), uint64(5); got != want {
+		t.Errorf("Dropped: got %v want %v", got, want)
+	}
+	q.Dequeue()
+	if got, had := q.EnqueuePop(8); had {
+		t.Errorf("EnqueuePop after Dequeue: got (%v, true) want (0, false)", got)
+	}
+	if diff := cmp.Diff([]int{6, 7, 8}, ToSlice[int](q)); diff != "" {
+		t.Errorf("contents diff:\n%s", diff)
+	}
+}
@@ -0,0 +1,65 @@
+package queues
+
+import "sync"
+
+// PerProducerFIFOQueue is a queue that is safe for concurrent use and that
+// keeps the elements of each producer in the order they were enqueued, even
+// when several producers enqueue concurrently.
+// There is no global order: Dequeue takes one element from each producer with
+// pending elements in turn, so a busy producer can't starve the others.
+type PerProducerFIFOQueue[T any] struct {
+	mu sync.Mutex
+	n  int
+	// pending holds the elements of each producer, and it has no entry for
+	// producers without pending elements.
+	pending map[int]*ringQueue[T]
+	// turns holds the producers with pending elements, in the order they will
+	// be dequeued from.
+	turns ringQueue[int]
+}
+
+// NewPerProducerFIFOQueue returns an empty PerProducerFIFOQueue.
+func NewPerProducerFIFOQueue[T any]() *PerProducerFIFOQueue[T] {
+	return &PerProducerFIFOQueue[T]{pending: make(map[int]*ringQueue[T])}
+}
+
+func (pq *PerProducerFIFOQueue[T]) Len() int {
+	pq.mu.Lock()
+	defer pq.mu.Unlock()
+	return pq.n
+}
+
+// Enqueue adds v after the other pending elements of producer, which is any
+// identifier chosen by the caller, like the index of a worker.
+func (pq *PerProducerFIFOQueue[T]) Enqueue(producer int, v T) {
+	pq.mu.Lock()
+	defer pq.mu.Unlock()
+	q, ok := pq.pending[producer]
+	if !ok {
+		q = &ringQueue[T]{}
+		pq.pending[producer] = q
+		pq.turns.Enqueue(producer)
+	}
+	q.Enqueue(v)
+	pq.n++
+}
+
+// Dequeue removes and returns the first pending element of the producer whose
+// turn it is, along with the producer.
+func (pq *PerProducerFIFOQueue[T]) Dequeue() (producer int, v T) {
+	pq.mu.Lock()
+	defer pq.mu.Unlock()
+	if pq.n == 0 {
+		panic(ErrEmptyQueue)
+	}
+	producer = pq.turns.Dequeue()
+	q := pq.pending[producer]
+	v = q.Dequeue()
+	if q.Len() > 0 {
+		pq.turns.Enqueue(producer)
+	} else {
+		delete(pq.pending, producer)
+	}
+	pq.n--
+	return producer, v
+}
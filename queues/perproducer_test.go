@@ -0,0 +1,62 @@
+package queues
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPerProducerFIFOQueueTurns(t *testing.T) {
+	q := NewPerProducerFIFOQueue[string]()
+	q.Enqueue(1, "a1")
+	q.Enqueue(1, "a2")
+	q.Enqueue(1, "a3")
+	q.Enqueue(2, "b1")
+	q.Enqueue(3, "c1")
+	q.Enqueue(3, "c2")
+	var got []string
+	for q.Len() > 0 {
+		_, v := q.Dequeue()
+		got = append(got, v)
+		if v == "a2" {
+			// A producer that comes back waits for its turn.
+			q.Enqueue(2, "b2")
+		}
+	}
+	want := []string{"a1", "b1", "c1", "a2", "c2", "a3", "b2"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("dequeue order diff:\n%s", diff)
+	}
+}
+
+func TestPerProducerFIFOQueue(t *testing.T) {
+	q := NewPerProducerFIFOQueue[int]()
+	const producers, perProducer = 4, 1000
+	var wg sync.WaitGroup
+	for p := range producers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range perProducer {
+				q.Enqueue(p, i)
+			}
+		}()
+	}
+	next := make([]int, producers)
+	for range producers * perProducer {
+		for q.Len() == 0 {
+			runtime.Gosched()
+		}
+		p, v := q.Dequeue()
+		if v != next[p] {
+			t.Fatalf("producer %v: got %v want %v", p, v, next[p])
+		}
+		next[p]++
+	}
+	wg.Wait()
+	if got := q.Len(); got != 0 {
+		t.Errorf("Len: got %v want 0", got)
+	}
+}
@@ -0,0 +1,158 @@
+package queues
+
+import "container/heap"
+
+var _ Queue[int] = &stablePriorityQueue[int]{}
+
+type sequenced[T any] struct {
+	v   T
+	seq uint64
+}
+
+type stablePriorityQueue[T any] struct {
+	h   lessHeap[sequenced[T]]
+	seq uint64
+}
+
+// NewStablePriorityQueue returns a priority queue that dequeues the element
+// that is less than all others first.
+// Elements with the same priority are dequeued in FIFO order.
+// Helpers that rotate the queue, like ForEach and ToSlice, re-enqueue the
+// minimum over and over, so they must not be used on it.
+func NewStablePriorityQueue[T any](less func(a, b T) bool) Queue[T] {
+	return &stablePriorityQueue[T]{
+		h: lessHeap[sequenced[T]]{less: func(a, b sequenced[T]) bool {
+			switch {
+			case less(a.v, b.v):
+				return true
+			case less(b.v, a.v):
+				return false
+			}
+			return a.seq < b.seq
+		}},
+	}
+}
+
+func (pq *stablePriorityQueue[T]) Len() int {
+	return pq.h.Len()
+}
+
+func (pq *stablePriorityQueue[T]) Dequeue() T {
+	if pq.h.Len() == 0 {
+		panic(ErrEmptyQueue)
+	}
+	return heap.Pop(&pq.h).(sequenced[T]).v
+}
+
+// Ends returns the element with the highest priority and the most recently
+// enqueued one. Finding the latter requires a linear scan.
+func (pq *stablePriorityQueue[T]) Ends() (front, back T, ok bool) {
+	if pq.h.Len() == 0 {
+		return front, back, false
+	}
+	last := pq.h.s[0]
+	for _, e := range pq.h.s[1:] {
+		if e.seq > last.seq {
+			last = e
+		}
+	}
+	return pq.h.s[0].v, last.v, true
+}
+
+func (pq *stablePriorityQueue[T]) Enqueue(v T) {
+	heap.Push(&pq.h, sequenced[T]{v: v, seq: pq.seq})
+	pq.seq++
+}
+
+// handleHeap is a heap of sequenced elements that keeps track of the position
+// of each element, so that it can be fixed after an update.
+// Sequence numbers are used as handles.
+type handleHeap[T any] struct {
+	s    []sequenced[T]
+	idx  map[Handle]int
+	less func(a, b T) bool
+}
+
+func (h *handleHeap[T]) Len() int { return len(h.s) }
+
+func (h *handleHeap[T]) Less(i, j int) bool {
+	a, b := h.s[i], h.s[j]
+	switch {
+	case h.less(a.v, b.v):
+		return true
+	case h.less(b.v, a.v):
+		return false
+	}
+	return a.seq < b.seq
+}
+
+func (h *handleHeap[T]) Swap(i, j int) {
+	h.s[i], h.s[j] = h.s[j], h.s[i]
+	h.idx[Handle(h.s[i].seq)] = i
+	h.idx[Handle(h.s[j].seq)] = j
+}
+
+func (h *handleHeap[T]) Push(x any) {
+	e := x.(sequenced[T])
+	h.idx[Handle(e.seq)] = len(h.s)
+	h.s = append(h.s, e)
+}
+
+func (h *handleHeap[T]) Pop() any {
+	var zero sequenced[T]
+	v := h.s[len(h.s)-1]
+	delete(h.idx, Handle(v.seq))
+	h.s[len(h.s)-1] = zero
+	h.s = h.s[:len(h.s)-1]
+	return v
+}
+
+// HandlePriorityQueue is a priority queue whose elements can be updated
+// through the Handle returned when they were enqueued, which is needed for
+// decrease-key in algorithms like Dijkstra's.
+// Like the queue returned by NewStablePriorityQueue, it dequeues the minimum
+// first and elements with the same priority in FIFO order.
+type HandlePriorityQueue[T any] struct {
+	h   handleHeap[T]
+	seq uint64
+}
+
+// NewHandlePriorityQueue returns an empty HandlePriorityQueue that dequeues
+// the element that is less than all others first.
+func NewHandlePriorityQueue[T any](less func(a, b T) bool) *HandlePriorityQueue[T] {
+	return &HandlePriorityQueue[T]{h: handleHeap[T]{idx: make(map[Handle]int), less: less}}
+}
+
+func (pq *HandlePriorityQueue[T]) Len() int {
+	return pq.h.Len()
+}
+
+// Enqueue adds v to the queue and returns its handle.
+func (pq *HandlePriorityQueue[T]) Enqueue(v T) Handle {
+	h := Handle(pq.seq)
+	heap.Push(&pq.h, sequenced[T]{v: v, seq: pq.seq})
+	pq.seq++
+	return h
+}
+
+// Dequeue removes and returns the minimum.
+func (pq *HandlePriorityQueue[T]) Dequeue() T {
+	if pq.h.Len() == 0 {
+		panic(ErrEmptyQueue)
+	}
+	return heap.Pop(&pq.h).(sequenced[T]).v
+}
+
+// Update replaces the element identified by h with v and moves it to its new
+// position in O(log n). The element keeps its place among the ones with the
+// same priority.
+// It reports false if the element was already dequeued.
+func (pq *HandlePriorityQueue[T]) Update(h Handle, v T) bool {
+	i, ok := pq.h.idx[h]
+	if !ok {
+		return false
+	}
+	pq.h.s[i].v = v
+	heap.Fix(&pq.h, i)
+	return true
+}
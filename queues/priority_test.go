@@ -0,0 +1,96 @@
+package queues
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestStablePriorityQueue(t *testing.T) {
+	type task struct {
+		prio int
+		name string
+	}
+	q := NewStablePriorityQueue(func(a, b task) bool { return a.prio < b.prio })
+	for _, tk := range []task{
+		{2, "a"}, {1, "b"}, {2, "c"}, {3, "d"}, {2, "e"}, {1, "f"}, {2, "g"}, {0, "h"},
+	} {
+		q.Enqueue(tk)
+	}
+	var got []string
+	for q.Len() > 0 {
+		got = append(got, q.Dequeue().name)
+	}
+	want := []string{"h", "b", "f", "a", "c", "e", "g", "d"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("got %v want %v diff:\n%s", got, want, diff)
+	}
+}
+
+func TestHandlePriorityQueueDijkstra(t *testing.T) {
+	type dist struct {
+		node string
+		d    int
+	}
+	// a -1-> b -1-> c -1-> d, and a direct but longer a -10-> d.
+	edges := map[string][]struct {
+		to string
+		w  int
+	}{
+		"a": {{"b", 1}, {"d", 10}},
+		"b": {{"c", 1}},
+		"c": {{"d", 1}},
+	}
+	q := NewHandlePriorityQueue(func(a, b dist) bool { return a.d < b.d })
+	handles := map[string]Handle{}
+	best := map[string]int{"a": 0}
+	handles["a"] = q.Enqueue(dist{"a", 0})
+	var order []dist
+	for q.Len() > 0 {
+		cur := q.Dequeue()
+		order = append(order, cur)
+		delete(handles, cur.node)
+		for _, e := range edges[cur.node] {
+			nd := cur.d + e.w
+			if old, seen := best[e.to]; seen && old <= nd {
+				continue
+			}
+			best[e.to] = nd
+			if h, queued := handles[e.to]; queued {
+				if !q.Update(h, dist{e.to, nd}) {
+					t.Fatalf("Update(%v) of a queued node reported false", e.to)
+				}
+				continue
+			}
+			handles[e.to] = q.Enqueue(dist{e.to, nd})
+		}
+	}
+	// d is first reached through the direct edge, then relaxed through c.
+	want := []dist{{"a", 0}, {"b", 1}, {"c", 2}, {"d", 3}}
+	if diff := cmp.Diff(want, order, cmp.AllowUnexported(dist{})); diff != "" {
+		t.Errorf("dequeue order diff:\n%s", diff)
+	}
+}
+
+func TestHandlePriorityQueueUpdate(t *testing.T) {
+	q := NewHandlePriorityQueue(func(a, b int) bool { return a < b })
+	hs := make([]Handle, 10)
+	for i := range hs {
+		hs[i] = q.Enqueue(10 * (i + 1))
+	}
+	// Decrease, increase and keep priorities, moving elements both ways.
+	q.Update(hs[9], 5)
+	q.Update(hs[0], 95)
+	q.Update(hs[4], 50)
+	got := []int{q.Dequeue()}
+	if q.Update(hs[9], 1) {
+		t.Errorf("Update of a dequeued element reported true")
+	}
+	for q.Len() > 0 {
+		got = append(got, q.Dequeue())
+	}
+	want := []int{5, 20, 30, 40, 50, 60, 70, 80, 90, 95}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("dequeue order diff:\n%s", diff)
+	}
+}
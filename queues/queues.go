@@ -1,6 +1,10 @@
 package queues
 
 import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
 	"sync"
 )
 
@@ -11,54 +15,413 @@ var (
 
 const growthFactor = 2
 
+// checkResize returns an error if a queue of length l can't fit in newCap.
+func checkResize(l, newCap int) error {
+	if newCap < l {
+		return fmt.Errorf("resize to %d would drop elements: Len() is %d", newCap, l)
+	}
+	return nil
+}
+
 func shouldShrink(l, c int) (newCap int, ok bool) {
 	newCap = l * growthFactor
 	ok = l < c/4 && l > minShrink && l > baseLen
 	return newCap, ok
 }
 
+// shrinkPolicy decides when slice and ring queues shrink, on top of shouldShrink.
+type shrinkPolicy struct {
+	// minCap is the capacity below which queues never shrink.
+	minCap int
+	// window delays shrinking until the length has stayed below the shrink
+	// threshold for this amount of consecutive checks, to avoid thrashing on
+	// workloads that grow and shrink repeatedly. Zero disables it.
+	window int
+	below  int
+	// peak is the largest length seen while below the threshold.
+	peak int
+}
+
+func newShrinkPolicy(o options) shrinkPolicy {
+	return shrinkPolicy{minCap: o.minCap, window: o.shrinkHysteresis}
+}
+
+func (sp *shrinkPolicy) shouldShrink(l, c int) (newCap int, ok bool) {
+	newCap, ok = sp.hysteresis(l, c)
+	newCap = max(newCap, sp.minCap)
+	return newCap, ok && newCap < c
+}
+
+func (sp *shrinkPolicy) hysteresis(l, c int) (newCap int, ok bool) {
+	newCap, ok = shouldShrink(l, c)
+	if sp.window == 0 {
+		return newCap, ok
+	}
+	if !ok {
+		sp.below, sp.peak = 0, 0
+		return newCap, false
+	}
+	sp.below++
+	sp.peak = max(sp.peak, l)
+	if sp.below < sp.window {
+		return newCap, false
+	}
+	newCap = sp.peak * growthFactor
+	sp.below, sp.peak = 0, 0
+	return newCap, true
+}
+
+// opCounters counts the operations performed on a queue.
+// Its methods are no-ops on a nil *opCounters, which is what queues hold unless
+// WithCounters is used.
+type opCounters struct {
+	enqueued, dequeued uint64
+}
+
+func newOpCounters(o options) *opCounters {
+	if !o.counters {
+		return nil
+	}
+	return &opCounters{}
+}
+
+func (c *opCounters) enqueue() {
+	if c != nil {
+		c.enqueued++
+	}
+}
+
+func (c *opCounters) enqueueN(n int) {
+	if c != nil {
+		c.enqueued += uint64(n)
+	}
+}
+
+func (c *opCounters) dequeue(n int) {
+	if c != nil {
+		c.dequeued += uint64(n)
+	}
+}
+
+func (c *opCounters) get() (enqueued, dequeued uint64) {
+	if c == nil {
+		return 0, 0
+	}
+	return c.enqueued, c.dequeued
+}
+
+// clone returns new counters if c is enabled, so that a copy of a queue counts
+// its own operations.
+func (c *opCounters) clone() *opCounters {
+	if c == nil {
+		return nil
+	}
+	return &opCounters{}
+}
+
+// growthPolicy decides how much backing stores grow.
+// The zero value always grows by growthFactor.
+type growthPolicy struct {
+	// slowAbove is the capacity from which growth slows down to 1.5x.
+	// Zero disables it.
+	slowAbove int
+}
+
+// growCap returns the capacity the backing store should grow to from c in
+// order to fit need elements.
+// Bursts larger than a growth step get exactly the room they need, so that
+// they cause a single reallocation without overshooting.
+func (gp growthPolicy) growCap(c, need int) int {
+	next := growthFactor * c
+	if gp.slowAbove > 0 && c >= gp.slowAbove {
+		next = c + c/2
+	}
+	return max(next, baseLen, need)
+}
+
+// growCap is growthPolicy.growCap with the default policy.
+func growCap(c, need int) int {
+	return growthPolicy{}.growCap(c, need)
+}
+
+// ErrEmptyQueue is the value queues panic with when Dequeue is called on an
+// empty queue. It can be recovered and compared with errors.Is.
+var ErrEmptyQueue = errors.New("dequeue from empty queue")
+
 // Queue represents a queue of elements.
 // It is expected to automatically shrink its capacity when its length shrinks.
 type Queue[T any] interface {
 	// Len returns the amount of elements stored.
 	Len() int
 	// Dequeue returns the first element and removes it from the queue.
-	// Callers are responsible to check if Len>0 before calling Dequeue.
+	// Callers are responsible to check if Len>0 before calling Dequeue,
+	// which panics with ErrEmptyQueue otherwise.
 	Dequeue() (t T)
 	// Enqueue adds an element at the end of the queue.
 	Enqueue(t T)
+	// Ends returns the element that would be dequeued next and the most
+	// recently enqueued one, without removing them.
+	// ok is false if the queue is empty.
+	Ends() (front, back T, ok bool)
 }
 
 // Slice
 
 var _ Queue[int] = &sliceQueue[int]{}
 
-type sliceQueue[T any] []T
+type sliceQueue[T any] struct {
+	s []T
+	// dropped is the amount of slots at the start of the backing array that
+	// were left behind by Dequeue.
+	dropped int
+	// autoCompact is the fraction of the backing array that can be left
+	// behind before compacting. Zero disables auto-compaction.
+	autoCompact float64
+	shrink      shrinkPolicy
+	growth      growthPolicy
+	counters    *opCounters
+	// shared is set when s might be in use by a copy-on-write clone.
+	shared bool
+}
+
+// NewSliceQueue returns an empty slice backed queue.
+// Use WithAutoCompact to release the space left behind by dequeues.
+// The returned queue has a Compact method.
+func NewSliceQueue[T any](opts ...Option) Queue[T] {
+	o := newOptions(opts)
+	return &sliceQueue[T]{
+		autoCompact: o.autoCompact,
+		shrink:      newShrinkPolicy(o),
+		growth:      growthPolicy{slowAbove: o.slowGrowthAbove},
+		counters:    newOpCounters(o),
+	}
+}
 
 func (sq *sliceQueue[T]) Len() int {
-	return len(*sq)
+	return len(sq.s)
+}
+
+// Cap returns the capacity of the backing array, including the slots left
+// behind by dequeues.
+func (sq *sliceQueue[T]) Cap() int {
+	return sq.dropped + cap(sq.s)
+}
+
+func (sq *sliceQueue[T]) realloc(nc int) {
+	n := make([]T, len(sq.s), nc)
+	copy(n, sq.s)
+	sq.s = n
+	sq.dropped = 0
+	sq.shared = false
+}
+
+func (sq *sliceQueue[T]) reserve(n int, exact bool) {
+	need := len(sq.s) + n
+	if need <= cap(sq.s) {
+		return
+	}
+	nc := need
+	if !exact {
+		nc = sq.growth.growCap(cap(sq.s), need)
+	}
+	sq.realloc(nc)
+}
+
+// adopt takes over the backing array of src, which is left empty. The queue
+// must be empty.
+func (sq *sliceQueue[T]) adopt(src *sliceQueue[T]) {
+	n := len(src.s)
+	sq.s, sq.dropped, sq.shared = src.s, src.dropped, src.shared
+	src.s, src.dropped, src.shared = nil, 0, false
+	sq.counters.enqueueN(n)
+	src.counters.dequeue(n)
+}
+
+// Compact moves the elements to a backing array of exactly Len elements,
+// releasing the slots left behind by dequeues, and returns the amount of slots
+// released.
+func (sq *sliceQueue[T]) Compact() (reclaimed int) {
+	before := sq.Cap()
+	sq.realloc(len(sq.s))
+	return before - sq.Cap()
+}
+
+// Resize moves the elements to a backing array of exactly newCap elements.
+// It returns an error if newCap is less than Len.
+// Later operations might still grow or shrink the queue.
+func (sq *sliceQueue[T]) Resize(newCap int) error {
+	if err := checkResize(len(sq.s), newCap); err != nil {
+		return err
+	}
+	sq.realloc(newCap)
+	return nil
 }
 
 func (sq *sliceQueue[T]) checkShrink() {
-	if nl, ok := shouldShrink(len(*sq), cap(*sq)); ok {
-		n := make([]T, len(*sq), nl)
-		copy(n, *sq)
-		*sq = n
+	if nl, ok := sq.shrink.shouldShrink(len(sq.s), cap(sq.s)); ok {
+		sq.realloc(nl)
+		return
+	}
+	if sq.autoCompact > 0 && float64(sq.dropped) > sq.autoCompact*float64(sq.Cap()) {
+		sq.realloc(max(len(sq.s), sq.shrink.minCap))
 	}
 }
 
 func (sq *sliceQueue[T]) Dequeue() T {
-	v := (*sq)[0]
-	*sq = (*sq)[1:]
+	if len(sq.s) == 0 {
+		panic(ErrEmptyQueue)
+	}
+	v := sq.s[0]
+	sq.s = sq.s[1:]
+	sq.dropped++
+	sq.counters.dequeue(1)
 	sq.checkShrink()
 	return v
 }
 
+// DequeueMatching removes and returns the first element for which pred
+// returns true, shifting the elements before it. It reports false if there is
+// none.
+func (sq *sliceQueue[T]) DequeueMatching(pred func(T) bool) (t T, ok bool) {
+	i := slices.IndexFunc(sq.s, pred)
+	if i < 0 {
+		return t, false
+	}
+	if sq.shared {
+		sq.realloc(cap(sq.s))
+	}
+	t = sq.s[i]
+	copy(sq.s[1:i+1], sq.s[:i])
+	var zero T
+	sq.s[0] = zero
+	sq.s = sq.s[1:]
+	sq.dropped++
+	sq.counters.dequeue(1)
+	sq.checkShrink()
+	return t, true
+}
+
+// TrimBack removes elements from the back until at most n are left, and
+// returns how many were removed.
+func (sq *sliceQueue[T]) TrimBack(n int) int {
+	n = max(n, 0)
+	if len(sq.s) <= n {
+		return 0
+	}
+	if sq.shared {
+		sq.realloc(cap(sq.s))
+	}
+	trimmed := len(sq.s) - n
+	clear(sq.s[n:])
+	sq.s = sq.s[:n]
+	sq.counters.dequeue(trimmed)
+	sq.checkShrink()
+	return trimmed
+}
+
+// Shuffle randomly permutes the elements in place, using r as the source of
+// randomness.
+func (sq *sliceQueue[T]) Shuffle(r *rand.Rand) {
+	if sq.shared {
+		sq.realloc(cap(sq.s))
+	}
+	r.Shuffle(len(sq.s), func(i, j int) {
+		sq.s[i], sq.s[j] = sq.s[j], sq.s[i]
+	})
+}
+
+// Counters returns the amount of elements enqueued and dequeued so far, if
+// the queue was created with WithCounters, or zeros otherwise.
+func (sq *sliceQueue[T]) Counters() (enqueued, dequeued uint64) {
+	return sq.counters.get()
+}
+
+// CloneCOW returns a copy of the queue that shares the backing array with it.
+// Queues only write to the slots they hold through PeekPtr, which copies the
+// array first, and the clone has no spare capacity, so appending to either
+// queue can't affect the other.
+func (sq *sliceQueue[T]) CloneCOW() Queue[T] {
+	sq.shared = true
+	return &sliceQueue[T]{
+		s:           sq.s[:len(sq.s):len(sq.s)],
+		autoCompact: sq.autoCompact,
+		shrink:      shrinkPolicy{minCap: sq.shrink.minCap, window: sq.shrink.window},
+		growth:      sq.growth,
+		counters:    sq.counters.clone(),
+		shared:      true,
+	}
+}
+
+// PeekPtr returns a pointer to the first element, or nil if the queue is
+// empty. It avoids copying large elements, and the element can be modified in
+// place through it.
+// The pointer aliases the backing array: it is only valid until the next call
+// that modifies the queue, after which it might point to a stale copy or to a
+// slot that was reused for another element.
+func (sq *sliceQueue[T]) PeekPtr() *T {
+	if len(sq.s) == 0 {
+		return nil
+	}
+	if sq.shared {
+		sq.realloc(cap(sq.s))
+	}
+	return &sq.s[0]
+}
+
+func (sq *sliceQueue[T]) Ends() (front, back T, ok bool) {
+	if len(sq.s) == 0 {
+		return front, back, false
+	}
+	return sq.s[0], sq.s[len(sq.s)-1], true
+}
+
+// ReplaceFront replaces the first element with v and returns the old one, or
+// reports false if the queue is empty.
+func (sq *sliceQueue[T]) ReplaceFront(v T) (old T, ok bool) {
+	return sq.replace(0, v)
+}
+
+// ReplaceBack replaces the last element with v and returns the old one, or
+// reports false if the queue is empty.
+func (sq *sliceQueue[T]) ReplaceBack(v T) (old T, ok bool) {
+	return sq.replace(len(sq.s)-1, v)
+}
+
+func (sq *sliceQueue[T]) replace(i int, v T) (old T, ok bool) {
+	if len(sq.s) == 0 {
+		return old, false
+	}
+	if sq.shared {
+		sq.realloc(cap(sq.s))
+	}
+	old, sq.s[i] = sq.s[i], v
+	return old, true
+}
+
+// PeekN returns a copy of up to n elements from the front of the queue,
+// without removing them.
+func (sq *sliceQueue[T]) PeekN(n int) []T {
+	n = min(n, len(sq.s))
+	if n <= 0 {
+		return nil
+	}
+	return append([]T(nil), sq.s[:n]...)
+}
+
 func (sq *sliceQueue[T]) Enqueue(v T) {
-	if *sq == nil {
-		*sq = make([]T, 0, baseLen)
+	if sq.s == nil {
+		sq.s = make([]T, 0, baseLen)
+	}
+	if len(sq.s) == cap(sq.s) {
+		if sq.growth.slowAbove > 0 {
+			sq.realloc(sq.growth.growCap(cap(sq.s), len(sq.s)+1))
+		} else {
+			// append is going to reallocate.
+			sq.dropped = 0
+		}
 	}
-	*sq = append(*sq, v)
+	sq.s = append(sq.s, v)
+	sq.counters.enqueue()
 }
 
 // LinkedList
@@ -70,6 +433,16 @@ type elem[T any] struct {
 	next *elem[T]
 }
 
+// replaceElem replaces the value of e with v and returns the old one, or
+// reports false if e is nil.
+func replaceElem[T any](e *elem[T], v T) (old T, ok bool) {
+	if e == nil {
+		return old, false
+	}
+	old, e.v = e.v, v
+	return old, true
+}
+
 type linkedListQueue[T any] struct {
 	len  int
 	head *elem[T]
@@ -82,7 +455,7 @@ func (sq *linkedListQueue[T]) Len() int {
 
 func (sq *linkedListQueue[T]) Dequeue() T {
 	if sq.head == nil {
-		panic("dequeue from empty queue")
+		panic(ErrEmptyQueue)
 	}
 	sq.len--
 	v := sq.head.v
@@ -93,6 +466,42 @@ func (sq *linkedListQueue[T]) Dequeue() T {
 	return v
 }
 
+// DequeueMatching removes and returns the first element for which pred
+// returns true, unlinking its node. It reports false if there is none.
+func (sq *linkedListQueue[T]) DequeueMatching(pred func(T) bool) (t T, ok bool) {
+	e, ok := unlinkMatching(&sq.head, &sq.tail, pred)
+	if !ok {
+		return t, false
+	}
+	sq.len--
+	return e.v, true
+}
+
+func (sq *linkedListQueue[T]) Ends() (front, back T, ok bool) {
+	if sq.head == nil {
+		return front, back, false
+	}
+	return sq.head.v, sq.tail.v, true
+}
+
+// ReplaceFront replaces the first element with v and returns the old one, or
+// reports false if the queue is empty.
+func (sq *linkedListQueue[T]) ReplaceFront(v T) (old T, ok bool) {
+	return replaceElem(sq.head, v)
+}
+
+// ReplaceBack replaces the last element with v and returns the old one, or
+// reports false if the queue is empty.
+func (sq *linkedListQueue[T]) ReplaceBack(v T) (old T, ok bool) {
+	return replaceElem(sq.tail, v)
+}
+
+// PeekN returns up to n elements from the front of the queue, without
+// removing them.
+func (sq *linkedListQueue[T]) PeekN(n int) []T {
+	return peekList(sq.head, min(n, sq.len))
+}
+
 func (sq *linkedListQueue[T]) Enqueue(v T) {
 	sq.len++
 	var e elem[T] = elem[T]{v: v}
@@ -105,6 +514,40 @@ func (sq *linkedListQueue[T]) Enqueue(v T) {
 	sq.tail = &e
 }
 
+// unlinkMatching removes the first node for which pred returns true from the
+// list between head and tail, and returns it.
+func unlinkMatching[T any](head, tail **elem[T], pred func(T) bool) (*elem[T], bool) {
+	var prev *elem[T]
+	for e := *head; e != nil; prev, e = e, e.next {
+		if !pred(e.v) {
+			continue
+		}
+		if prev == nil {
+			*head = e.next
+		} else {
+			prev.next = e.next
+		}
+		if *tail == e {
+			*tail = prev
+		}
+		return e, true
+	}
+	return nil, false
+}
+
+// peekList returns the values of the first n nodes starting at e.
+func peekList[T any](e *elem[T], n int) []T {
+	if n <= 0 {
+		return nil
+	}
+	vs := make([]T, n)
+	for i := range vs {
+		vs[i] = e.v
+		e = e.next
+	}
+	return vs
+}
+
 // LinkedList with mempool
 
 var _ Queue[int] = &linkedListPooledQueue[int]{}
@@ -114,10 +557,22 @@ type linkedListPooledQueue[T any] struct {
 	p    *sync.Pool
 	head *elem[T]
 	tail *elem[T]
+	// keepOnRecycle skips clearing values of nodes returned to the pool.
+	keepOnRecycle bool
+	counters      *opCounters
 }
 
-func newPooled[T any]() *linkedListPooledQueue[T] {
+// NewPooledQueue returns an empty linked list backed queue that recycles its
+// nodes through a sync.Pool.
+func NewPooledQueue[T any](opts ...Option) Queue[T] {
+	return newPooled[T](opts...)
+}
+
+func newPooled[T any](opts ...Option) *linkedListPooledQueue[T] {
+	o := newOptions(opts)
 	return &linkedListPooledQueue[T]{
+		keepOnRecycle: o.keepOnRecycle,
+		counters:      newOpCounters(o),
 		p: &sync.Pool{
 			New: func() any {
 				return &elem[T]{}
@@ -126,27 +581,85 @@ func newPooled[T any]() *linkedListPooledQueue[T] {
 	}
 }
 
+// recycle returns e to the pool, clearing it so that the pool doesn't keep
+// its value alive.
+func (sq *linkedListPooledQueue[T]) recycle(e *elem[T]) {
+	if !sq.keepOnRecycle {
+		var zero T
+		e.v = zero
+	}
+	e.next = nil
+	sq.p.Put(e)
+}
+
 func (sq *linkedListPooledQueue[T]) Len() int {
 	return sq.len
 }
 
 func (sq *linkedListPooledQueue[T]) Dequeue() T {
-	sq.len--
 	if sq.head == nil {
-		panic("pop from empty list")
+		panic(ErrEmptyQueue)
 	}
+	sq.len--
 	oldHead := sq.head
 	v := oldHead.v
 	sq.head = oldHead.next
-	sq.p.Put(oldHead)
+	sq.recycle(oldHead)
 	if sq.head == nil {
 		sq.tail = nil
 	}
+	sq.counters.dequeue(1)
 	return v
 }
 
+// Counters returns the amount of elements enqueued and dequeued so far, if
+// the queue was created with WithCounters, or zeros otherwise.
+func (sq *linkedListPooledQueue[T]) Counters() (enqueued, dequeued uint64) {
+	return sq.counters.get()
+}
+
+// DequeueMatching removes and returns the first element for which pred
+// returns true, unlinking its node. It reports false if there is none.
+func (sq *linkedListPooledQueue[T]) DequeueMatching(pred func(T) bool) (t T, ok bool) {
+	e, ok := unlinkMatching(&sq.head, &sq.tail, pred)
+	if !ok {
+		return t, false
+	}
+	sq.len--
+	t = e.v
+	sq.recycle(e)
+	sq.counters.dequeue(1)
+	return t, true
+}
+
+func (sq *linkedListPooledQueue[T]) Ends() (front, back T, ok bool) {
+	if sq.head == nil {
+		return front, back, false
+	}
+	return sq.head.v, sq.tail.v, true
+}
+
+// ReplaceFront replaces the first element with v and returns the old one, or
+// reports false if the queue is empty.
+func (sq *linkedListPooledQueue[T]) ReplaceFront(v T) (old T, ok bool) {
+	return replaceElem(sq.head, v)
+}
+
+// ReplaceBack replaces the last element with v and returns the old one, or
+// reports false if the queue is empty.
+func (sq *linkedListPooledQueue[T]) ReplaceBack(v T) (old T, ok bool) {
+	return replaceElem(sq.tail, v)
+}
+
+// PeekN returns up to n elements from the front of the queue, without
+// removing them.
+func (sq *linkedListPooledQueue[T]) PeekN(n int) []T {
+	return peekList(sq.head, min(n, sq.len))
+}
+
 func (sq *linkedListPooledQueue[T]) Enqueue(v T) {
 	sq.len++
+	sq.counters.enqueue()
 	e := sq.p.Get().(*elem[T])
 	e.v = v
 	e.next = nil
@@ -159,14 +672,239 @@ func (sq *linkedListPooledQueue[T]) Enqueue(v T) {
 	sq.tail = e
 }
 
+// DequeueN removes up to n elements from the front of the queue and returns them.
+// Nodes are reset and returned to the pool in a single pass.
+func (sq *linkedListPooledQueue[T]) DequeueN(n int) []T {
+	n = min(n, sq.len)
+	if n <= 0 {
+		return nil
+	}
+	vs := make([]T, n)
+	for i := range vs {
+		e := sq.head
+		vs[i] = e.v
+		sq.head = e.next
+		sq.recycle(e)
+	}
+	sq.len -= n
+	if sq.head == nil {
+		sq.tail = nil
+	}
+	sq.counters.dequeue(n)
+	return vs
+}
+
+// DropWhile removes elements from the front of the queue while pred returns
+// true for them, and returns how many were removed.
+// Like for DequeueN, nodes are reset and returned to the pool in a single pass.
+func (sq *linkedListPooledQueue[T]) DropWhile(pred func(T) bool) int {
+	n := 0
+	for sq.head != nil && pred(sq.head.v) {
+		e := sq.head
+		sq.head = e.next
+		sq.recycle(e)
+		n++
+	}
+	sq.len -= n
+	if sq.head == nil {
+		sq.tail = nil
+	}
+	sq.counters.dequeue(n)
+	return n
+}
+
+// LinkedList with batched allocations
+
+var _ Queue[int] = &linkedListBatchedQueue[int]{}
+
+// nodeBatchLen is the amount of nodes allocated at once by
+// linkedListBatchedQueue.
+const nodeBatchLen = 64
+
+type linkedListBatchedQueue[T any] struct {
+	len  int
+	head *elem[T]
+	tail *elem[T]
+	// free holds the nodes of the current batch that were not handed out yet.
+	free []elem[T]
+}
+
+// NewBatchedListQueue returns an empty linked list backed queue that allocates
+// its nodes nodeBatchLen at a time, which reduces allocations by the same
+// factor.
+// A batch is only released once all of its nodes are dequeued.
+func NewBatchedListQueue[T any]() Queue[T] {
+	return &linkedListBatchedQueue[T]{}
+}
+
+func (sq *linkedListBatchedQueue[T]) Len() int {
+	return sq.len
+}
+
+func (sq *linkedListBatchedQueue[T]) Dequeue() T {
+	if sq.head == nil {
+		panic(ErrEmptyQueue)
+	}
+	sq.len--
+	e := sq.head
+	v := e.v
+	sq.head = e.next
+	if sq.head == nil {
+		sq.tail = nil
+	}
+	// The node can't be reused, but it is kept alive by the rest of its batch.
+	var zero T
+	e.v = zero
+	e.next = nil
+	return v
+}
+
+func (sq *linkedListBatchedQueue[T]) Ends() (front, back T, ok bool) {
+	if sq.head == nil {
+		return front, back, false
+	}
+	return sq.head.v, sq.tail.v, true
+}
+
+// ReplaceFront replaces the first element with v and returns the old one, or
+// reports false if the queue is empty.
+func (sq *linkedListBatchedQueue[T]) ReplaceFront(v T) (old T, ok bool) {
+	return replaceElem(sq.head, v)
+}
+
+// ReplaceBack replaces the last element with v and returns the old one, or
+// reports false if the queue is empty.
+func (sq *linkedListBatchedQueue[T]) ReplaceBack(v T) (old T, ok bool) {
+	return replaceElem(sq.tail, v)
+}
+
+// PeekN returns up to n elements from the front of the queue, without
+// removing them.
+func (sq *linkedListBatchedQueue[T]) PeekN(n int) []T {
+	return peekList(sq.head, min(n, sq.len))
+}
+
+func (sq *linkedListBatchedQueue[T]) Enqueue(v T) {
+	if len(sq.free) == 0 {
+		sq.free = make([]elem[T], nodeBatchLen)
+	}
+	e := &sq.free[0]
+	sq.free = sq.free[1:]
+	e.v = v
+	sq.len++
+	if sq.tail == nil {
+		sq.head = e
+		sq.tail = e
+		return
+	}
+	sq.tail.next = e
+	sq.tail = e
+}
+
+// Slabs
+
+var _ Queue[int] = &slabQueue[int]{}
+
+type slabQueue[T any] struct {
+	slabSize int
+	// slabs holds the slabs in FIFO order. Elements are read from the first
+	// one starting at r, and written to the last one starting at w.
+	slabs ringQueue[[]T]
+	r, w  int
+	len   int
+}
+
+// NewSlabQueue returns an empty queue that stores its elements in slabs of
+// slabSize elements, allocated one at a time as the queue grows.
+// Every slab is a single allocation, so large queues only need a few of them,
+// and a slab is released as soon as all of its elements are dequeued. For
+// element types without pointers the GC doesn't need to scan slabs at all.
+func NewSlabQueue[T any](slabSize int) Queue[T] {
+	return &slabQueue[T]{slabSize: max(slabSize, 1)}
+}
+
+func (sq *slabQueue[T]) Len() int {
+	return sq.len
+}
+
+func (sq *slabQueue[T]) Dequeue() T {
+	if sq.len == 0 {
+		panic(ErrEmptyQueue)
+	}
+	s := sq.slabs.at(0)
+	var zero T
+	v := s[sq.r]
+	s[sq.r] = zero
+	sq.r++
+	sq.len--
+	switch {
+	case sq.r == sq.slabSize:
+		sq.slabs.Dequeue()
+		sq.r = 0
+	case sq.len == 0:
+		// The only slab left is empty, so it can be reused from the start.
+		sq.r, sq.w = 0, 0
+	}
+	return v
+}
+
+func (sq *slabQueue[T]) Ends() (front, back T, ok bool) {
+	if sq.len == 0 {
+		return front, back, false
+	}
+	return sq.slabs.at(0)[sq.r], sq.slabs.at(sq.slabs.Len() - 1)[sq.w-1], true
+}
+
+// ReplaceFront replaces the first element with v and returns the old one, or
+// reports false if the queue is empty.
+func (sq *slabQueue[T]) ReplaceFront(v T) (old T, ok bool) {
+	if sq.len == 0 {
+		return old, false
+	}
+	s := sq.slabs.at(0)
+	old, s[sq.r] = s[sq.r], v
+	return old, true
+}
+
+// ReplaceBack replaces the last element with v and returns the old one, or
+// reports false if the queue is empty.
+func (sq *slabQueue[T]) ReplaceBack(v T) (old T, ok bool) {
+	if sq.len == 0 {
+		return old, false
+	}
+	s := sq.slabs.at(sq.slabs.Len() - 1)
+	old, s[sq.w-1] = s[sq.w-1], v
+	return old, true
+}
+
+func (sq *slabQueue[T]) Enqueue(v T) {
+	if sq.slabs.Len() == 0 || sq.w == sq.slabSize {
+		sq.slabs.Enqueue(make([]T, sq.slabSize))
+		sq.w = 0
+	}
+	sq.slabs.at(sq.slabs.Len() - 1)[sq.w] = v
+	sq.w++
+	sq.len++
+}
+
 // Chan
 
 var _ Queue[int] = newChanQueue[int]()
 
-func copyChan[T any](dst chan<- T, src chan T) {
-	close(src)
-	for v := range src {
-		dst <- v
+// moveChan moves the elements buffered in src to dst, which must have room for
+// them. src is not closed, and it is left empty.
+func moveChan[T any](dst chan<- T, src chan T) {
+	for range len(src) {
+		select {
+		case v := <-src:
+			select {
+			case dst <- v:
+			default:
+				panic("not enough room to move chan queue elements")
+			}
+		default:
+			panic("chan queue was modified while moving its elements")
+		}
 	}
 }
 
@@ -181,10 +919,41 @@ func (cq *chanQueue[T]) Len() int {
 	return len(*cq)
 }
 
+func (cq *chanQueue[T]) Cap() int {
+	return cap(*cq)
+}
+
+// Resize moves the elements to a channel with a buffer of exactly newCap
+// elements. It returns an error if newCap is less than Len.
+// Later operations might still grow or shrink the queue.
+func (cq *chanQueue[T]) Resize(newCap int) error {
+	if err := checkResize(len(*cq), newCap); err != nil {
+		return err
+	}
+	n := make(chan T, newCap)
+	moveChan(n, *cq)
+	*cq = n
+	return nil
+}
+
+func (cq *chanQueue[T]) reserve(n int, exact bool) {
+	need := len(*cq) + n
+	if need <= cap(*cq) {
+		return
+	}
+	nc := need
+	if !exact {
+		nc = growCap(cap(*cq), need)
+	}
+	c := make(chan T, nc)
+	moveChan(c, *cq)
+	*cq = c
+}
+
 func (cq *chanQueue[T]) checkShrink() {
 	if nl, ok := shouldShrink(len(*cq), cap(*cq)); ok {
 		n := make(chan T, nl)
-		copyChan(n, *cq)
+		moveChan(n, *cq)
 		*cq = n
 	}
 }
@@ -195,16 +964,33 @@ func (cq *chanQueue[T]) Dequeue() T {
 		cq.checkShrink()
 		return v
 	default:
-		panic("dequeue from empty queue")
+		panic(ErrEmptyQueue)
 	}
 }
 
+// Ends has to receive and re-send all elements, as channels can't be peeked.
+func (cq *chanQueue[T]) Ends() (front, back T, ok bool) {
+	n := len(*cq)
+	if n == 0 {
+		return front, back, false
+	}
+	for i := range n {
+		v := <-*cq
+		if i == 0 {
+			front = v
+		}
+		back = v
+		*cq <- v
+	}
+	return front, back, true
+}
+
 func (cq *chanQueue[T]) Enqueue(v T) {
 	select {
 	case *cq <- v:
 	default:
-		n := make(chan T, cap(*cq)*growthFactor)
-		copyChan(n, *cq)
+		n := make(chan T, growCap(cap(*cq), len(*cq)+1))
+		moveChan(n, *cq)
 		*cq = n
 		n <- v
 	}
@@ -217,12 +1003,48 @@ var _ Queue[int] = &ringQueue[int]{}
 type ringQueue[T any] struct {
 	first, l int
 	buf      []T
+	shrink   shrinkPolicy
+	growth   growthPolicy
+	counters *opCounters
+	// shared is set when buf might be in use by a copy-on-write clone.
+	shared bool
+}
+
+// NewRingQueue returns an empty ring buffer backed queue.
+func NewRingQueue[T any](opts ...Option) Queue[T] {
+	o := newOptions(opts)
+	return &ringQueue[T]{
+		shrink:   newShrinkPolicy(o),
+		growth:   growthPolicy{slowAbove: o.slowGrowthAbove},
+		counters: newOpCounters(o),
+	}
 }
 
 func (sq *ringQueue[T]) Len() int {
 	return sq.l
 }
 
+func (sq *ringQueue[T]) Cap() int {
+	return len(sq.buf)
+}
+
+func (sq *ringQueue[T]) reserve(n int, exact bool) {
+	need := sq.l + n
+	if need <= len(sq.buf) {
+		return
+	}
+	nc := need
+	if !exact {
+		nc = sq.growth.growCap(len(sq.buf), need)
+	}
+	sq.swapBuf(make([]T, nc))
+}
+
+// at returns the i-th element in FIFO order.
+func (sq *ringQueue[T]) at(i int) T {
+	return sq.buf[(sq.first+i)%len(sq.buf)]
+}
+
 func (sq *ringQueue[T]) swapBuf(n []T) {
 	if sq.first+sq.l > len(sq.buf) {
 		skip := copy(n, sq.buf[sq.first:])
@@ -232,10 +1054,49 @@ func (sq *ringQueue[T]) swapBuf(n []T) {
 	}
 	sq.first = 0
 	sq.buf = n
+	sq.shared = false
+}
+
+// adopt takes over the backing buffer of src, which is left empty. The queue
+// must be empty.
+func (sq *ringQueue[T]) adopt(src *ringQueue[T]) {
+	n := src.l
+	sq.first, sq.l, sq.buf, sq.shared = src.first, src.l, src.buf, src.shared
+	src.first, src.l, src.buf, src.shared = 0, 0, nil, false
+	sq.counters.enqueueN(n)
+	src.counters.dequeue(n)
+}
+
+// unshare copies buf if it is shared with a clone, so that it can be written.
+func (sq *ringQueue[T]) unshare() {
+	if sq.shared {
+		sq.swapBuf(make([]T, len(sq.buf)))
+	}
+}
+
+// CloneCOW returns a copy of the queue that shares the backing buffer with
+// it. The first write on either queue copies the buffer.
+func (sq *ringQueue[T]) CloneCOW() Queue[T] {
+	sq.shared = true
+	c := *sq
+	c.shrink = shrinkPolicy{minCap: sq.shrink.minCap, window: sq.shrink.window}
+	c.counters = sq.counters.clone()
+	return &c
+}
+
+// Resize moves the elements to a buffer of exactly newCap elements, starting
+// at its beginning. It returns an error if newCap is less than Len.
+// Later operations might still grow or shrink the queue.
+func (sq *ringQueue[T]) Resize(newCap int) error {
+	if err := checkResize(sq.l, newCap); err != nil {
+		return err
+	}
+	sq.swapBuf(make([]T, newCap))
+	return nil
 }
 
 func (sq *ringQueue[T]) checkShrink() {
-	nl, ok := shouldShrink(sq.l, len(sq.buf))
+	nl, ok := sq.shrink.shouldShrink(sq.l, len(sq.buf))
 	if !ok {
 		return
 	}
@@ -245,26 +1106,216 @@ func (sq *ringQueue[T]) checkShrink() {
 
 func (sq *ringQueue[T]) Dequeue() T {
 	if sq.l == 0 {
-		panic("dequeue on empty queue")
+		panic(ErrEmptyQueue)
 	}
 	v := sq.buf[sq.first]
 	sq.first = (sq.first + 1) % len(sq.buf)
 	sq.l--
+	sq.counters.dequeue(1)
 	sq.checkShrink()
 	return v
 }
 
+// Counters returns the amount of elements enqueued and dequeued so far, if
+// the queue was created with WithCounters, or zeros otherwise.
+func (sq *ringQueue[T]) Counters() (enqueued, dequeued uint64) {
+	return sq.counters.get()
+}
+
+// PeekPtr returns a pointer to the first element, or nil if the queue is
+// empty. It avoids copying large elements, and the element can be modified in
+// place through it.
+// The pointer aliases the backing buffer: it is only valid until the next call
+// that modifies the queue, after which it might point to a stale copy or to a
+// slot that was reused for another element.
+func (sq *ringQueue[T]) PeekPtr() *T {
+	if sq.l == 0 {
+		return nil
+	}
+	sq.unshare()
+	return &sq.buf[sq.first]
+}
+
+func (sq *ringQueue[T]) Ends() (front, back T, ok bool) {
+	if sq.l == 0 {
+		return front, back, false
+	}
+	return sq.buf[sq.first], sq.at(sq.l - 1), true
+}
+
+// ReplaceFront replaces the first element with v and returns the old one, or
+// reports false if the queue is empty.
+func (sq *ringQueue[T]) ReplaceFront(v T) (old T, ok bool) {
+	return sq.replace(0, v)
+}
+
+// ReplaceBack replaces the last element with v and returns the old one, or
+// reports false if the queue is empty.
+func (sq *ringQueue[T]) ReplaceBack(v T) (old T, ok bool) {
+	return sq.replace(sq.l-1, v)
+}
+
+func (sq *ringQueue[T]) replace(i int, v T) (old T, ok bool) {
+	if sq.l == 0 {
+		return old, false
+	}
+	sq.unshare()
+	old = sq.at(i)
+	sq.set(i, v)
+	return old, true
+}
+
+// PeekN returns up to n elements from the front of the queue, without
+// removing them.
+func (sq *ringQueue[T]) PeekN(n int) []T {
+	n = min(n, sq.l)
+	if n <= 0 {
+		return nil
+	}
+	vs := make([]T, n)
+	skip := copy(vs, sq.buf[sq.first:min(sq.first+n, len(sq.buf))])
+	copy(vs[skip:], sq.buf[:n-skip])
+	return vs
+}
+
 func (sq *ringQueue[T]) grow() {
-	n := make([]T, max(growthFactor*len(sq.buf), baseLen))
-	sq.swapBuf(n)
+	sq.swapBuf(make([]T, sq.growth.growCap(len(sq.buf), sq.l+1)))
 }
 
 func (sq *ringQueue[T]) Enqueue(v T) {
 	if sq.l+1 > len(sq.buf) {
 		sq.grow()
+	} else {
+		sq.unshare()
 	}
 	sq.buf[(sq.first+sq.l)%len(sq.buf)] = v
 	sq.l++
+	sq.counters.enqueue()
+}
+
+func (sq *ringQueue[T]) set(i int, v T) {
+	sq.buf[(sq.first+i)%len(sq.buf)] = v
+}
+
+// InsertAt inserts v so that it becomes the i-th element, where 0 is the front.
+// It shifts whichever side of the queue is shorter.
+// It panics if i is not in [0, Len()].
+func (sq *ringQueue[T]) InsertAt(i int, v T) {
+	if i < 0 || i > sq.l {
+		panic("insert index out of range")
+	}
+	if sq.l+1 > len(sq.buf) {
+		sq.grow()
+	} else {
+		sq.unshare()
+	}
+	if i < sq.l/2 {
+		sq.first = (sq.first - 1 + len(sq.buf)) % len(sq.buf)
+		for k := range i {
+			sq.set(k, sq.at(k+1))
+		}
+	} else {
+		for k := sq.l; k > i; k-- {
+			sq.set(k, sq.at(k-1))
+		}
+	}
+	sq.set(i, v)
+	sq.l++
+	sq.counters.enqueue()
+}
+
+// RemoveAt removes and returns the i-th element, where 0 is the front.
+// It shifts whichever side of the queue is shorter.
+// It reports false if i is out of range.
+func (sq *ringQueue[T]) RemoveAt(i int) (t T, ok bool) {
+	if i < 0 || i >= sq.l {
+		return t, false
+	}
+	sq.unshare()
+	var zero T
+	t = sq.at(i)
+	if i < sq.l/2 {
+		for k := i; k > 0; k-- {
+			sq.set(k, sq.at(k-1))
+		}
+		sq.set(0, zero)
+		sq.first = (sq.first + 1) % len(sq.buf)
+	} else {
+		for k := i; k < sq.l-1; k++ {
+			sq.set(k, sq.at(k+1))
+		}
+		sq.set(sq.l-1, zero)
+	}
+	sq.l--
+	sq.counters.dequeue(1)
+	sq.checkShrink()
+	return t, true
+}
+
+// StealHalf removes the back half of the elements and returns them in FIFO
+// order, leaving the front half in the queue. With an odd length the queue
+// keeps the extra element, so a single element is never stolen.
+// It is meant for work-stealing schedulers, where idle workers take work from
+// the back of a busy worker's queue.
+func (sq *ringQueue[T]) StealHalf() []T {
+	n := sq.l / 2
+	if n == 0 {
+		return nil
+	}
+	sq.unshare()
+	var zero T
+	stolen := make([]T, n)
+	keep := sq.l - n
+	for i := range stolen {
+		stolen[i] = sq.at(keep + i)
+		sq.set(keep+i, zero)
+	}
+	sq.l = keep
+	sq.counters.dequeue(n)
+	sq.checkShrink()
+	return stolen
+}
+
+// DequeueMatching removes and returns the first element for which pred
+// returns true, shifting whichever side of the queue is shorter. It reports
+// false if there is none.
+func (sq *ringQueue[T]) DequeueMatching(pred func(T) bool) (t T, ok bool) {
+	for i := range sq.l {
+		if pred(sq.at(i)) {
+			return sq.RemoveAt(i)
+		}
+	}
+	return t, false
+}
+
+// TrimBack removes elements from the back until at most n are left, and
+// returns how many were removed.
+func (sq *ringQueue[T]) TrimBack(n int) int {
+	n = max(n, 0)
+	if sq.l <= n {
+		return 0
+	}
+	sq.unshare()
+	var zero T
+	trimmed := sq.l - n
+	for i := n; i < sq.l; i++ {
+		sq.set(i, zero)
+	}
+	sq.l = n
+	sq.counters.dequeue(trimmed)
+	sq.checkShrink()
+	return trimmed
+}
+
+// Shuffle randomly permutes the elements in place, in FIFO order, using r as
+// the source of randomness.
+func (sq *ringQueue[T]) Shuffle(r *rand.Rand) {
+	sq.unshare()
+	r.Shuffle(sq.l, func(i, j int) {
+		vi, vj := sq.at(i), sq.at(j)
+		sq.set(i, vj)
+		sq.set(j, vi)
+	})
 }
 
 // Map
@@ -280,13 +1331,19 @@ func newMapQueue[T any]() *mapQueue[T] {
 	return &mapQueue[T]{mem: make(map[uint64]T)}
 }
 
+// NewMapQueueCap returns an empty map backed queue with room for about hint
+// elements, which avoids rehashing while it grows up to that size.
+func NewMapQueueCap[T any](hint int) Queue[T] {
+	return &mapQueue[T]{mem: make(map[uint64]T, hint)}
+}
+
 func (mq *mapQueue[T]) Len() int {
 	return len(mq.mem)
 }
 
 func (mq *mapQueue[T]) Dequeue() T {
 	if len(mq.mem) == 0 {
-		panic("remove from empty map queue")
+		panic(ErrEmptyQueue)
 	}
 	v := mq.mem[mq.first]
 	delete(mq.mem, mq.first)
@@ -294,6 +1351,47 @@ func (mq *mapQueue[T]) Dequeue() T {
 	return v
 }
 
+func (mq *mapQueue[T]) Ends() (front, back T, ok bool) {
+	if len(mq.mem) == 0 {
+		return front, back, false
+	}
+	return mq.mem[mq.first], mq.mem[mq.last-1], true
+}
+
+// ReplaceFront replaces the first element with v and returns the old one, or
+// reports false if the queue is empty.
+func (mq *mapQueue[T]) ReplaceFront(v T) (old T, ok bool) {
+	return mq.replace(mq.first, v)
+}
+
+// ReplaceBack replaces the last element with v and returns the old one, or
+// reports false if the queue is empty.
+func (mq *mapQueue[T]) ReplaceBack(v T) (old T, ok bool) {
+	return mq.replace(mq.last-1, v)
+}
+
+func (mq *mapQueue[T]) replace(k uint64, v T) (old T, ok bool) {
+	if len(mq.mem) == 0 {
+		return old, false
+	}
+	old, mq.mem[k] = mq.mem[k], v
+	return old, true
+}
+
+// PeekN returns up to n elements from the front of the queue, without
+// removing them.
+func (mq *mapQueue[T]) PeekN(n int) []T {
+	n = min(n, len(mq.mem))
+	if n <= 0 {
+		return nil
+	}
+	vs := make([]T, n)
+	for i := range vs {
+		vs[i] = mq.mem[mq.first+uint64(i)]
+	}
+	return vs
+}
+
 func (mq *mapQueue[T]) Enqueue(v T) {
 	mq.mem[mq.last] = v
 	mq.last++
@@ -301,3 +1399,10 @@ func (mq *mapQueue[T]) Enqueue(v T) {
 		panic("this is impossible on modern machines")
 	}
 }
+
+// Reset empties the queue but keeps the map allocation, so that it can be
+// reused without having to grow it again.
+func (mq *mapQueue[T]) Reset() {
+	clear(mq.mem)
+	mq.first, mq.last = 0, 0
+}
@@ -1,9 +1,17 @@
 package queues
 
 import (
+	"errors"
+	"flag"
+	"io"
 	"math/rand"
+	"path/filepath"
+	"runtime"
+	"slices"
 	"strconv"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 )
@@ -38,6 +46,17 @@ var impls = []struct {
 			return newPooled[int]()
 		},
 	},
+	{"batched linked list",
+		func() Queue[int] {
+			return NewBatchedListQueue[int]()
+		},
+	},
+	{"slab queue",
+		func() Queue[int] {
+			// Small slabs to exercise slab boundaries.
+			return NewSlabQueue[int](16)
+		},
+	},
 	{"map queue",
 		func() Queue[int] {
 			return newMapQueue[int]()
@@ -124,13 +143,203 @@ func TestQueues(t *testing.T) {
 	}
 }
 
+func checkEnds(t *testing.T, q Queue[int], front, back int, ok bool) {
+	t.Helper()
+	f, b, gotOK := q.Ends()
+	if gotOK != ok || (ok && (f != front || b != back)) {
+		t.Errorf("Ends: got (%v, %v, %v) want (%v, %v, %v)", f, b, gotOK, front, back, ok)
+	}
+}
+
+// newWrapped returns a queue from ctor holding values, after enqueuing and
+// dequeuing a few more so that rings wrap around and slices leave room at the
+// front.
+func newWrapped(ctor func() Queue[int], values []int) Queue[int] {
+	q := ctor()
+	EnqueueMany(q, seq(-5, -1))
+	EnqueueMany(q, values)
+	for range 5 {
+		q.Dequeue()
+	}
+	return q
+}
+
+// AssertFIFO drives a queue from ctor through several cycles of growing to a
+// large size and draining back down, with dequeues interleaved to the enqueues,
+// and checks that elements come out in strict FIFO order across every resize.
+// val maps the position of an element in the sequence to its value, and it
+// must return distinct values for distinct positions.
+// Small baseLen and minShrink are set for the duration of the test, so that
+// backing stores are resized as often as possible.
+func AssertFIFO[T comparable](t *testing.T, ctor func() Queue[T], val func(i int) T) {
+	t.Helper()
+	bakMin, bakBase := minShrink, baseLen
+	t.Cleanup(func() { minShrink, baseLen = bakMin, bakBase })
+	minShrink, baseLen = 2, 2
+
+	const (
+		cycles = 3
+		peak   = 50_000
+	)
+	q := ctor()
+	c, hasCap := q.(capper)
+	resizes, lastCap := 0, 0
+	checkCap := func() {
+		if !hasCap {
+			return
+		}
+		if got := c.Cap(); got != lastCap {
+			resizes++
+			lastCap = got
+		}
+	}
+	next, want := 0, 0
+	enq := func() {
+		q.Enqueue(val(next))
+		next++
+		checkCap()
+	}
+	deq := func() {
+		if got := q.Dequeue(); got != val(want) {
+			t.Fatalf("Dequeue: got %v want %v (element %d)", got, val(want), want)
+		}
+		want++
+		checkCap()
+	}
+	for range cycles {
+		// Grow, dequeuing one element every three enqueues.
+		for q.Len() < peak {
+			enq()
+			enq()
+			enq()
+			deq()
+		}
+		// Shrink, enqueuing one element every three dequeues.
+		for q.Len() > 3 {
+			deq()
+			deq()
+			deq()
+			enq()
+		}
+	}
+	for q.Len() > 0 {
+		deq()
+	}
+	if want != next {
+		t.Errorf("dequeued %d elements, want %d", want, next)
+	}
+	// Each cycle should at least grow and shrink a few times.
+	if hasCap && resizes < 4*cycles {
+		t.Errorf("backing store was resized %d times, want at least %d", resizes, 4*cycles)
+	}
+}
+
+func TestFIFOAcrossResizes(t *testing.T) {
+	type ctor struct {
+		name string
+		ctor func() Queue[int]
+	}
+	var ctors []ctor
+	for _, i := range impls {
+		ctors = append(ctors, ctor(i))
+	}
+	ctors = append(ctors,
+		ctor{"adaptive", func() Queue[int] { return NewAdaptiveQueue[int]() }},
+		ctor{"blocking", func() Queue[int] { return NewBlockingQueue[int]() }},
+		ctor{"view", func() Queue[int] { return NewQueueView[int](&ringQueue[int]{}) }},
+	)
+	for _, c := range ctors {
+		t.Run(c.name, func(t *testing.T) {
+			AssertFIFO(t, c.ctor, func(i int) int { return i })
+		})
+	}
+	t.Run("string ring", func(t *testing.T) {
+		AssertFIFO(t, func() Queue[string] { return NewRingQueue[string]() }, strconv.Itoa)
+	})
+}
+
+func TestEnds(t *testing.T) {
+	type ctor struct {
+		name string
+		ctor func() Queue[int]
+	}
+	var ctors []ctor
+	for _, i := range impls {
+		ctors = append(ctors, ctor(i))
+	}
+	ctors = append(ctors,
+		ctor{"array 4", func() Queue[int] { return &ArrayQueue4[int]{} }},
+		ctor{"overwrite ring", func() Queue[int] { return NewOverwriteRing[int](100) }},
+		ctor{"bounded", func() Queue[int] { return NewBoundedQueue[int](100) }},
+		ctor{"priority", func() Queue[int] {
+			return NewStablePriorityQueue(func(a, b int) bool { return a < b })
+		}},
+		ctor{"replayable", func() Queue[int] { return NewReplayableQueue[int]() }},
+		ctor{"timestamped", func() Queue[int] { return NewTimestampedQueue[int]() }},
+		ctor{"blocking", func() Queue[int] { return NewBlockingQueue[int]() }},
+		ctor{"view", func() Queue[int] { return NewQueueView[int](&ringQueue[int]{}) }},
+		ctor{"file", func() Queue[int] {
+			q, err := NewFileQueue(filepath.Join(t.TempDir(), "queue"), GobCodec[int]{})
+			if err != nil {
+				t.Fatalf("NewFileQueue: %v", err)
+			}
+			t.Cleanup(func() { q.(io.Closer).Close() })
+			return q
+		}},
+		ctor{"spilling", func() Queue[int] {
+			q, err := NewSpillingQueue(2, filepath.Join(t.TempDir(), "spill"), GobCodec[int]{})
+			if err != nil {
+				t.Fatalf("NewSpillingQueue: %v", err)
+			}
+			t.Cleanup(func() { q.(io.Closer).Close() })
+			return q
+		}},
+	)
+	for _, c := range ctors {
+		t.Run(c.name, func(t *testing.T) {
+			q := c.ctor()
+			checkEnds(t, q, 0, 0, false)
+			q.Enqueue(0)
+			checkEnds(t, q, 0, 0, true)
+			for i := range 3 {
+				q.Enqueue(i + 1)
+			}
+			checkEnds(t, q, 0, 3, true)
+			q.Dequeue()
+			q.Dequeue()
+			q.Enqueue(4)
+			q.Enqueue(5)
+			checkEnds(t, q, 2, 5, true)
+			if got, want := q.Len(), 4; got != want {
+				t.Errorf("Len after Ends: got %v want %v", got, want)
+			}
+			for q.Len() > 1 {
+				q.Dequeue()
+			}
+			checkEnds(t, q, 5, 5, true)
+			q.Dequeue()
+			checkEnds(t, q, 0, 0, false)
+		})
+	}
+}
+
 const jitter = 10
 
+var benchSeed = flag.Int64("bench.seed", 1, "seed for the randomized benchmark workloads")
+
+// benchRand returns a source seeded with -bench.seed, so that randomized
+// workloads perform the same operations across runs and implementations.
+func benchRand() *rand.Rand {
+	return rand.New(rand.NewSource(*benchSeed))
+}
+
+type workload func(b *testing.B, qctor func() Queue[int], size int, rnd *rand.Rand)
+
 var benchs = []struct {
 	name string
-	r    func(b *testing.B, qctor func() Queue[int], size int)
+	r    workload
 }{
-	{"one by one empty", func(b *testing.B, qctor func() Queue[int], size int) {
+	{"one by one empty", func(b *testing.B, qctor func() Queue[int], size int, rnd *rand.Rand) {
 		for range b.N {
 			q := qctor()
 			for range size {
@@ -142,7 +351,7 @@ var benchs = []struct {
 			}
 		}
 	}},
-	{"1 by 1 not empty", func(b *testing.B, qctor func() Queue[int], size int) {
+	{"1 by 1 not empty", func(b *testing.B, qctor func() Queue[int], size int, rnd *rand.Rand) {
 		for range b.N {
 			q := qctor()
 			q.Enqueue(1)
@@ -155,7 +364,7 @@ var benchs = []struct {
 			}
 		}
 	}},
-	{"send first", func(b *testing.B, qctor func() Queue[int], size int) {
+	{"send first", func(b *testing.B, qctor func() Queue[int], size int, rnd *rand.Rand) {
 		for range b.N {
 			q := qctor()
 			q.Enqueue(1)
@@ -167,14 +376,14 @@ var benchs = []struct {
 			}
 		}
 	}},
-	{"with jitter", func(b *testing.B, qctor func() Queue[int], size int) {
+	{"with jitter", func(b *testing.B, qctor func() Queue[int], size int, rnd *rand.Rand) {
 		for range b.N {
 			q := qctor()
 			for range jitter {
-				for range rand.Intn(size / jitter) {
+				for range rnd.Intn(size / jitter) {
 					q.Enqueue(1)
 				}
-				for range rand.Intn(size / jitter) {
+				for range rnd.Intn(size / jitter) {
 					if q.Len() > 0 {
 						_ = q.Dequeue()
 					}
@@ -185,14 +394,14 @@ var benchs = []struct {
 			}
 		}
 	}},
-	{"more enq", func(b *testing.B, qctor func() Queue[int], size int) {
+	{"more enq", func(b *testing.B, qctor func() Queue[int], size int, rnd *rand.Rand) {
 		for range b.N {
 			q := qctor()
 			for range jitter {
-				for range rand.Intn(size/jitter) * 2 {
+				for range rnd.Intn(size/jitter) * 2 {
 					q.Enqueue(1)
 				}
-				for range rand.Intn(size / jitter) {
+				for range rnd.Intn(size / jitter) {
 					if q.Len() > 0 {
 						_ = q.Dequeue()
 					}
@@ -203,14 +412,14 @@ var benchs = []struct {
 			}
 		}
 	}},
-	{"more deq", func(b *testing.B, qctor func() Queue[int], size int) {
+	{"more deq", func(b *testing.B, qctor func() Queue[int], size int, rnd *rand.Rand) {
 		for range b.N {
 			q := qctor()
 			for range jitter {
-				for range rand.Intn(size / jitter) {
+				for range rnd.Intn(size / jitter) {
 					q.Enqueue(1)
 				}
-				for range rand.Intn(size/jitter) * 2 {
+				for range rnd.Intn(size/jitter) * 2 {
 					if q.Len() > 0 {
 						_ = q.Dequeue()
 					}
@@ -221,24 +430,24 @@ var benchs = []struct {
 			}
 		}
 	}},
-	{"grow and shrink", func(b *testing.B, qctor func() Queue[int], size int) {
+	{"grow and shrink", func(b *testing.B, qctor func() Queue[int], size int, rnd *rand.Rand) {
 		for range b.N {
 			q := qctor()
 			for range jitter {
-				for range rand.Intn(size/jitter) * 2 {
+				for range rnd.Intn(size/jitter) * 2 {
 					q.Enqueue(1)
 				}
-				for range rand.Intn(size / jitter) {
+				for range rnd.Intn(size / jitter) {
 					if q.Len() > 0 {
 						_ = q.Dequeue()
 					}
 				}
 			}
 			for range jitter {
-				for range rand.Intn(size / jitter) {
+				for range rnd.Intn(size / jitter) {
 					q.Enqueue(1)
 				}
-				for range rand.Intn(size/jitter) * 2 {
+				for range rnd.Intn(size/jitter) * 2 {
 					if q.Len() > 0 {
 						_ = q.Dequeue()
 					}
@@ -312,7 +521,7 @@ func BenchmarkQueue(b *testing.B) {
 				b.Run(strconv.Itoa(s), func(b *testing.B) {
 					for _, i := range impls {
 						b.Run(i.name, func(b *testing.B) {
-							t.r(b, i.ctor, s)
+							t.r(b, i.ctor, s, benchRand())
 						})
 					}
 				})
@@ -320,3 +529,1202 @@ func BenchmarkQueue(b *testing.B) {
 		})
 	}
 }
+
+/*
+BenchmarkBatchedList/one_by_one_empty/linked_list         	       5	  46428781 ns/op	16001108 B/op	 1000001 allocs/op
+BenchmarkBatchedList/one_by_one_empty/batched_linked_list 	       5	  34968406 ns/op	18001132 B/op	   15626 allocs/op
+BenchmarkBatchedList/1_by_1_not_empty/linked_list         	       5	  32516877 ns/op	16001124 B/op	 1000002 allocs/op
+BenchmarkBatchedList/1_by_1_not_empty/batched_linked_list 	       5	  30022541 ns/op	18002284 B/op	   15627 allocs/op
+BenchmarkBatchedList/send_first/linked_list               	       5	  74375157 ns/op	16001124 B/op	 1000002 allocs/op
+BenchmarkBatchedList/send_first/batched_linked_list       	       5	  34458653 ns/op	18002284 B/op	   15627 allocs/op
+BenchmarkBatchedList/with_jitter/linked_list              	       5	  26657313 ns/op	 7405777 B/op	  462793 allocs/op
+BenchmarkBatchedList/with_jitter/batched_linked_list      	       5	  19755264 ns/op	 8331705 B/op	    7232 allocs/op
+BenchmarkBatchedList/more_enq/linked_list                 	       5	  68110847 ns/op	14810446 B/op	  925585 allocs/op
+BenchmarkBatchedList/more_enq/batched_linked_list         	       5	  37147917 ns/op	16662048 B/op	   14464 allocs/op
+BenchmarkBatchedList/more_deq/linked_list                 	       5	  23490295 ns/op	 7405777 B/op	  462793 allocs/op
+BenchmarkBatchedList/more_deq/batched_linked_list         	       5	  19811480 ns/op	 8331705 B/op	    7232 allocs/op
+BenchmarkBatchedList/grow_and_shrink/linked_list          	       5	  94547416 ns/op	21908724 B/op	 1369227 allocs/op
+BenchmarkBatchedList/grow_and_shrink/batched_linked_list  	       5	  56334107 ns/op	24647712 B/op	   21396 allocs/op
+
+Batching cuts allocations by the batch length and it is faster in all
+workloads. Batches take 1152 bytes instead of 1024, as they get rounded up to
+the next allocation size class, hence the higher B/op.
+*/
+func BenchmarkBatchedList(b *testing.B) {
+	const size = 1_000_000
+	lists := []struct {
+		name string
+		ctor func() Queue[int]
+	}{
+		{"linked_list", func() Queue[int] { return &linkedListQueue[int]{} }},
+		{"batched_linked_list", NewBatchedListQueue[int]},
+	}
+	for _, bb := range benchs {
+		b.Run(bb.name, func(b *testing.B) {
+			for _, l := range lists {
+				b.Run(l.name, func(b *testing.B) {
+					b.ReportAllocs()
+					bb.r(b, l.ctor, size, benchRand())
+				})
+			}
+		})
+	}
+}
+
+func TestSlabQueue(t *testing.T) {
+	const slabSize = 4
+	sq := NewSlabQueue[int](slabSize).(*slabQueue[int])
+	var want []int
+	// Interleave enqueues and dequeues so that both ends cross slab
+	// boundaries at different times.
+	for i := range 50 {
+		sq.Enqueue(i)
+		want = append(want, i)
+		if i%3 == 0 {
+			if got := sq.Dequeue(); got != want[0] {
+				t.Fatalf("Dequeue: got %v want %v", got, want[0])
+			}
+			want = want[1:]
+		}
+	}
+	if got, wantSlabs := sq.slabs.Len(), (len(want)+sq.r+slabSize-1)/slabSize; got != wantSlabs {
+		t.Errorf("slabs: got %v want %v", got, wantSlabs)
+	}
+	checkEnds(t, sq, want[0], want[len(want)-1], true)
+	if diff := cmp.Diff(want, DrainToSlice[int](sq)); diff != "" {
+		t.Errorf("diff:\n%s", diff)
+	}
+	// Drained slabs are released, except for a partially written one that
+	// is reused.
+	if got := sq.slabs.Len(); got > 1 {
+		t.Errorf("slabs after draining: got %v want at most 1", got)
+	}
+	sq.Enqueue(1)
+	checkEnds(t, sq, 1, 1, true)
+}
+
+/*
+BenchmarkSlabQueue/ring_slice         	       6	 187965750 ns/op	402652072 B/op	      39 allocs/op
+BenchmarkSlabQueue/slab_queue         	      12	 101409657 ns/op	80313476 B/op	    2457 allocs/op
+
+Slabs take more allocations, one every 4096 elements, but they never copy
+elements while growing nor shrinking, so they allocate 5x less memory in total
+and they are almost twice as fast.
+*/
+func BenchmarkSlabQueue(b *testing.B) {
+	const size = 10_000_000
+	sendFirst := benchs[2]
+	ctors := []struct {
+		name string
+		ctor func() Queue[int]
+	}{
+		{"ring_slice", func() Queue[int] { return &ringQueue[int]{} }},
+		{"slab_queue", func() Queue[int] { return NewSlabQueue[int](4096) }},
+	}
+	for _, c := range ctors {
+		b.Run(c.name, func(b *testing.B) {
+			b.ReportAllocs()
+			sendFirst.r(b, c.ctor, size, benchRand())
+		})
+	}
+}
+
+// sharedPool is a pool of list nodes shared by all the queues created with
+// newSharedPooled, to measure reuse across queue instances.
+var sharedPool = &sync.Pool{New: func() any { return &elem[int]{} }}
+
+func newSharedPooled() Queue[int] {
+	q := newPooled[int]()
+	q.p = sharedPool
+	return q
+}
+
+/*
+BenchmarkPooledSharing/one_by_one_empty/per_instance         	      50	  21978526 ns/op	     464 B/op	       6 allocs/op
+BenchmarkPooledSharing/one_by_one_empty/shared               	      50	  22411192 ns/op	     303 B/op	       4 allocs/op
+BenchmarkPooledSharing/1_by_1_not_empty/per_instance         	      52	  23257907 ns/op	     651 B/op	       9 allocs/op
+BenchmarkPooledSharing/1_by_1_not_empty/shared               	      56	  20734354 ns/op	     294 B/op	       4 allocs/op
+BenchmarkPooledSharing/send_first/per_instance               	      14	  82788267 ns/op	32783181 B/op	 1000041 allocs/op
+BenchmarkPooledSharing/send_first/shared                     	      33	  37320401 ns/op	 1502176 B/op	   30308 allocs/op
+BenchmarkPooledSharing/with_jitter/per_instance              	      93	  29885940 ns/op	 9511968 B/op	  170858 allocs/op
+BenchmarkPooledSharing/with_jitter/shared                    	      84	  17764544 ns/op	  409740 B/op	    3740 allocs/op
+BenchmarkPooledSharing/more_enq/per_instance                 	      25	  71359289 ns/op	25279301 B/op	  561149 allocs/op
+BenchmarkPooledSharing/more_enq/shared                       	      27	  46860070 ns/op	 7145573 B/op	   37497 allocs/op
+BenchmarkPooledSharing/more_deq/per_instance                 	      84	  29024532 ns/op	 7038372 B/op	  125699 allocs/op
+BenchmarkPooledSharing/more_deq/shared                       	      93	  19169055 ns/op	  493149 B/op	    2606 allocs/op
+BenchmarkPooledSharing/grow_and_shrink/per_instance          	      21	  92570485 ns/op	26812904 B/op	  576891 allocs/op
+BenchmarkPooledSharing/grow_and_shrink/shared                	      21	  65985385 ns/op	 6964635 B/op	   48206 allocs/op
+
+When the queue never holds more than a node or two, as in the one by one
+workloads, a per-instance pool is enough and sharing makes no difference.
+Workloads that build up a backlog allocate a node per element with a
+per-instance pool, as the nodes recycled while draining are dropped along with
+the queue. A shared pool hands them to the next queue: allocs/op drop by 10x
+to 40x, and only the nodes that the GC cleared from the pool are allocated
+again.
+*/
+func BenchmarkPooledSharing(b *testing.B) {
+	const size = 1_000_000
+	pools := []struct {
+		name string
+		ctor func() Queue[int]
+	}{
+		{"per_instance", func() Queue[int] { return newPooled[int]() }},
+		{"shared", newSharedPooled},
+	}
+	for _, bb := range benchs {
+		b.Run(bb.name, func(b *testing.B) {
+			for _, p := range pools {
+				b.Run(p.name, func(b *testing.B) {
+					b.ReportAllocs()
+					bb.r(b, p.ctor, size, benchRand())
+				})
+			}
+		})
+	}
+}
+
+/*
+BenchmarkPeekPtr/slice/copy         	 9416490	       125.6 ns/op
+BenchmarkPeekPtr/slice/pointer      	407467735	         3.145 ns/op
+BenchmarkPeekPtr/ring/copy          	 7328014	       146.0 ns/op
+BenchmarkPeekPtr/ring/pointer       	387656392	         3.553 ns/op
+
+Copies are peeked with Ends, which copies both the front and the back.
+*/
+func BenchmarkPeekPtr(b *testing.B) {
+	ctors := []struct {
+		name string
+		ctor func() ptrPeeker[largeElem]
+	}{
+		{"slice", func() ptrPeeker[largeElem] { return &sliceQueue[largeElem]{} }},
+		{"ring", func() ptrPeeker[largeElem] { return &ringQueue[largeElem]{} }},
+	}
+	var sink int
+	for _, c := range ctors {
+		q := c.ctor()
+		q.Enqueue(largeElem{1})
+		b.Run(c.name+"/copy", func(b *testing.B) {
+			for range b.N {
+				front, _, _ := q.Ends()
+				sink += front[0]
+			}
+		})
+		b.Run(c.name+"/pointer", func(b *testing.B) {
+			for range b.N {
+				sink += q.PeekPtr()[0]
+			}
+		})
+	}
+	_ = sink
+}
+
+// peakTracker records the peak capacity of the queue it wraps.
+type peakTracker struct {
+	Queue[int]
+	peak *int
+}
+
+func (pt *peakTracker) Enqueue(v int) {
+	pt.Queue.Enqueue(v)
+	*pt.peak = max(*pt.peak, pt.Queue.(capper).Cap())
+}
+
+/*
+BenchmarkHybridGrowth/send_first/ring_2x         	       3	 271462042 ns/op	 134217728 peak-B	402653101 B/op	      42 allocs/op
+BenchmarkHybridGrowth/send_first/ring_hybrid     	       3	 281656988 ns/op	 102036672 peak-B	407696045 B/op	      47 allocs/op
+BenchmarkHybridGrowth/send_first/slice_append    	       3	 326761260 ns/op	  98557952 peak-B	510583981 B/op	      59 allocs/op
+BenchmarkHybridGrowth/send_first/slice_hybrid    	       3	 289207585 ns/op	 102036672 peak-B	327678765 B/op	      40 allocs/op
+
+peak-B is the largest backing store seen during the workload, while B/op
+sums all allocations, including the ones made while shrinking. append already
+grows large slices by less than 2x, so the hybrid growth mostly helps rings.
+*/
+func BenchmarkHybridGrowth(b *testing.B) {
+	const size = 10_000_000
+	sendFirst := benchs[2]
+	ctors := []struct {
+		name string
+		ctor func() Queue[int]
+	}{
+		{"ring_2x", func() Queue[int] { return NewRingQueue[int]() }},
+		{"ring_hybrid", func() Queue[int] { return NewRingQueue[int](WithHybridGrowth(1 << 16)) }},
+		{"slice_append", func() Queue[int] { return NewSliceQueue[int]() }},
+		{"slice_hybrid", func() Queue[int] { return NewSliceQueue[int](WithHybridGrowth(1 << 16)) }},
+	}
+	for _, c := range ctors {
+		b.Run(sendFirst.name+"/"+c.name, func(b *testing.B) {
+			b.ReportAllocs()
+			var peak int
+			sendFirst.r(b, func() Queue[int] {
+				return &peakTracker{Queue: c.ctor(), peak: &peak}
+			}, size, benchRand())
+			b.ReportMetric(float64(peak*strconv.IntSize/8), "peak-B")
+		})
+	}
+}
+
+// opRecorder is a queue that records the operations performed on it.
+type opRecorder struct {
+	ringQueue[int]
+	ops []string
+}
+
+func (r *opRecorder) Enqueue(v int) {
+	r.ops = append(r.ops, "enq")
+	r.ringQueue.Enqueue(v)
+}
+
+func (r *opRecorder) Dequeue() int {
+	r.ops = append(r.ops, "deq")
+	return r.ringQueue.Dequeue()
+}
+
+func TestBenchsDeterministic(t *testing.T) {
+	for _, bb := range benchs {
+		t.Run(bb.name, func(t *testing.T) {
+			run := func() []string {
+				rec := &opRecorder{}
+				bb.r(&testing.B{N: 1}, func() Queue[int] { return rec }, 1000, rand.New(rand.NewSource(42)))
+				return rec.ops
+			}
+			first, second := run(), run()
+			if len(first) == 0 {
+				t.Fatalf("no operations recorded")
+			}
+			if diff := cmp.Diff(first, second); diff != "" {
+				t.Errorf("runs with the same seed differ:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestHybridGrowth(t *testing.T) {
+	caps := func(q Queue[int]) []int {
+		var cs []int
+		for i := range 1000 {
+			q.Enqueue(i)
+			if c := q.(capper).Cap(); len(cs) == 0 || cs[len(cs)-1] != c {
+				cs = append(cs, c)
+			}
+		}
+		return cs
+	}
+	hybrid := []int{8, 16, 32, 64, 96, 144, 216, 324, 486, 729, 1093}
+	tests := []struct {
+		name string
+		q    Queue[int]
+		want []int
+	}{
+		{"ring default", NewRingQueue[int](), []int{8, 16, 32, 64, 128, 256, 512, 1024}},
+		{"ring hybrid", NewRingQueue[int](WithHybridGrowth(64)), hybrid},
+		{"slice hybrid", NewSliceQueue[int](WithHybridGrowth(64)), hybrid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, caps(tt.q)); diff != "" {
+				t.Errorf("capacities diff:\n%s", diff)
+			}
+			if diff := cmp.Diff(seq(0, 999), DrainToSlice(tt.q)); diff != "" {
+				t.Errorf("contents diff:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestErrEmptyQueue(t *testing.T) {
+	less := func(a, b int) bool { return a < b }
+	fromQueue := func(ctor func() Queue[int]) func() {
+		return func() {
+			q := ctor()
+			q.Enqueue(1)
+			q.Dequeue()
+			q.Dequeue()
+		}
+	}
+	tests := []struct {
+		name string
+		// dequeue dequeues from an empty queue.
+		dequeue func()
+	}{
+		{"map", fromQueue(func() Queue[int] { return newMapQueue[int]() })},
+		{"array", fromQueue(func() Queue[int] { return &ArrayQueue4[int]{} })},
+		{"overwrite ring", fromQueue(func() Queue[int] { return NewOverwriteRing[int](4) })},
+		{"replayable", fromQueue(func() Queue[int] { return NewReplayableQueue[int]() })},
+		{"rate limited", fromQueue(func() Queue[int] { return NewRateLimitedQueue(NewRingQueue[int](), 10, 1) })},
+		{"stable priority", fromQueue(func() Queue[int] { return NewStablePriorityQueue(less) })},
+		{"heap adapter", fromQueue(func() Queue[int] { return NewHeapAdapter(less) })},
+		{"monotonic", fromQueue(func() Queue[int] { return NewMonotonicQueue(less) })},
+		{"min max", fromQueue(func() Queue[int] { return NewMinMaxQueue[int]() })},
+		{"timestamped", fromQueue(func() Queue[int] { return NewTimestampedQueue[int]() })},
+		{"unique", fromQueue(func() Queue[int] { return NewUniqueQueue(NewRingQueue[int]()) })},
+		{"sync", fromQueue(func() Queue[int] { return NewSyncQueue(NewRingQueue[int]()) })},
+		{"handle priority", func() { NewHandlePriorityQueue(less).Dequeue() }},
+		{"handle", func() { NewHandleQueue[int]().Dequeue() }},
+	}
+	for _, i := range impls {
+		tests = append(tests, struct {
+			name    string
+			dequeue func()
+		}{i.name, fromQueue(i.ctor)})
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				err, _ := recover().(error)
+				if !errors.Is(err, ErrEmptyQueue) {
+					t.Errorf("Dequeue on empty queue: got panic %v want %v", err, ErrEmptyQueue)
+				}
+			}()
+			tt.dequeue()
+		})
+	}
+}
+
+func TestLenAfterEmptyDequeue(t *testing.T) {
+	for _, i := range impls {
+		t.Run(i.name, func(t *testing.T) {
+			q := i.ctor()
+			func() {
+				defer func() { recover() }()
+				q.Dequeue()
+			}()
+			if got := q.Len(); got != 0 {
+				t.Errorf("Len after a failed Dequeue: got %v want 0", got)
+			}
+			q.Enqueue(1)
+			if got := q.Len(); got != 1 {
+				t.Errorf("Len after Enqueue: got %v want 1", got)
+			}
+		})
+	}
+}
+
+func TestCounters(t *testing.T) {
+	type counter interface {
+		Queue[int]
+		Counters() (enqueued, dequeued uint64)
+	}
+	ctors := []struct {
+		name string
+		ctor func(opts ...Option) Queue[int]
+	}{
+		{"slice", NewSliceQueue[int]},
+		{"ring", NewRingQueue[int]},
+		{"pooled", NewPooledQueue[int]},
+	}
+	for _, c := range ctors {
+		t.Run(c.name, func(t *testing.T) {
+			q := c.ctor(WithCounters()).(counter)
+			EnqueueMany[int](q, seq(0, 9))
+			for range 4 {
+				q.Dequeue()
+			}
+			if enq, deq := q.Counters(); enq != 10 || deq != 4 {
+				t.Errorf("Counters: got (%v, %v) want (10, 4)", enq, deq)
+			}
+			// Rotating counts as well.
+			ToSlice[int](q)
+			if enq, deq := q.Counters(); enq != 16 || deq != 10 {
+				t.Errorf("Counters after ToSlice: got (%v, %v) want (16, 10)", enq, deq)
+			}
+
+			q = c.ctor().(counter)
+			EnqueueMany[int](q, seq(0, 9))
+			q.Dequeue()
+			if enq, deq := q.Counters(); enq != 0 || deq != 0 {
+				t.Errorf("Counters without WithCounters: got (%v, %v) want (0, 0)", enq, deq)
+			}
+		})
+	}
+	t.Run("ring InsertAt and RemoveAt", func(t *testing.T) {
+		q := NewRingQueue[int](WithCounters()).(*ringQueue[int])
+		EnqueueMany[int](q, seq(0, 9))
+		q.InsertAt(3, 42)
+		q.RemoveAt(7)
+		q.RemoveAt(0)
+		DequeueMatching[int](q, func(v int) bool { return v == 42 })
+		if enq, deq := q.Counters(); enq != 11 || deq != 3 {
+			t.Errorf("Counters: got (%v, %v) want (11, 3)", enq, deq)
+		}
+		if enq, deq := q.Counters(); int(enq-deq) != q.Len() {
+			t.Errorf("Counters: got (%v, %v) with Len %v", enq, deq, q.Len())
+		}
+	})
+	t.Run("pooled DequeueN", func(t *testing.T) {
+		q := newPooled[int](WithCounters())
+		EnqueueMany[int](q, seq(0, 9))
+		q.DequeueN(7)
+		if enq, deq := q.Counters(); enq != 10 || deq != 7 {
+			t.Errorf("Counters: got (%v, %v) want (10, 7)", enq, deq)
+		}
+	})
+}
+
+func TestZeroBaseLen(t *testing.T) {
+	bakBase := baseLen
+	defer func() { baseLen = bakBase }()
+	baseLen = 0
+
+	ctors := []struct {
+		name string
+		ctor func() Queue[int]
+	}{
+		{"slice", func() Queue[int] { return &sliceQueue[int]{} }},
+		{"ring", func() Queue[int] { return &ringQueue[int]{} }},
+		{"chan", func() Queue[int] { return newChanQueue[int]() }},
+	}
+	for _, c := range ctors {
+		t.Run(c.name, func(t *testing.T) {
+			q := c.ctor()
+			if c, ok := q.(capper); ok && c.Cap() != 0 {
+				t.Fatalf("Cap: got %v want 0", c.Cap())
+			}
+			for i := range 100 {
+				q.Enqueue(i)
+			}
+			if diff := cmp.Diff(seq(0, 99), DrainToSlice(q)); diff != "" {
+				t.Errorf("contents diff:\n%s", diff)
+			}
+		})
+	}
+	t.Run("bytes", func(t *testing.T) {
+		var bq ByteQueue
+		bq.Write([]byte("abc"))
+		b := make([]byte, 3)
+		if n, _ := bq.Read(b); string(b[:n]) != "abc" {
+			t.Errorf("Read: got %q want %q", b[:n], "abc")
+		}
+	})
+}
+
+func TestChanQueueGrowth(t *testing.T) {
+	q := newChanQueue[int]()
+	var want []int
+	next := 0
+	// Interleave dequeues so that growths happen with a non empty channel
+	// whose buffer has been partially consumed.
+	for range 6 {
+		old := *q
+		for range cap(old) + 1 {
+			q.Enqueue(next)
+			want = append(want, next)
+			next++
+		}
+		if cap(*q) <= cap(old) {
+			t.Fatalf("queue did not grow past %v", cap(old))
+		}
+		select {
+		case _, ok := <-old:
+			if !ok {
+				t.Fatalf("old channel was closed")
+			}
+			t.Fatalf("old channel still holds elements")
+		default:
+		}
+		for range cap(old) / 2 {
+			if got := q.Dequeue(); got != want[0] {
+				t.Fatalf("Dequeue: got %v want %v", got, want[0])
+			}
+			want = want[1:]
+		}
+	}
+	var got []int
+	for q.Len() > 0 {
+		got = append(got, q.Dequeue())
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("diff:\n%s", diff)
+	}
+}
+
+func TestCloneCOW(t *testing.T) {
+	type cloner interface {
+		CloneCOW() Queue[int]
+	}
+	tests := []struct {
+		name string
+		ctor func() Queue[int]
+		// backing returns the address of the first slot of the backing array.
+		backing func(q Queue[int]) *int
+	}{
+		{"simple slice", func() Queue[int] { return &sliceQueue[int]{} },
+			func(q Queue[int]) *int { return &q.(*sliceQueue[int]).s[0] }},
+		{"ring slice", func() Queue[int] { return &ringQueue[int]{} },
+			func(q Queue[int]) *int { return &q.(*ringQueue[int]).buf[0] }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orig := tt.ctor()
+			EnqueueMany(orig, seq(0, 9))
+			orig.Dequeue()
+			clone := orig.(cloner).CloneCOW()
+			// Ends doesn't write, while ToSlice would rotate the queues.
+			checkEnds(t, orig, 1, 9, true)
+			checkEnds(t, clone, 1, 9, true)
+			if tt.backing(orig) != tt.backing(clone) {
+				t.Errorf("backing array was copied before any write")
+			}
+
+			clone.Enqueue(100)
+			if tt.backing(orig) == tt.backing(clone) {
+				t.Errorf("backing array is still shared after a write")
+			}
+			orig.Enqueue(200)
+			orig.Dequeue()
+			clone.Enqueue(101)
+			if diff := cmp.Diff(append(seq(2, 9), 200), ToSlice(orig)); diff != "" {
+				t.Errorf("original diff:\n%s", diff)
+			}
+			if diff := cmp.Diff(append(seq(1, 9), 100, 101), ToSlice(clone)); diff != "" {
+				t.Errorf("clone diff:\n%s", diff)
+			}
+		})
+	}
+}
+
+// largeElem is as large as lookup's largeData.
+type largeElem [100]int
+
+type ptrPeeker[T any] interface {
+	Queue[T]
+	PeekPtr() *T
+}
+
+func TestPeekPtr(t *testing.T) {
+	ctors := []struct {
+		name string
+		ctor func() ptrPeeker[largeElem]
+	}{
+		{"slice", func() ptrPeeker[largeElem] { return &sliceQueue[largeElem]{} }},
+		{"ring", func() ptrPeeker[largeElem] { return &ringQueue[largeElem]{} }},
+	}
+	for _, c := range ctors {
+		t.Run(c.name, func(t *testing.T) {
+			q := c.ctor()
+			if p := q.PeekPtr(); p != nil {
+				t.Errorf("PeekPtr on empty queue: got %v want nil", p)
+			}
+			for i := range 10 {
+				q.Enqueue(largeElem{i, i})
+			}
+			q.Dequeue()
+			p := q.PeekPtr()
+			if p == nil || p[0] != 1 || p[99] != 0 {
+				t.Fatalf("PeekPtr: got %v want element 1", p)
+			}
+			// Writes through the pointer modify the queued element.
+			p[99] = 42
+			if got := q.Dequeue(); got != (largeElem{1, 1, 99: 42}) {
+				t.Errorf("Dequeue after modifying in place: got %v", got[:3])
+			}
+			if p := q.PeekPtr(); p[0] != 2 {
+				t.Errorf("PeekPtr after Dequeue: got element %v want 2", p[0])
+			}
+
+			// Writes through the pointer don't leak into copy-on-write clones.
+			clone := q.(interface{ CloneCOW() Queue[largeElem] }).CloneCOW()
+			q.PeekPtr()[99] = 7
+			if front, _, _ := clone.Ends(); front[99] != 0 {
+				t.Errorf("write through PeekPtr is visible in the clone")
+			}
+			if front, _, _ := q.Ends(); front[99] != 7 {
+				t.Errorf("write through PeekPtr after CloneCOW was lost")
+			}
+		})
+	}
+}
+
+func TestPooledZeroOnRecycle(t *testing.T) {
+	// Large enough to not be batched by the tiny allocator, whose objects
+	// might never be finalized.
+	type payload [4]int64
+	collected := func(opts ...Option) bool {
+		q := NewPooledQueue[*payload](opts...)
+		done := make(chan struct{})
+		p := &payload{}
+		runtime.SetFinalizer(p, func(*payload) { close(done) })
+		q.Enqueue(p)
+		q.Enqueue(&payload{})
+		p = nil
+		q.Dequeue()
+		// A single cycle moves pooled nodes to the victim cache, which keeps
+		// them reachable.
+		runtime.GC()
+		defer runtime.KeepAlive(q)
+		select {
+		case <-done:
+			return true
+		case <-time.After(100 * time.Millisecond):
+			return false
+		}
+	}
+	if !collected() {
+		t.Errorf("dequeued value was kept alive by the pool")
+	}
+	// Whether the pool keeps nodes alive depends on the GC and, under the race
+	// detector, on sync.Pool randomly dropping them, so the opt-out is checked
+	// on the recycled node instead.
+	for _, tt := range []struct {
+		opts      []Option
+		wantClear bool
+	}{
+		{nil, true},
+		{[]Option{WithZeroOnRecycle(false)}, false},
+	} {
+		q := NewPooledQueue[*payload](tt.opts...).(*linkedListPooledQueue[*payload])
+		e := &elem[*payload]{v: &payload{}}
+		q.recycle(e)
+		if cleared := e.v == nil; cleared != tt.wantClear {
+			t.Errorf("recycle with %d options: value cleared is %v want %v", len(tt.opts), cleared, tt.wantClear)
+		}
+	}
+}
+
+func TestPooledDequeueN(t *testing.T) {
+	tests := []struct {
+		name    string
+		n       int
+		want    []int
+		wantLen int
+	}{
+		{"none", 0, nil, 5},
+		{"some", 3, []int{0, 1, 2}, 2},
+		{"all", 5, []int{0, 1, 2, 3, 4}, 0},
+		{"more than len", 10, []int{0, 1, 2, 3, 4}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newPooled[int]()
+			for i := range 5 {
+				q.Enqueue(i)
+			}
+			got := q.DequeueN(tt.n)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("got %v want %v diff:\n%s", got, tt.want, diff)
+			}
+			if q.Len() != tt.wantLen {
+				t.Errorf("Len: got %v want %v", q.Len(), tt.wantLen)
+			}
+			// The queue must still be usable.
+			q.Enqueue(42)
+			var rest []int
+			for q.Len() > 0 {
+				rest = append(rest, q.Dequeue())
+			}
+			if last := rest[len(rest)-1]; last != 42 || len(rest) != tt.wantLen+1 {
+				t.Errorf("after DequeueN got %v", rest)
+			}
+		})
+	}
+}
+
+func BenchmarkPooledDequeueN(b *testing.B) {
+	const size = 1000
+	b.Run("loop", func(b *testing.B) {
+		q := newPooled[int]()
+		b.ReportAllocs()
+		for range b.N {
+			for i := range size {
+				q.Enqueue(i)
+			}
+			vs := make([]int, 0, size)
+			for q.Len() > 0 {
+				vs = append(vs, q.Dequeue())
+			}
+		}
+	})
+	b.Run("bulk", func(b *testing.B) {
+		q := newPooled[int]()
+		b.ReportAllocs()
+		for range b.N {
+			for i := range size {
+				q.Enqueue(i)
+			}
+			q.DequeueN(size)
+		}
+	})
+}
+
+func TestSliceCompact(t *testing.T) {
+	q := &sliceQueue[int]{}
+	for i := range 1000 {
+		q.Enqueue(i)
+	}
+	// Leave 600 elements behind without triggering checkShrink.
+	for range 400 {
+		q.Dequeue()
+	}
+	before := q.Cap()
+	reclaimed := q.Compact()
+	if got, want := q.Cap(), 600; got != want || got >= before {
+		t.Errorf("Cap after Compact: got %v want %v (was %v)", got, want, before)
+	}
+	if got, want := reclaimed, before-q.Cap(); got != want {
+		t.Errorf("Compact reclaimed: got %v want %v", got, want)
+	}
+	if got := q.Compact(); got != 0 {
+		t.Errorf("Compact on a compact queue reclaimed %v slots, want 0", got)
+	}
+	if diff := cmp.Diff(seq(400, 999), ToSlice[int](q)); diff != "" {
+		t.Errorf("Compact altered contents, diff:\n%s", diff)
+	}
+
+	// Draining completely reclaims everything on the next Compact.
+	for q.Len() > 0 {
+		q.Dequeue()
+	}
+	before = q.Cap()
+	if got := q.Compact(); got != before || q.Cap() != 0 {
+		t.Errorf("Compact after draining: reclaimed %v with Cap %v, want %v with Cap 0", got, q.Cap(), before)
+	}
+}
+
+func TestSliceAutoCompact(t *testing.T) {
+	q := NewSliceQueue[int](WithAutoCompact(0.5)).(*sliceQueue[int])
+	for i := range 1000 {
+		q.Enqueue(i)
+	}
+	before := q.Cap()
+	for range 700 {
+		q.Dequeue()
+		if q.dropped > q.Cap()/2 {
+			t.Fatalf("left behind %v slots out of %v", q.dropped, q.Cap())
+		}
+	}
+	if q.Cap() >= before {
+		t.Errorf("Cap: got %v want less than %v", q.Cap(), before)
+	}
+	if diff := cmp.Diff(seq(700, 999), ToSlice[int](q)); diff != "" {
+		t.Errorf("auto compaction altered contents, diff:\n%s", diff)
+	}
+}
+
+func TestPeekN(t *testing.T) {
+	type peeker interface {
+		PeekN(n int) []int
+	}
+	for _, impl := range impls {
+		t.Run(impl.name, func(t *testing.T) {
+			q := impl.ctor()
+			p, ok := q.(peeker)
+			if !ok {
+				t.Skip("no PeekN")
+			}
+			if got := p.PeekN(3); got != nil {
+				t.Errorf("PeekN on empty queue: got %v want nil", got)
+			}
+			// Wrap rings around their buffer.
+			for i := range 6 {
+				q.Enqueue(i)
+			}
+			for range 4 {
+				q.Dequeue()
+			}
+			for i := 6; i < 12; i++ {
+				q.Enqueue(i)
+			}
+			want := seq(4, 11)
+			tests := []struct {
+				n    int
+				want []int
+			}{
+				{n: 0, want: nil},
+				{n: 5, want: want[:5]},
+				{n: len(want), want: want},
+				{n: len(want) + 10, want: want},
+			}
+			for _, tt := range tests {
+				if diff := cmp.Diff(tt.want, p.PeekN(tt.n)); diff != "" {
+					t.Errorf("PeekN(%v) diff:\n%s", tt.n, diff)
+				}
+				checkEnds(t, q, want[0], want[len(want)-1], true)
+			}
+			if diff := cmp.Diff(want, DrainToSlice(q)); diff != "" {
+				t.Errorf("PeekN altered the queue, diff:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestResize(t *testing.T) {
+	type resizer interface {
+		Queue[int]
+		capper
+		Resize(newCap int) error
+	}
+	ctors := []struct {
+		name string
+		ctor func() resizer
+	}{
+		{"slice", func() resizer { return &sliceQueue[int]{} }},
+		{"ring", func() resizer { return &ringQueue[int]{} }},
+		{"chan", func() resizer { return newChanQueue[int]() }},
+	}
+	for _, c := range ctors {
+		t.Run(c.name, func(t *testing.T) {
+			q := c.ctor()
+			// Wrap rings around their buffer so that Resize has to linearize.
+			for i := range 6 {
+				q.Enqueue(i)
+			}
+			for range 4 {
+				q.Dequeue()
+			}
+			for i := 6; i < 12; i++ {
+				q.Enqueue(i)
+			}
+			want := seq(4, 11)
+			steps := []struct {
+				newCap, wantCap int
+				wantErr         bool
+			}{
+				{newCap: 100, wantCap: 100},
+				{newCap: len(want), wantCap: len(want)},
+				{newCap: len(want) - 1, wantCap: len(want), wantErr: true},
+			}
+			for _, s := range steps {
+				err := q.Resize(s.newCap)
+				if gotErr := err != nil; gotErr != s.wantErr {
+					t.Errorf("Resize(%v): got err %v, want error: %v", s.newCap, err, s.wantErr)
+				}
+				if got := q.Cap(); got != s.wantCap {
+					t.Errorf("Resize(%v): got Cap %v want %v", s.newCap, got, s.wantCap)
+				}
+				front, back, _ := q.Ends()
+				if front != want[0] || back != want[len(want)-1] || q.Len() != len(want) {
+					t.Errorf("Resize(%v) altered the queue: got ends (%v, %v) and Len %v", s.newCap, front, back, q.Len())
+				}
+			}
+			if diff := cmp.Diff(want, DrainToSlice[int](q)); diff != "" {
+				t.Errorf("contents after Resize, diff:\n%s", diff)
+			}
+			// Empty queues can be resized to zero and still grow afterwards.
+			if err := q.Resize(0); err != nil {
+				t.Fatalf("Resize(0) on empty queue: %v", err)
+			}
+			q.Enqueue(42)
+			if got := q.Dequeue(); got != 42 {
+				t.Errorf("Dequeue after Resize(0): got %v want 42", got)
+			}
+		})
+	}
+}
+
+func TestShuffle(t *testing.T) {
+	type shuffler interface {
+		Queue[int]
+		Shuffle(r *rand.Rand)
+	}
+	// The expected permutation is the one the same seed gives on a plain
+	// slice.
+	want := seq(5, 24)
+	rand.New(rand.NewSource(42)).Shuffle(len(want), func(i, j int) {
+		want[i], want[j] = want[j], want[i]
+	})
+	if slices.Equal(want, seq(5, 24)) {
+		t.Fatalf("the seed doesn't shuffle")
+	}
+	ctors := []struct {
+		name string
+		ctor func() shuffler
+	}{
+		{"slice", func() shuffler { return &sliceQueue[int]{} }},
+		{"ring", func() shuffler { return &ringQueue[int]{} }},
+	}
+	for _, c := range ctors {
+		t.Run(c.name, func(t *testing.T) {
+			q := c.ctor()
+			// Wrap the ring around to exercise the logical index mapping.
+			EnqueueMany[int](q, seq(0, 19))
+			for range 5 {
+				q.Dequeue()
+			}
+			EnqueueMany[int](q, seq(20, 24))
+			q.Shuffle(rand.New(rand.NewSource(42)))
+			if got := q.Len(); got != 20 {
+				t.Errorf("Len after Shuffle: got %v want 20", got)
+			}
+			if diff := cmp.Diff(want, DrainToSlice[int](q)); diff != "" {
+				t.Errorf("Shuffle diff:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRingInsertRemoveAt(t *testing.T) {
+	// newRing returns a ring holding 0..9 that wraps around its buffer.
+	newRing := func() *ringQueue[int] {
+		var q ringQueue[int]
+		for i := -10; i < 4; i++ {
+			q.Enqueue(i)
+		}
+		for range 10 {
+			q.Dequeue()
+		}
+		for i := 4; i < 10; i++ {
+			q.Enqueue(i)
+		}
+		if q.first+q.l <= len(q.buf) {
+			t.Fatalf("ring does not wrap: first=%v len=%v buf=%v", q.first, q.l, len(q.buf))
+		}
+		return &q
+	}
+	for _, i := range []int{0, 1, 3, 5, 8, 10} {
+		t.Run("insert at "+strconv.Itoa(i), func(t *testing.T) {
+			q := newRing()
+			q.InsertAt(i, 42)
+			want := slices.Insert(seq(0, 9), i, 42)
+			if diff := cmp.Diff(want, ToSlice[int](q)); diff != "" {
+				t.Errorf("InsertAt(%v) diff:\n%s", i, diff)
+			}
+		})
+	}
+	for _, i := range []int{0, 1, 3, 5, 8, 9} {
+		t.Run("remove at "+strconv.Itoa(i), func(t *testing.T) {
+			q := newRing()
+			got, ok := q.RemoveAt(i)
+			if !ok || got != i {
+				t.Errorf("RemoveAt(%v): got (%v, %v) want (%v, true)", i, got, ok, i)
+			}
+			want := slices.Delete(seq(0, 9), i, i+1)
+			if diff := cmp.Diff(want, ToSlice[int](q)); diff != "" {
+				t.Errorf("RemoveAt(%v) diff:\n%s", i, diff)
+			}
+		})
+	}
+	for _, n := range []int{10, 9} {
+		t.Run("steal half of "+strconv.Itoa(n), func(t *testing.T) {
+			q := newRing()
+			if n == 9 {
+				q.RemoveAt(9)
+			}
+			keep := n - n/2
+			if diff := cmp.Diff(seq(keep, n-1), q.StealHalf()); diff != "" {
+				t.Errorf("stolen diff:\n%s", diff)
+			}
+			if diff := cmp.Diff(seq(0, keep-1), ToSlice[int](q)); diff != "" {
+				t.Errorf("left diff:\n%s", diff)
+			}
+			if err := q.Validate(); err != nil {
+				t.Errorf("Validate after StealHalf: %v", err)
+			}
+			// The queue keeps working after the steal.
+			q.Enqueue(42)
+			if diff := cmp.Diff(append(seq(0, keep-1), 42), ToSlice[int](q)); diff != "" {
+				t.Errorf("Enqueue after StealHalf diff:\n%s", diff)
+			}
+		})
+	}
+	t.Run("steal from small queues", func(t *testing.T) {
+		var q ringQueue[int]
+		if got := q.StealHalf(); got != nil {
+			t.Errorf("StealHalf on empty queue: got %v want nil", got)
+		}
+		q.Enqueue(1)
+		if got := q.StealHalf(); got != nil {
+			t.Errorf("StealHalf on single element: got %v want nil", got)
+		}
+		if q.Len() != 1 {
+			t.Errorf("Len: got %v want 1", q.Len())
+		}
+	})
+	t.Run("remove out of range", func(t *testing.T) {
+		q := newRing()
+		for _, i := range []int{-1, 10} {
+			if _, ok := q.RemoveAt(i); ok {
+				t.Errorf("RemoveAt(%v): got ok", i)
+			}
+		}
+	})
+	t.Run("insert into empty", func(t *testing.T) {
+		var q ringQueue[int]
+		q.InsertAt(0, 1)
+		q.InsertAt(0, 0)
+		q.InsertAt(2, 2)
+		if diff := cmp.Diff([]int{0, 1, 2}, ToSlice[int](&q)); diff != "" {
+			t.Errorf("diff:\n%s", diff)
+		}
+	})
+}
+
+func TestShrinkHysteresis(t *testing.T) {
+	bakMin := minShrink
+	defer func() { minShrink = bakMin }()
+	minShrink = 2
+
+	for _, window := range []int{0, 1, 10} {
+		t.Run("window "+strconv.Itoa(window), func(t *testing.T) {
+			q := NewRingQueue[int](WithShrinkHysteresis(window)).(*ringQueue[int])
+			Reserve[int](q, 128, WithExactCapacity())
+			EnqueueMany[int](q, seq(0, 99))
+			if got, want := q.Cap(), 128; got != want {
+				t.Fatalf("Cap: got %v want %v", got, want)
+			}
+			// Length 31 is the first one below the threshold of 128/4.
+			for q.Len() > 32 {
+				q.Dequeue()
+			}
+			for n := range max(window, 1) - 1 {
+				q.Dequeue()
+				if got, want := q.Cap(), 128; got != want {
+					t.Fatalf("Cap after %v dequeues below threshold: got %v want %v", n+1, got, want)
+				}
+			}
+			q.Dequeue()
+			if got, want := q.Cap(), 62; got != want {
+				t.Errorf("Cap after the hysteresis window: got %v want %v", got, want)
+			}
+			if diff := cmp.Diff(seq(68+max(window, 1), 99), ToSlice[int](q)); diff != "" {
+				t.Errorf("contents diff:\n%s", diff)
+			}
+		})
+	}
+}
+
+/*
+BenchmarkShrinkHysteresis/window_0         	       3	 291297045 ns/op	251656245 B/op	      41 allocs/op
+BenchmarkShrinkHysteresis/window_1000      	       3	 258060171 ns/op	245522485 B/op	      67 allocs/op
+*/
+func BenchmarkShrinkHysteresis(b *testing.B) {
+	var wl workload
+	for _, bb := range benchs {
+		if bb.name == "grow and shrink" {
+			wl = bb.r
+		}
+	}
+	const size = 10_000_000
+	for _, window := range []int{0, 1000} {
+		b.Run("window "+strconv.Itoa(window), func(b *testing.B) {
+			b.ReportAllocs()
+			wl(b, func() Queue[int] {
+				return NewRingQueue[int](WithShrinkHysteresis(window))
+			}, size, benchRand())
+		})
+	}
+}
+
+func TestMinCapacity(t *testing.T) {
+	const floor = 500
+	ctors := []struct {
+		name string
+		ctor func(opts ...Option) Queue[int]
+	}{
+		{"simple slice", NewSliceQueue[int]},
+		{"ring slice", NewRingQueue[int]},
+	}
+	for _, c := range ctors {
+		t.Run(c.name, func(t *testing.T) {
+			drain := func(q Queue[int]) (minCap int) {
+				// Spare capacity lets slices shrink while dequeueing.
+				Reserve(q, 8000)
+				EnqueueMany(q, seq(0, 1999))
+				minCap = q.(capper).Cap()
+				for q.Len() > 10 {
+					q.Dequeue()
+					minCap = min(minCap, q.(capper).Cap())
+				}
+				return minCap
+			}
+			if got := drain(c.ctor()); got >= floor {
+				t.Fatalf("without a floor Cap went down to %v, want less than %v", got, floor)
+			}
+			q := c.ctor(WithMinCapacity(floor))
+			if got := drain(q); got != floor {
+				t.Errorf("min Cap: got %v want %v", got, floor)
+			}
+			if diff := cmp.Diff(seq(1990, 1999), ToSlice(q)); diff != "" {
+				t.Errorf("diff:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMapQueueCap(t *testing.T) {
+	q := NewMapQueueCap[int](100)
+	EnqueueMany(q, seq(0, 199))
+	var got []int
+	for q.Len() > 0 {
+		got = append(got, q.Dequeue())
+	}
+	if diff := cmp.Diff(seq(0, 199), got); diff != "" {
+		t.Errorf("diff:\n%s", diff)
+	}
+}
+
+func TestMapQueueReset(t *testing.T) {
+	q := newMapQueue[int]()
+	EnqueueMany(q, seq(0, 99))
+	for range 10 {
+		q.Dequeue()
+	}
+	q.Reset()
+	if got := q.Len(); got != 0 {
+		t.Errorf("Len after Reset: got %v want 0", got)
+	}
+	if _, _, ok := q.Ends(); ok {
+		t.Errorf("Ends after Reset: got ok want !ok")
+	}
+	EnqueueMany(q, seq(0, 9))
+	if err := q.Validate(); err != nil {
+		t.Errorf("Validate after Reset: %v", err)
+	}
+	if diff := cmp.Diff(seq(0, 9), DrainToSlice[int](q)); diff != "" {
+		t.Errorf("diff after Reset:\n%s", diff)
+	}
+}
+
+/*
+BenchmarkMapQueueCap/unhinted         	       1	5402423427 ns/op	605295568 B/op	   65564 allocs/op
+BenchmarkMapQueueCap/hinted           	       1	3270581949 ns/op	302645320 B/op	   32771 allocs/op
+*/
+func BenchmarkMapQueueCap(b *testing.B) {
+	var wl workload
+	for _, bb := range benchs {
+		if bb.name == "send first" {
+			wl = bb.r
+		}
+	}
+	const size = 10_000_000
+	b.Run("unhinted", func(b *testing.B) {
+		b.ReportAllocs()
+		wl(b, func() Queue[int] { return newMapQueue[int]() }, size, benchRand())
+	})
+	b.Run("hinted", func(b *testing.B) {
+		b.ReportAllocs()
+		wl(b, func() Queue[int] { return NewMapQueueCap[int](size + 1) }, size, benchRand())
+	})
+}
+
+/*
+BenchmarkMapQueueReset/new         	   11295	    115561 ns/op	   74264 B/op	      20 allocs/op
+BenchmarkMapQueueReset/reset       	   28197	     41094 ns/op	       2 B/op	       0 allocs/op
+*/
+func BenchmarkMapQueueReset(b *testing.B) {
+	const size = 1000
+	b.Run("new", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			q := newMapQueue[int]()
+			for i := range size {
+				q.Enqueue(i)
+			}
+			for q.Len() > 0 {
+				q.Dequeue()
+			}
+		}
+	})
+	b.Run("reset", func(b *testing.B) {
+		b.ReportAllocs()
+		q := newMapQueue[int]()
+		for range b.N {
+			q.Reset()
+			for i := range size {
+				q.Enqueue(i)
+			}
+			for q.Len() > 0 {
+				q.Dequeue()
+			}
+		}
+	})
+}
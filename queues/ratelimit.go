@@ -0,0 +1,95 @@
+package queues
+
+import (
+	"context"
+	"time"
+)
+
+var (
+	_ Queue[int]     = &RateLimitedQueue[int]{}
+	_ unwrapper[int] = &RateLimitedQueue[int]{}
+)
+
+// RateLimitedQueue wraps a queue to pace dequeues to a given rate.
+// It implements a token bucket: up to burst elements can be dequeued at once,
+// after which one element is allowed every 1/perSecond seconds.
+// Helpers like ForEach and ToSlice rotate the wrapped queue directly, so they
+// neither wait nor spend tokens.
+type RateLimitedQueue[T any] struct {
+	inner    Queue[T]
+	clock    Clock
+	interval time.Duration
+	burst    int
+	// tat is the theoretical arrival time of the next token.
+	tat time.Time
+}
+
+// NewRateLimitedQueue wraps inner so that at most perSecond elements per second
+// can be dequeued, with bursts of up to burst elements.
+// It panics if perSecond is not positive.
+// Use WithClock to control the passing of time.
+func NewRateLimitedQueue[T any](inner Queue[T], perSecond float64, burst int, opts ...Option) *RateLimitedQueue[T] {
+	// Also rejects NaN.
+	if !(perSecond > 0) {
+		panic("rate limited queue rate must be positive")
+	}
+	return &RateLimitedQueue[T]{
+		inner:    inner,
+		clock:    newOptions(opts).clock,
+		interval: time.Duration(float64(time.Second) / perSecond),
+		burst:    max(burst, 1),
+	}
+}
+
+func (rq *RateLimitedQueue[T]) Len() int {
+	return rq.inner.Len()
+}
+
+func (rq *RateLimitedQueue[T]) Enqueue(v T) {
+	rq.inner.Enqueue(v)
+}
+
+// reserve takes a token and returns how long to wait before it can be used.
+func (rq *RateLimitedQueue[T]) reserve() time.Duration {
+	now := rq.clock.Now()
+	if rq.tat.Before(now) {
+		rq.tat = now
+	}
+	wait := rq.tat.Sub(now) - time.Duration(rq.burst-1)*rq.interval
+	rq.tat = rq.tat.Add(rq.interval)
+	return max(wait, 0)
+}
+
+// Ends is not rate limited, as it doesn't consume elements.
+func (rq *RateLimitedQueue[T]) Ends() (front, back T, ok bool) {
+	return rq.inner.Ends()
+}
+
+func (rq *RateLimitedQueue[T]) unwrap() Queue[T] {
+	return rq.inner
+}
+
+// Dequeue blocks until the rate allows it, then returns the first element.
+// Like for all other queues, callers must check Len before calling it.
+func (rq *RateLimitedQueue[T]) Dequeue() T {
+	v, _ := rq.DequeueCtx(context.Background())
+	return v
+}
+
+// DequeueCtx is like Dequeue but it stops waiting when ctx is done, in which
+// case it returns the context error and leaves the queue untouched.
+func (rq *RateLimitedQueue[T]) DequeueCtx(ctx context.Context) (t T, err error) {
+	if rq.inner.Len() == 0 {
+		panic(ErrEmptyQueue)
+	}
+	if wait := rq.reserve(); wait > 0 {
+		select {
+		case <-rq.clock.After(wait):
+		case <-ctx.Done():
+			// Give the token back.
+			rq.tat = rq.tat.Add(-rq.interval)
+			return t, ctx.Err()
+		}
+	}
+	return rq.inner.Dequeue(), nil
+}
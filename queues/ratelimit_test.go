@@ -0,0 +1,107 @@
+package queues
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// fakeClock is a Clock that advances only when waited on, or manually.
+type fakeClock struct {
+	now time.Time
+}
+
+func (fc *fakeClock) Now() time.Time { return fc.now }
+
+func (fc *fakeClock) After(d time.Duration) <-chan time.Time {
+	fc.now = fc.now.Add(d)
+	c := make(chan time.Time, 1)
+	c <- fc.now
+	return c
+}
+
+func (fc *fakeClock) Advance(d time.Duration) { fc.now = fc.now.Add(d) }
+
+func TestRateLimitedQueue(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	q := NewRateLimitedQueue[int](&ringQueue[int]{}, 10, 2, WithClock(clock))
+	for i := range 5 {
+		q.Enqueue(i)
+	}
+	var got []time.Duration
+	for q.Len() > 0 {
+		q.Dequeue()
+		got = append(got, clock.Now().Sub(time.Unix(0, 0)))
+	}
+	ms := time.Millisecond
+	// Two elements in a burst, then one every 100ms.
+	want := []time.Duration{0, 0, 100 * ms, 200 * ms, 300 * ms}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("dequeue times diff:\n%s", diff)
+	}
+
+	// Tokens accumulate up to burst while idle.
+	clock.Advance(time.Second)
+	start := clock.Now()
+	for i := range 3 {
+		q.Enqueue(i)
+	}
+	for q.Len() > 0 {
+		q.Dequeue()
+	}
+	if got, want := clock.Now().Sub(start), 100*ms; got != want {
+		t.Errorf("after idle: waited %v want %v", got, want)
+	}
+}
+
+func TestRateLimitedQueueRotation(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	q := NewRateLimitedQueue[int](&ringQueue[int]{}, 1, 1, WithClock(clock))
+	EnqueueMany[int](q, seq(1, 5))
+	if diff := cmp.Diff(seq(1, 5), ToSlice[int](q)); diff != "" {
+		t.Errorf("ToSlice diff:\n%s", diff)
+	}
+	if got := clock.Now(); !got.Equal(time.Unix(0, 0)) {
+		t.Errorf("ToSlice waited until %v", got)
+	}
+	// The token is still available.
+	if got := q.Dequeue(); got != 1 {
+		t.Errorf("Dequeue: got %v want 1", got)
+	}
+	if got := clock.Now(); !got.Equal(time.Unix(0, 0)) {
+		t.Errorf("first Dequeue after ToSlice waited until %v", got)
+	}
+}
+
+func TestRateLimitedQueueCtx(t *testing.T) {
+	q := NewRateLimitedQueue[int](&ringQueue[int]{}, 0.001, 1)
+	q.Enqueue(1)
+	q.Enqueue(2)
+	if _, err := q.DequeueCtx(context.Background()); err != nil {
+		t.Fatalf("first DequeueCtx: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := q.DequeueCtx(ctx); err != context.DeadlineExceeded {
+		t.Errorf("DequeueCtx: got %v want %v", err, context.DeadlineExceeded)
+	}
+	if got, want := q.Len(), 1; got != want {
+		t.Errorf("Len: got %v want %v", got, want)
+	}
+}
+
+func TestRateLimitedQueueInvalidRate(t *testing.T) {
+	for _, perSecond := range []float64{0, -1, math.Inf(-1), math.NaN()} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("NewRateLimitedQueue with rate %v: got no panic", perSecond)
+				}
+			}()
+			NewRateLimitedQueue[int](&ringQueue[int]{}, perSecond, 1)
+		}()
+	}
+}
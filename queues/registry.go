@@ -0,0 +1,37 @@
+package queues
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+var (
+	ctorsMu sync.RWMutex
+	// ctors maps element types to queue constructors.
+	ctors = map[reflect.Type]func() any{}
+)
+
+// RegisterQueueCtor registers ctor as the constructor of queues for elements of
+// type t. ctor should return a Queue whose element type is t, for example
+// a Queue[int] for reflect.TypeFor[int]().
+// Registering a type twice replaces the previous constructor.
+func RegisterQueueCtor(t reflect.Type, ctor func() any) {
+	ctorsMu.Lock()
+	defer ctorsMu.Unlock()
+	ctors[t] = ctor
+}
+
+// NewQueueByType returns a new queue from the constructor registered for
+// elements of type t, or an error if there is none.
+// The caller is responsible for asserting the result to the Queue type it
+// expects, as the registry can't check that at compile time.
+func NewQueueByType(t reflect.Type) (any, error) {
+	ctorsMu.RLock()
+	ctor, ok := ctors[t]
+	ctorsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("no queue constructor registered for %v", t)
+	}
+	return ctor(), nil
+}
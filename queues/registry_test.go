@@ -0,0 +1,51 @@
+package queues
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestQueueRegistry(t *testing.T) {
+	intT, strT := reflect.TypeFor[int](), reflect.TypeFor[string]()
+	RegisterQueueCtor(intT, func() any { return NewRingQueue[int]() })
+	RegisterQueueCtor(strT, func() any { return NewSliceQueue[string]() })
+	t.Cleanup(func() {
+		ctorsMu.Lock()
+		defer ctorsMu.Unlock()
+		delete(ctors, intT)
+		delete(ctors, strT)
+	})
+
+	qi, err := NewQueueByType(intT)
+	if err != nil {
+		t.Fatalf("NewQueueByType(int): %v", err)
+	}
+	iq, ok := qi.(Queue[int])
+	if !ok {
+		t.Fatalf("NewQueueByType(int): got %T, want a Queue[int]", qi)
+	}
+	iq.Enqueue(42)
+	if got := iq.Dequeue(); got != 42 {
+		t.Errorf("Dequeue: got %v want 42", got)
+	}
+
+	qs, err := NewQueueByType(strT)
+	if err != nil {
+		t.Fatalf("NewQueueByType(string): %v", err)
+	}
+	if _, ok := qs.(Queue[string]); !ok {
+		t.Errorf("NewQueueByType(string): got %T, want a Queue[string]", qs)
+	}
+	if _, ok := qs.(Queue[int]); ok {
+		t.Errorf("NewQueueByType(string) returned a Queue[int]")
+	}
+
+	// Every call returns a new queue.
+	if qi2, _ := NewQueueByType(intT); qi2.(Queue[int]).Len() != 0 || qi2 == qi {
+		t.Errorf("NewQueueByType returned a queue that is not new")
+	}
+
+	if q, err := NewQueueByType(reflect.TypeFor[float64]()); err == nil {
+		t.Errorf("NewQueueByType(float64): got %T, want error", q)
+	}
+}
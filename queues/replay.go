@@ -0,0 +1,72 @@
+package queues
+
+var (
+	_ Queue[int]   = &ReplayableQueue[int]{}
+	_ indexer[int] = &ReplayableQueue[int]{}
+)
+
+// ReplayableQueue is a map backed queue that retains dequeued elements until
+// Compact is called, so that they can be consumed again after a Rewind.
+// Memory usage is only bounded by explicit calls to Compact.
+// ForEach and the helpers built on it, like ToSlice, read the elements in
+// place. Other helpers that rotate the queue, like TrimBack and
+// DequeueMatching, append the rotated elements to the retained ones again.
+type ReplayableQueue[T any] struct {
+	// oldest retained element, read cursor and next write position.
+	start, first, last uint64
+	mem                map[uint64]T
+}
+
+// NewReplayableQueue returns an empty ReplayableQueue.
+func NewReplayableQueue[T any]() *ReplayableQueue[T] {
+	return &ReplayableQueue[T]{mem: make(map[uint64]T)}
+}
+
+// Len returns the amount of elements that have not been dequeued yet.
+// This is the logical length of the queue, computed from the cursors, and not
+// the amount of elements in memory, which also counts the retained ones.
+func (rq *ReplayableQueue[T]) Len() int {
+	return int(rq.last - rq.first)
+}
+
+func (rq *ReplayableQueue[T]) Dequeue() T {
+	if rq.first == rq.last {
+		panic(ErrEmptyQueue)
+	}
+	v := rq.mem[rq.first]
+	rq.first++
+	return v
+}
+
+func (rq *ReplayableQueue[T]) Ends() (front, back T, ok bool) {
+	if rq.first == rq.last {
+		return front, back, false
+	}
+	return rq.mem[rq.first], rq.mem[rq.last-1], true
+}
+
+func (rq *ReplayableQueue[T]) Enqueue(v T) {
+	rq.mem[rq.last] = v
+	rq.last++
+	if rq.last == rq.start {
+		panic("this is impossible on modern machines")
+	}
+}
+
+func (rq *ReplayableQueue[T]) nth(i int) T {
+	return rq.mem[rq.first+uint64(i)]
+}
+
+// Rewind moves the read cursor back to the oldest retained element.
+func (rq *ReplayableQueue[T]) Rewind() {
+	rq.first = rq.start
+}
+
+// Compact discards all the elements that have been dequeued.
+// They can no longer be replayed after this call.
+func (rq *ReplayableQueue[T]) Compact() {
+	for i := rq.start; i < rq.first; i++ {
+		delete(rq.mem, i)
+	}
+	rq.start = rq.first
+}
@@ -0,0 +1,71 @@
+package queues
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReplayableQueue(t *testing.T) {
+	drain := func(q Queue[int], n int) []int {
+		var got []int
+		for range n {
+			got = append(got, q.Dequeue())
+		}
+		return got
+	}
+	q := NewReplayableQueue[int]()
+	for i := range 5 {
+		q.Enqueue(i)
+	}
+	if diff := cmp.Diff([]int{0, 1, 2}, drain(q, 3)); diff != "" {
+		t.Errorf("first read diff:\n%s", diff)
+	}
+	if got, want := q.Len(), 2; got != want {
+		t.Errorf("Len: got %v want %v", got, want)
+	}
+	// Dequeued elements are retained until Compact.
+	if got, want := len(q.mem), 5; got != want {
+		t.Errorf("retained before Compact: got %v want %v", got, want)
+	}
+
+	q.Rewind()
+	if got, want := q.Len(), 5; got != want {
+		t.Errorf("Len after Rewind: got %v want %v", got, want)
+	}
+	if diff := cmp.Diff([]int{0, 1}, drain(q, 2)); diff != "" {
+		t.Errorf("replay diff:\n%s", diff)
+	}
+
+	q.Compact()
+	if got, want := len(q.mem), 3; got != want {
+		t.Errorf("retained after Compact: got %v want %v", got, want)
+	}
+	q.Rewind()
+	q.Enqueue(5)
+	if diff := cmp.Diff([]int{2, 3, 4, 5}, drain(q, q.Len())); diff != "" {
+		t.Errorf("read after Compact diff:\n%s", diff)
+	}
+}
+
+func TestReplayableQueueForEach(t *testing.T) {
+	q := NewReplayableQueue[int]()
+	EnqueueMany[int](q, seq(1, 3))
+	q.Dequeue()
+	for range 2 {
+		if diff := cmp.Diff(seq(2, 3), ToSlice[int](q)); diff != "" {
+			t.Errorf("ToSlice diff:\n%s", diff)
+		}
+	}
+	if !QueueContains[int](q, 3) {
+		t.Errorf("QueueContains(3): got false want true")
+	}
+	// Iterating must not add to the retained elements.
+	if got, want := len(q.mem), 3; got != want {
+		t.Errorf("retained after ToSlice: got %v want %v", got, want)
+	}
+	q.Rewind()
+	if diff := cmp.Diff(seq(1, 3), DrainToSlice[int](q)); diff != "" {
+		t.Errorf("replay diff:\n%s", diff)
+	}
+}
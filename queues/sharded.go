@@ -0,0 +1,107 @@
+package queues
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+var _ ConcurrentQueue[int] = &ShardedQueue[int]{}
+
+type shard[T any] struct {
+	mu sync.Mutex
+	q  Queue[T]
+}
+
+// ShardedQueue spreads elements across several independently locked queues,
+// to reduce contention between concurrent producers and consumers.
+// Enqueues and dequeues go round-robin across the shards, so elements only
+// keep their FIFO order within a shard: globally, an element can be dequeued
+// before one that was enqueued earlier, but never more than a round of
+// shards early under sequential use.
+type ShardedQueue[T any] struct {
+	shards   []shard[T]
+	enq, deq atomic.Uint64
+	n        atomic.Int64
+}
+
+// NewShardedQueue returns an empty ShardedQueue with the given amount of
+// shards, each created by ctor.
+func NewShardedQueue[T any](shards int, ctor func() Queue[T]) *ShardedQueue[T] {
+	sq := &ShardedQueue[T]{shards: make([]shard[T], max(shards, 1))}
+	for i := range sq.shards {
+		sq.shards[i].q = ctor()
+	}
+	return sq
+}
+
+// Len returns the sum of the lengths of the shards without locking them.
+// Like for SyncQueue, it should only be used for sampling under concurrency.
+func (sq *ShardedQueue[T]) Len() int {
+	return int(sq.n.Load())
+}
+
+// ConcurrencySafe always returns true.
+func (sq *ShardedQueue[T]) ConcurrencySafe() bool {
+	return true
+}
+
+func (sq *ShardedQueue[T]) Enqueue(v T) {
+	s := &sq.shards[(sq.enq.Add(1)-1)%uint64(len(sq.shards))]
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.q.Enqueue(v)
+	sq.n.Add(1)
+}
+
+// Dequeue removes an element from the next shard in round-robin order, or
+// from the first non-empty shard after it.
+func (sq *ShardedQueue[T]) Dequeue() T {
+	// Claim an element first, so that concurrent consumers can't all find
+	// the shards empty while scanning them one at a time.
+	if sq.n.Add(-1) < 0 {
+		sq.n.Add(1)
+		panic(ErrEmptyQueue)
+	}
+	n := uint64(len(sq.shards))
+	for start := sq.deq.Add(1) - 1; ; start++ {
+		s := &sq.shards[start%n]
+		s.mu.Lock()
+		if s.q.Len() > 0 {
+			v := s.q.Dequeue()
+			s.mu.Unlock()
+			return v
+		}
+		s.mu.Unlock()
+	}
+}
+
+// Ends returns the front of the shard the next Dequeue would pick and the
+// back of the shard that received the last Enqueue. Under concurrent use they
+// are only a snapshot of the two shards.
+func (sq *ShardedQueue[T]) Ends() (front, back T, ok bool) {
+	start := sq.deq.Load()
+	for i := range uint64(len(sq.shards)) {
+		s := &sq.shards[(start+i)%uint64(len(sq.shards))]
+		s.mu.Lock()
+		front, _, ok = s.q.Ends()
+		s.mu.Unlock()
+		if ok {
+			break
+		}
+	}
+	if !ok {
+		return front, back, false
+	}
+	n := uint64(len(sq.shards))
+	last := (sq.enq.Load() - 1) % n
+	for i := range n {
+		s := &sq.shards[(last+n-i)%n]
+		s.mu.Lock()
+		_, back, ok = s.q.Ends()
+		s.mu.Unlock()
+		if ok {
+			break
+		}
+	}
+	return front, back, ok
+}
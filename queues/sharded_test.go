@@ -0,0 +1,119 @@
+package queues
+
+import (
+	"runtime"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestShardedQueue(t *testing.T) {
+	q := NewShardedQueue(3, func() Queue[int] { return &ringQueue[int]{} })
+	if _, _, ok := q.Ends(); ok {
+		t.Errorf("Ends on empty queue: got ok want !ok")
+	}
+	EnqueueMany[int](q, seq(0, 9))
+	if got, want := q.Len(), 10; got != want {
+		t.Errorf("Len: got %v want %v", got, want)
+	}
+	if front, back, ok := q.Ends(); front != 0 || back != 9 || !ok {
+		t.Errorf("Ends: got (%v, %v, %v) want (0, 9, true)", front, back, ok)
+	}
+	// Under sequential use round-robin on both sides preserves FIFO order.
+	if diff := cmp.Diff(seq(0, 9), DrainToSlice[int](q)); diff != "" {
+		t.Errorf("Dequeue diff:\n%s", diff)
+	}
+
+	// Dequeues skip empty shards.
+	q.Enqueue(1)
+	q.Enqueue(2)
+	if diff := cmp.Diff([]int{1, 2}, DrainToSlice[int](q)); diff != "" {
+		t.Errorf("Dequeue with empty shards diff:\n%s", diff)
+	}
+}
+
+func TestShardedQueueConcurrent(t *testing.T) {
+	const producers, perProducer = 4, 1000
+	q := NewShardedQueue(4, func() Queue[int] { return &ringQueue[int]{} })
+	var wg sync.WaitGroup
+	for p := range producers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range perProducer {
+				q.Enqueue(p*perProducer + i)
+			}
+		}()
+	}
+	wg.Wait()
+
+	var mu sync.Mutex
+	var got []int
+	for range producers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range perProducer {
+				v := q.Dequeue()
+				mu.Lock()
+				got = append(got, v)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	slices.Sort(got)
+	if diff := cmp.Diff(seq(0, producers*perProducer-1), got); diff != "" {
+		t.Errorf("dequeued elements diff:\n%s", diff)
+	}
+	if got := q.Len(); got != 0 {
+		t.Errorf("Len after draining: got %v want 0", got)
+	}
+}
+
+/*
+These were taken on a single CPU machine, where goroutines never run in
+parallel and there is no contention to reduce: sharding only adds the cost of
+the shared round-robin counters. Under -race, which serializes more, the
+sharded queue is already ahead. It should be measured again with -cpu on a
+machine with several cores.
+
+BenchmarkShardedQueue/sync           	21266844	        54.02 ns/op	       0 B/op	       0 allocs/op
+BenchmarkShardedQueue/sync-4         	16808740	        71.67 ns/op	       0 B/op	       0 allocs/op
+BenchmarkShardedQueue/sync-8         	14796309	        83.79 ns/op	       0 B/op	       0 allocs/op
+BenchmarkShardedQueue/sharded        	12577653	        90.57 ns/op	       0 B/op	       0 allocs/op
+BenchmarkShardedQueue/sharded-4      	13005742	       100.8 ns/op	       0 B/op	       0 allocs/op
+BenchmarkShardedQueue/sharded-8      	12146515	        99.57 ns/op	       0 B/op	       0 allocs/op
+
+With -race -benchtime 200000x:
+
+BenchmarkShardedQueue/sync-8         	  200000	      2183 ns/op	       0 B/op	       0 allocs/op
+BenchmarkShardedQueue/sharded-8      	  200000	      1643 ns/op	       0 B/op	       0 allocs/op
+*/
+func BenchmarkShardedQueue(b *testing.B) {
+	ctors := []struct {
+		name string
+		ctor func() Queue[int]
+	}{
+		{"sync", func() Queue[int] { return NewSyncQueue[int](&ringQueue[int]{}) }},
+		{"sharded", func() Queue[int] {
+			return NewShardedQueue(runtime.GOMAXPROCS(0), func() Queue[int] { return &ringQueue[int]{} })
+		}},
+	}
+	for _, c := range ctors {
+		b.Run(c.name, func(b *testing.B) {
+			q := c.ctor()
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				// Every goroutine dequeues only what it enqueued, so the
+				// queue never runs empty.
+				for pb.Next() {
+					q.Enqueue(1)
+					q.Dequeue()
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package queues
+
+// Snapshot is an immutable copy of the contents of a queue.
+// It is cheap to hold and can be restored any number of times.
+type Snapshot[T any] struct {
+	s []T
+}
+
+// TakeSnapshot captures the contents of q, leaving q unchanged.
+func TakeSnapshot[T any](q Queue[T]) Snapshot[T] {
+	return Snapshot[T]{s: ToSlice(q)}
+}
+
+// Len returns the amount of elements captured.
+func (s Snapshot[T]) Len() int {
+	return len(s.s)
+}
+
+// Restore returns a new queue with the contents captured by s.
+func Restore[T any](s Snapshot[T]) Queue[T] {
+	return FromSlice(s.s)
+}
@@ -0,0 +1,36 @@
+package queues
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSnapshot(t *testing.T) {
+	for _, i := range impls {
+		t.Run(i.name, func(t *testing.T) {
+			q := i.ctor()
+			EnqueueMany(q, []int{1, 2, 3})
+			s := TakeSnapshot(q)
+
+			q.Dequeue()
+			q.Enqueue(4)
+
+			for range 2 {
+				r := Restore(s)
+				if diff := cmp.Diff([]int{1, 2, 3}, ToSlice(r)); diff != "" {
+					t.Errorf("restored diff:\n%s", diff)
+				}
+				// Mutating a restored queue doesn't affect the snapshot.
+				r.Dequeue()
+				r.Enqueue(5)
+			}
+			if got, want := s.Len(), 3; got != want {
+				t.Errorf("Len: got %v want %v", got, want)
+			}
+			if diff := cmp.Diff([]int{2, 3, 4}, ToSlice(q)); diff != "" {
+				t.Errorf("original diff:\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package queues
+
+import (
+	"cmp"
+	"slices"
+)
+
+// SortedSlice returns the elements of q sorted in ascending order, leaving q unchanged.
+func SortedSlice[T cmp.Ordered](q Queue[T]) []T {
+	s := ToSlice(q)
+	slices.Sort(s)
+	return s
+}
+
+// IsSorted reports whether the FIFO order of q is ascending.
+func IsSorted[T cmp.Ordered](q Queue[T]) bool {
+	return slices.IsSorted(ToSlice(q))
+}
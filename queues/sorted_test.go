@@ -0,0 +1,33 @@
+package queues
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSortedSlice(t *testing.T) {
+	input := []int{5, 3, 9, 1, 3, 7}
+	for _, i := range impls {
+		t.Run(i.name, func(t *testing.T) {
+			q := i.ctor()
+			EnqueueMany(q, input)
+			if IsSorted(q) {
+				t.Errorf("IsSorted(%v): got true want false", input)
+			}
+			want := []int{1, 3, 3, 5, 7, 9}
+			if diff := cmp.Diff(want, SortedSlice(q)); diff != "" {
+				t.Errorf("SortedSlice diff:\n%s", diff)
+			}
+			if diff := cmp.Diff(input, ToSlice(q)); diff != "" {
+				t.Errorf("queue was modified, diff:\n%s", diff)
+			}
+
+			sorted := i.ctor()
+			EnqueueMany(sorted, want)
+			if !IsSorted(sorted) {
+				t.Errorf("IsSorted(%v): got false want true", want)
+			}
+		})
+	}
+}
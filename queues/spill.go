@@ -0,0 +1,86 @@
+package queues
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+)
+
+var _ Queue[int] = &spillingQueue[int]{}
+
+type spillingQueue[T any] struct {
+	memLimit int
+	path     string
+	mem      ringQueue[T]
+	disk     Queue[T]
+}
+
+// NewSpillingQueue returns a queue that keeps at most memLimit elements in
+// memory and spills the rest to a file queue at spillPath.
+// Once elements are spilled, all subsequent enqueues go to disk until it is
+// drained, which preserves FIFO order. When the in-memory elements run out, up
+// to memLimit elements are reloaded from disk.
+//
+// Any pre-existing data at spillPath is discarded.
+// The returned Queue implements io.Closer, which removes the spill files.
+func NewSpillingQueue[T any](memLimit int, spillPath string, codec Codec[T]) (Queue[T], error) {
+	if memLimit <= 0 {
+		return nil, errors.New("memLimit must be positive")
+	}
+	if err := removeFileQueue(spillPath); err != nil {
+		return nil, err
+	}
+	disk, err := NewFileQueue(spillPath, codec)
+	if err != nil {
+		return nil, err
+	}
+	return &spillingQueue[T]{memLimit: memLimit, path: spillPath, disk: disk}, nil
+}
+
+func removeFileQueue(path string) error {
+	for _, p := range []string{path, path + ".off"} {
+		if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (sq *spillingQueue[T]) Len() int {
+	return sq.mem.Len() + sq.disk.Len()
+}
+
+func (sq *spillingQueue[T]) Dequeue() T {
+	if sq.mem.Len() == 0 {
+		for sq.disk.Len() > 0 && sq.mem.Len() < sq.memLimit {
+			sq.mem.Enqueue(sq.disk.Dequeue())
+		}
+	}
+	return sq.mem.Dequeue()
+}
+
+func (sq *spillingQueue[T]) Ends() (front, back T, ok bool) {
+	front, back, ok = sq.mem.Ends()
+	if sq.disk.Len() == 0 {
+		return front, back, ok
+	}
+	diskFront, diskBack, _ := sq.disk.Ends()
+	if !ok {
+		front = diskFront
+	}
+	return front, diskBack, true
+}
+
+func (sq *spillingQueue[T]) Enqueue(v T) {
+	if sq.disk.Len() > 0 || sq.mem.Len() >= sq.memLimit {
+		sq.disk.Enqueue(v)
+		return
+	}
+	sq.mem.Enqueue(v)
+}
+
+// Close closes and removes the spill files.
+func (sq *spillingQueue[T]) Close() error {
+	return errors.Join(sq.disk.(io.Closer).Close(), removeFileQueue(sq.path))
+}
@@ -0,0 +1,50 @@
+package queues
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSpillingQueue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spill")
+	q, err := NewSpillingQueue(4, path, GobCodec[int]{})
+	if err != nil {
+		t.Fatalf("NewSpillingQueue: %v", err)
+	}
+	sq := q.(*spillingQueue[int])
+
+	for i := range 10 {
+		q.Enqueue(i)
+	}
+	if got, want := sq.mem.Len(), 4; got != want {
+		t.Errorf("in memory: got %v want %v", got, want)
+	}
+	if got, want := sq.disk.Len(), 6; got != want {
+		t.Errorf("on disk: got %v want %v", got, want)
+	}
+
+	var got []int
+	for range 5 {
+		got = append(got, q.Dequeue())
+	}
+	// Enqueues must go after the spilled elements.
+	q.Enqueue(10)
+	q.Enqueue(11)
+	for q.Len() > 0 {
+		got = append(got, q.Dequeue())
+	}
+	if diff := cmp.Diff(seq(0, 11), got); diff != "" {
+		t.Errorf("got %v diff:\n%s", got, diff)
+	}
+
+	if err := q.(io.Closer).Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("spill file was not removed: %v", err)
+	}
+}
@@ -0,0 +1,133 @@
+package queues
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	_ ConcurrentQueue[int] = &SyncQueue[int]{}
+	_ ConcurrentQueue[int] = &BlockingQueue[int]{}
+	_ ConcurrentQueue[int] = &TracedQueue[int]{}
+)
+
+// ConcurrentQueue is implemented by queues that can report whether they are
+// safe for concurrent use.
+type ConcurrentQueue[T any] interface {
+	Queue[T]
+	ConcurrencySafe() bool
+}
+
+// IsConcurrencySafe reports whether q is safe for concurrent use.
+// Queues that don't implement ConcurrentQueue are assumed not to be.
+func IsConcurrencySafe[T any](q Queue[T]) bool {
+	cq, ok := q.(ConcurrentQueue[T])
+	return ok && cq.ConcurrencySafe()
+}
+
+// SyncQueue makes a queue safe for concurrent use by guarding it with a mutex.
+// Len doesn't take the mutex, so observers can sample the length without
+// contending with producers and consumers.
+type SyncQueue[T any] struct {
+	mu    sync.Mutex
+	inner Queue[T]
+	// n mirrors inner.Len() and it is updated after every mutation.
+	n atomic.Int64
+}
+
+// NewSyncQueue returns a SyncQueue that wraps inner, which must not be used
+// directly afterwards.
+func NewSyncQueue[T any](inner Queue[T]) *SyncQueue[T] {
+	sq := &SyncQueue[T]{inner: inner}
+	sq.n.Store(int64(inner.Len()))
+	return sq
+}
+
+// Len returns the length of the queue without locking it.
+// The result might lag behind an Enqueue or Dequeue that is completing
+// concurrently, so it should only be used for sampling.
+func (sq *SyncQueue[T]) Len() int {
+	return int(sq.n.Load())
+}
+
+// ConcurrencySafe always returns true.
+func (sq *SyncQueue[T]) ConcurrencySafe() bool {
+	return true
+}
+
+func (sq *SyncQueue[T]) Ends() (front, back T, ok bool) {
+	sq.mu.Lock()
+	defer sq.mu.Unlock()
+	return sq.inner.Ends()
+}
+
+func (sq *SyncQueue[T]) Dequeue() T {
+	sq.mu.Lock()
+	defer sq.mu.Unlock()
+	v := sq.inner.Dequeue()
+	sq.n.Store(int64(sq.inner.Len()))
+	return v
+}
+
+func (sq *SyncQueue[T]) Enqueue(v T) {
+	sq.mu.Lock()
+	defer sq.mu.Unlock()
+	sq.inner.Enqueue(v)
+	sq.n.Store(int64(sq.inner.Len()))
+}
+
+// RemoveWhere removes the elements for which pred returns true, preserving the
+// order of the others, and returns how many were removed.
+// The whole sweep happens under the lock, like Filter but in place, so
+// concurrent operations observe the queue either before or after it.
+func (sq *SyncQueue[T]) RemoveWhere(pred func(T) bool) int {
+	sq.mu.Lock()
+	defer sq.mu.Unlock()
+	q := storage(sq.inner)
+	removed := 0
+	for range q.Len() {
+		v := q.Dequeue()
+		if pred(v) {
+			removed++
+			continue
+		}
+		q.Enqueue(v)
+	}
+	sq.n.Store(int64(sq.inner.Len()))
+	return removed
+}
+
+// Snapshots starts sending a copy of the contents of the queue every interval,
+// until ctx is done, after which the returned channel is closed.
+// Copies are taken under the lock, which is released before sending them, so
+// readers never hold up producers and consumers. If the reader hasn't
+// received the previous copy yet it is replaced, so slow readers get the most
+// recent copy instead of a backlog.
+// Use WithClock to control the passing of time.
+func (sq *SyncQueue[T]) Snapshots(ctx context.Context, interval time.Duration, opts ...Option) <-chan []T {
+	clock := newOptions(opts).clock
+	c := make(chan []T, 1)
+	go func() {
+		defer close(c)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-clock.After(interval):
+			}
+			sq.mu.Lock()
+			s := ToSlice(sq.inner)
+			sq.mu.Unlock()
+			// Replace the copy the reader didn't receive yet, if any. This
+			// goroutine is the only sender, so the send can't block.
+			select {
+			case <-c:
+			default:
+			}
+			c <- s
+		}
+	}()
+	return c
+}
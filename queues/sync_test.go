@@ -0,0 +1,235 @@
+package queues
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestSyncQueueLenSampling(t *testing.T) {
+	const producers, perProducer = 4, 2000
+	q := NewSyncQueue[int](&ringQueue[int]{})
+	done := make(chan struct{})
+	var sampler sync.WaitGroup
+	sampler.Add(1)
+	go func() {
+		defer sampler.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			if l := q.Len(); l < 0 || l > producers*perProducer {
+				t.Errorf("sampled Len %v out of range", l)
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for range producers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range perProducer {
+				q.Enqueue(i)
+			}
+		}()
+	}
+	// With a single consumer a positive Len guarantees that Dequeue won't
+	// find the queue empty.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for n := 0; n < producers*perProducer/2; {
+			if q.Len() > 0 {
+				q.Dequeue()
+				n++
+			}
+		}
+	}()
+	wg.Wait()
+	close(done)
+	sampler.Wait()
+
+	if got, want := q.Len(), producers*perProducer/2; got != want {
+		t.Errorf("Len after all operations: got %v want %v", got, want)
+	}
+	if got, want := len(ToSlice[int](q)), producers*perProducer/2; got != want {
+		t.Errorf("stored elements: got %v want %v", got, want)
+	}
+}
+
+func TestConcurrencySafe(t *testing.T) {
+	type ctor struct {
+		name string
+		ctor func() Queue[int]
+	}
+	var ctors []ctor
+	for _, i := range impls {
+		ctors = append(ctors, ctor(i))
+	}
+	tracer := noop.NewTracerProvider().Tracer("")
+	ctors = append(ctors,
+		ctor{"sync", func() Queue[int] { return NewSyncQueue[int](&ringQueue[int]{}) }},
+		ctor{"blocking", func() Queue[int] { return NewBlockingQueue[int]() }},
+		ctor{"traced ring", func() Queue[int] { return NewTracedQueue[int](&ringQueue[int]{}, tracer) }},
+		ctor{"traced sync", func() Queue[int] {
+			return NewTracedQueue[int](NewSyncQueue[int](&ringQueue[int]{}), tracer)
+		}},
+		ctor{"rate limited sync", func() Queue[int] {
+			return NewRateLimitedQueue[int](NewSyncQueue[int](&ringQueue[int]{}), 1, 1)
+		}},
+	)
+	want := map[string]bool{
+		"sync":        true,
+		"blocking":    true,
+		"traced sync": true,
+	}
+	for _, c := range ctors {
+		t.Run(c.name, func(t *testing.T) {
+			if got := IsConcurrencySafe(c.ctor()); got != want[c.name] {
+				t.Errorf("IsConcurrencySafe: got %v want %v", got, want[c.name])
+			}
+		})
+	}
+}
+
+func TestSyncQueueSnapshots(t *testing.T) {
+	q := NewSyncQueue[int](&ringQueue[int]{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	snaps := q.Snapshots(ctx, 100*time.Microsecond)
+
+	// A single producer enqueues increasing values and a single consumer
+	// dequeues them, so every consistent snapshot is a run of consecutive
+	// values.
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; ctx.Err() == nil; {
+			// Keep the queue short, so that snapshots are cheap.
+			if q.Len() < 1000 {
+				q.Enqueue(i)
+				i++
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for ctx.Err() == nil {
+			if q.Len() > 0 {
+				q.Dequeue()
+			}
+		}
+	}()
+
+	for range 20 {
+		s := <-snaps
+		for i := 1; i < len(s); i++ {
+			if s[i] != s[i-1]+1 {
+				t.Fatalf("inconsistent snapshot: %v follows %v at %v", s[i], s[i-1], i)
+			}
+		}
+	}
+	cancel()
+	wg.Wait()
+	// The channel is closed once ctx is done.
+	for range snaps {
+	}
+}
+
+// tickClock is a Clock whose After channels fire when the test sends on tick.
+type tickClock struct {
+	tick chan time.Time
+}
+
+func (tc tickClock) Now() time.Time                       { return time.Time{} }
+func (tc tickClock) After(time.Duration) <-chan time.Time { return tc.tick }
+
+func TestSyncQueueSnapshotsSlowReader(t *testing.T) {
+	q := NewSyncQueue[int](&ringQueue[int]{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clock := tickClock{tick: make(chan time.Time)}
+	snaps := q.Snapshots(ctx, time.Second, WithClock(clock))
+
+	// The reader doesn't receive while three snapshots are taken. Every tick
+	// is only received after the previous snapshot was sent, so the second
+	// one, [0 1], is sent by the time the third tick is received.
+	for i := range 3 {
+		q.Enqueue(i)
+		clock.tick <- time.Time{}
+	}
+	if s := <-snaps; len(s) < 2 {
+		t.Errorf("slow reader got stale snapshot %v, want at least [0 1]", s)
+	}
+	cancel()
+	for range snaps {
+	}
+}
+
+func TestSyncQueueRemoveWhere(t *testing.T) {
+	q := NewSyncQueue[int](&ringQueue[int]{})
+	const producers, perProducer = 4, 2000
+	odd := func(v int) bool { return v%2 != 0 }
+	var wg sync.WaitGroup
+	for p := range producers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range perProducer {
+				q.Enqueue(p*perProducer + i)
+			}
+		}()
+	}
+	done := make(chan struct{})
+	removed := 0
+	var sweeper sync.WaitGroup
+	sweeper.Add(1)
+	go func() {
+		defer sweeper.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				removed += q.RemoveWhere(odd)
+			}
+		}
+	}()
+	wg.Wait()
+	close(done)
+	sweeper.Wait()
+	removed += q.RemoveWhere(odd)
+
+	if want := producers * perProducer / 2; removed != want {
+		t.Errorf("removed: got %v want %v", removed, want)
+	}
+	got := ToSlice[int](q)
+	if len(got) != q.Len() {
+		t.Errorf("Len: got %v want %v", q.Len(), len(got))
+	}
+	last := make([]int, producers)
+	for i := range last {
+		last[i] = -1
+	}
+	for _, v := range got {
+		if odd(v) {
+			t.Fatalf("odd element %v survived the sweep", v)
+		}
+		p := v / perProducer
+		if v <= last[p] {
+			t.Fatalf("producer %v: %v follows %v", p, v, last[p])
+		}
+		last[p] = v
+	}
+	if want := producers * perProducer / 2; len(got) != want {
+		t.Errorf("remaining: got %v elements want %v", len(got), want)
+	}
+}
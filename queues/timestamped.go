@@ -0,0 +1,74 @@
+package queues
+
+import (
+	"math"
+	"time"
+)
+
+var (
+	_ Queue[int]   = &TimestampedQueue[int]{}
+	_ indexer[int] = &TimestampedQueue[int]{}
+)
+
+type stamped[T any] struct {
+	v  T
+	at time.Time
+}
+
+// TimestampedQueue is a ring backed queue that records when each element was
+// enqueued.
+// ForEach and the helpers built on it, like ToSlice, read the elements in
+// place. Other helpers that rotate the queue, like TrimBack and
+// DequeueMatching, stamp the rotated elements again with the current time.
+type TimestampedQueue[T any] struct {
+	q     ringQueue[stamped[T]]
+	clock Clock
+}
+
+// NewTimestampedQueue returns an empty TimestampedQueue.
+// Use WithClock to control the passing of time.
+func NewTimestampedQueue[T any](opts ...Option) *TimestampedQueue[T] {
+	return &TimestampedQueue[T]{clock: newOptions(opts).clock}
+}
+
+func (tq *TimestampedQueue[T]) Len() int {
+	return tq.q.Len()
+}
+
+func (tq *TimestampedQueue[T]) Dequeue() T {
+	return tq.q.Dequeue().v
+}
+
+func (tq *TimestampedQueue[T]) Ends() (front, back T, ok bool) {
+	f, b, ok := tq.q.Ends()
+	return f.v, b.v, ok
+}
+
+func (tq *TimestampedQueue[T]) Enqueue(v T) {
+	tq.q.Enqueue(stamped[T]{v: v, at: tq.clock.Now()})
+}
+
+func (tq *TimestampedQueue[T]) nth(i int) T {
+	return tq.q.at(i).v
+}
+
+// AgePercentiles returns, for each of the percentiles ps in [0, 100], the age
+// of the queued elements at that percentile using the nearest-rank method.
+// Since elements are stored in insertion order, this does not require sorting.
+// It returns nil if the queue is empty.
+func (tq *TimestampedQueue[T]) AgePercentiles(ps ...float64) []time.Duration {
+	n := tq.q.Len()
+	if n == 0 {
+		return nil
+	}
+	now := tq.clock.Now()
+	ages := make([]time.Duration, len(ps))
+	for i, p := range ps {
+		rank := int(math.Ceil(p / 100 * float64(n)))
+		rank = min(max(rank, 1), n)
+		// The youngest element is at the back, so the rank-th youngest
+		// element is rank positions from the end.
+		ages[i] = now.Sub(tq.q.at(n - rank).at)
+	}
+	return ages
+}
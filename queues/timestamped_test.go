@@ -0,0 +1,52 @@
+package queues
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAgePercentiles(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	q := NewTimestampedQueue[int](WithClock(clock))
+	if got := q.AgePercentiles(50); got != nil {
+		t.Errorf("AgePercentiles on empty queue: got %v want nil", got)
+	}
+	// Wrap the ring to make sure ranks are computed in FIFO order.
+	q.Enqueue(-1)
+	q.Dequeue()
+	for i := range 100 {
+		q.Enqueue(i)
+		clock.Advance(time.Second)
+	}
+	// Ages go from 1s for the last element to 100s for the first.
+	got := q.AgePercentiles(0, 50, 90, 99, 100)
+	want := []time.Duration{1 * time.Second, 50 * time.Second, 90 * time.Second, 99 * time.Second, 100 * time.Second}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("AgePercentiles diff:\n%s", diff)
+	}
+
+	for range 50 {
+		q.Dequeue()
+	}
+	got = q.AgePercentiles(50)
+	want = []time.Duration{25 * time.Second}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("AgePercentiles after dequeue diff:\n%s", diff)
+	}
+}
+
+func TestAgePercentilesAfterForEach(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	q := NewTimestampedQueue[int](WithClock(clock))
+	EnqueueMany[int](q, seq(1, 3))
+	clock.Advance(time.Hour)
+	if diff := cmp.Diff(seq(1, 3), ToSlice[int](q)); diff != "" {
+		t.Errorf("ToSlice diff:\n%s", diff)
+	}
+	// Iterating must keep the original timestamps.
+	if diff := cmp.Diff([]time.Duration{time.Hour}, q.AgePercentiles(100)); diff != "" {
+		t.Errorf("AgePercentiles after ToSlice diff:\n%s", diff)
+	}
+}
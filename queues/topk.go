@@ -0,0 +1,53 @@
+package queues
+
+import (
+	"container/heap"
+	"slices"
+)
+
+// TopKQueue retains the k largest elements it has been given.
+// When full, enqueueing a new element evicts the current minimum.
+type TopKQueue[T any] struct {
+	k int
+	h lessHeap[T]
+}
+
+// NewTopKQueue returns a TopKQueue that retains at most k elements, ordered by less.
+func NewTopKQueue[T any](k int, less func(a, b T) bool) *TopKQueue[T] {
+	return &TopKQueue[T]{
+		k: k,
+		h: lessHeap[T]{s: make([]T, 0, k+1), less: less},
+	}
+}
+
+// Len returns the amount of elements retained.
+func (tq *TopKQueue[T]) Len() int {
+	return tq.h.Len()
+}
+
+// Enqueue adds t, evicting the smallest retained element if there are more than k.
+func (tq *TopKQueue[T]) Enqueue(t T) {
+	if tq.h.Len() == tq.k && tq.k > 0 && !tq.h.less(tq.h.s[0], t) {
+		// Fast path: t would be evicted right away.
+		return
+	}
+	heap.Push(&tq.h, t)
+	if tq.h.Len() > tq.k {
+		heap.Pop(&tq.h)
+	}
+}
+
+// Results returns the retained elements, largest first.
+func (tq *TopKQueue[T]) Results() []T {
+	r := slices.Clone(tq.h.s)
+	slices.SortFunc(r, func(a, b T) int {
+		switch {
+		case tq.h.less(b, a):
+			return -1
+		case tq.h.less(a, b):
+			return 1
+		}
+		return 0
+	})
+	return r
+}
@@ -0,0 +1,70 @@
+package queues
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTopKQueue(t *testing.T) {
+	less := func(a, b int) bool { return a < b }
+	tests := []struct {
+		name  string
+		k     int
+		input []int
+		want  []int
+	}{
+		{
+			name:  "ascending",
+			k:     5,
+			input: seq(1, 100),
+			want:  []int{100, 99, 98, 97, 96},
+		},
+		{
+			name:  "descending",
+			k:     5,
+			input: reversed(seq(1, 100)),
+			want:  []int{100, 99, 98, 97, 96},
+		},
+		{
+			name:  "fewer than k",
+			k:     5,
+			input: []int{3, 1, 2},
+			want:  []int{3, 2, 1},
+		},
+		{
+			name:  "zero k",
+			k:     0,
+			input: []int{3, 1, 2},
+			want:  []int{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := NewTopKQueue(tt.k, less)
+			for _, v := range tt.input {
+				q.Enqueue(v)
+			}
+			got := q.Results()
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("got %v want %v diff:\n%s", got, tt.want, diff)
+			}
+		})
+	}
+}
+
+func seq(from, to int) []int {
+	var s []int
+	for i := from; i <= to; i++ {
+		s = append(s, i)
+	}
+	return s
+}
+
+func reversed(s []int) []int {
+	r := make([]int, 0, len(s))
+	for i := len(s) - 1; i >= 0; i-- {
+		r = append(r, s[i])
+	}
+	return r
+}
@@ -0,0 +1,68 @@
+package queues
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var _ Queue[int] = &TracedQueue[int]{}
+
+// TracedQueue wraps a queue to create a span for every operation.
+// Spans are annotated with the length of the queue after the operation.
+type TracedQueue[T any] struct {
+	inner  Queue[T]
+	tracer trace.Tracer
+}
+
+// NewTracedQueue wraps inner, creating spans with tracer.
+func NewTracedQueue[T any](inner Queue[T], tracer trace.Tracer) *TracedQueue[T] {
+	return &TracedQueue[T]{inner: inner, tracer: tracer}
+}
+
+func (tq *TracedQueue[T]) Len() int {
+	return tq.inner.Len()
+}
+
+// ConcurrencySafe reports whether the wrapped queue is safe for concurrent
+// use, as TracedQueue doesn't add any state of its own.
+func (tq *TracedQueue[T]) ConcurrencySafe() bool {
+	return IsConcurrencySafe(tq.inner)
+}
+
+func (tq *TracedQueue[T]) Ends() (front, back T, ok bool) {
+	return tq.inner.Ends()
+}
+
+func (tq *TracedQueue[T]) Enqueue(v T) {
+	tq.EnqueueCtx(context.Background(), v)
+}
+
+func (tq *TracedQueue[T]) Dequeue() T {
+	return tq.DequeueCtx(context.Background())
+}
+
+// EnqueueCtx is like Enqueue but the span is a child of the one in ctx, if any.
+func (tq *TracedQueue[T]) EnqueueCtx(ctx context.Context, v T) {
+	_, span := tq.tracer.Start(ctx, "queue.enqueue")
+	defer span.End()
+	tq.inner.Enqueue(v)
+	tq.annotate(span)
+}
+
+// DequeueCtx is like Dequeue but the span is a child of the one in ctx, if any.
+func (tq *TracedQueue[T]) DequeueCtx(ctx context.Context) T {
+	_, span := tq.tracer.Start(ctx, "queue.dequeue")
+	defer span.End()
+	v := tq.inner.Dequeue()
+	tq.annotate(span)
+	return v
+}
+
+func (tq *TracedQueue[T]) annotate(span trace.Span) {
+	if !span.IsRecording() {
+		return
+	}
+	span.SetAttributes(attribute.Int("queue.len", tq.inner.Len()))
+}
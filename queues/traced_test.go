@@ -0,0 +1,73 @@
+package queues
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+type recordedSpan struct {
+	name   string
+	parent string
+	attrs  []attribute.KeyValue
+	ended  bool
+}
+
+type recordingTracer struct {
+	noop.Tracer
+	spans []*recordedSpan
+}
+
+type recordingSpan struct {
+	noop.Span
+	rec *recordedSpan
+}
+
+type spanKey struct{}
+
+func (rt *recordingTracer) Start(ctx context.Context, name string, _ ...trace.SpanStartOption) (context.Context, trace.Span) {
+	rs := &recordedSpan{name: name}
+	if p, ok := ctx.Value(spanKey{}).(string); ok {
+		rs.parent = p
+	}
+	rt.spans = append(rt.spans, rs)
+	return context.WithValue(ctx, spanKey{}, name), recordingSpan{rec: rs}
+}
+
+func (rs recordingSpan) IsRecording() bool { return true }
+func (rs recordingSpan) End(...trace.SpanEndOption) {
+	rs.rec.ended = true
+}
+func (rs recordingSpan) SetAttributes(kv ...attribute.KeyValue) {
+	rs.rec.attrs = append(rs.rec.attrs, kv...)
+}
+
+func TestTracedQueue(t *testing.T) {
+	tracer := &recordingTracer{}
+	q := NewTracedQueue[int](&ringQueue[int]{}, tracer)
+	ctx := context.WithValue(context.Background(), spanKey{}, "parent")
+	q.EnqueueCtx(ctx, 1)
+	q.Enqueue(2)
+	q.DequeueCtx(ctx)
+
+	want := []*recordedSpan{
+		{name: "queue.enqueue", parent: "parent", attrs: []attribute.KeyValue{attribute.Int("queue.len", 1)}, ended: true},
+		{name: "queue.enqueue", attrs: []attribute.KeyValue{attribute.Int("queue.len", 2)}, ended: true},
+		{name: "queue.dequeue", parent: "parent", attrs: []attribute.KeyValue{attribute.Int("queue.len", 1)}, ended: true},
+	}
+	if diff := cmp.Diff(want, tracer.spans, cmp.AllowUnexported(recordedSpan{}, attribute.Value{})); diff != "" {
+		t.Errorf("spans diff:\n%s", diff)
+	}
+}
+
+func TestTracedQueueNotRecording(t *testing.T) {
+	q := NewTracedQueue[int](&ringQueue[int]{}, noop.NewTracerProvider().Tracer(""))
+	q.Enqueue(1)
+	if got := q.Dequeue(); got != 1 {
+		t.Errorf("Dequeue: got %v want 1", got)
+	}
+}
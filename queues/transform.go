@@ -0,0 +1,56 @@
+package queues
+
+var (
+	_ Queue[int]     = &transformingQueue[int]{}
+	_ unwrapper[int] = &transformingQueue[int]{}
+)
+
+type transformingQueue[T any] struct {
+	inner     Queue[T]
+	transform func(T) (T, error)
+}
+
+// NewTransformingQueue returns a queue that passes every enqueued element
+// through transform and stores the result in inner, which must not be used
+// directly afterwards. Elements for which transform returns an error are
+// discarded.
+// The returned Queue has a TryEnqueue(T) bool method that reports whether the
+// element was stored, so it can be used with DrainTo.
+// Helpers like ForEach and ToSlice rotate inner directly, so stored elements
+// are never transformed again.
+func NewTransformingQueue[T any](inner Queue[T], transform func(T) (T, error)) Queue[T] {
+	return &transformingQueue[T]{inner: inner, transform: transform}
+}
+
+func (tq *transformingQueue[T]) Len() int {
+	return tq.inner.Len()
+}
+
+func (tq *transformingQueue[T]) Ends() (front, back T, ok bool) {
+	return tq.inner.Ends()
+}
+
+func (tq *transformingQueue[T]) Dequeue() T {
+	return tq.inner.Dequeue()
+}
+
+func (tq *transformingQueue[T]) unwrap() Queue[T] {
+	return tq.inner
+}
+
+// Enqueue adds the transformed v at the end of the queue, or discards it if
+// the transformation fails.
+func (tq *transformingQueue[T]) Enqueue(v T) {
+	tq.TryEnqueue(v)
+}
+
+// TryEnqueue adds the transformed v at the end of the queue, reporting false
+// if the transformation failed.
+func (tq *transformingQueue[T]) TryEnqueue(v T) bool {
+	t, err := tq.transform(v)
+	if err != nil {
+		return false
+	}
+	tq.inner.Enqueue(t)
+	return true
+}
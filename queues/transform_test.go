@@ -0,0 +1,75 @@
+package queues
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTransformingQueue(t *testing.T) {
+	errNegative := errors.New("negative")
+	// clamp caps values at 10 and rejects negative ones.
+	clamp := func(v int) (int, error) {
+		if v < 0 {
+			return 0, errNegative
+		}
+		return min(v, 10), nil
+	}
+	for _, i := range impls {
+		t.Run(i.name, func(t *testing.T) {
+			q := NewTransformingQueue(i.ctor(), clamp)
+			for _, v := range []int{3, -1, 42, 10, -7, 0} {
+				q.Enqueue(v)
+			}
+			checkEnds(t, q, 3, 0, true)
+			try := q.(interface{ TryEnqueue(int) bool })
+			if !try.TryEnqueue(11) {
+				t.Errorf("TryEnqueue(11): got false want true")
+			}
+			if try.TryEnqueue(-2) {
+				t.Errorf("TryEnqueue(-2): got true want false")
+			}
+			var got []int
+			for q.Len() > 0 {
+				got = append(got, q.Dequeue())
+			}
+			if diff := cmp.Diff([]int{3, 10, 10, 0, 10}, got); diff != "" {
+				t.Errorf("dequeued diff:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTransformingQueueRotation(t *testing.T) {
+	// double isn't idempotent, so transforming stored elements again would
+	// change them.
+	double := func(v int) (int, error) { return v * 2, nil }
+	q := NewTransformingQueue[int](&ringQueue[int]{}, double)
+	EnqueueMany(q, seq(1, 3))
+	for range 2 {
+		if diff := cmp.Diff([]int{2, 4, 6}, ToSlice(q)); diff != "" {
+			t.Errorf("ToSlice diff:\n%s", diff)
+		}
+	}
+	TrimBack(q, 2)
+	ReplaceFront(q, 10)
+	if diff := cmp.Diff([]int{10, 4}, ToSlice(q)); diff != "" {
+		t.Errorf("contents after TrimBack and ReplaceFront diff:\n%s", diff)
+	}
+
+	// A transform that rejects everything must not drop stored elements.
+	reject := true
+	q = NewTransformingQueue[int](&ringQueue[int]{}, func(v int) (int, error) {
+		if reject {
+			return 0, errors.New("rejected")
+		}
+		return v, nil
+	})
+	reject = false
+	EnqueueMany(q, seq(1, 3))
+	reject = true
+	if diff := cmp.Diff(seq(1, 3), ToSlice(q)); diff != "" {
+		t.Errorf("ToSlice with a rejecting transform diff:\n%s", diff)
+	}
+}
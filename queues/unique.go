@@ -0,0 +1,120 @@
+package queues
+
+import "github.com/empijei/gotests-public/lookup"
+
+var _ Queue[int] = &UniqueQueue[int]{}
+
+// UniqueQueue wraps a queue so that it never holds the same value twice.
+// A value counts as new again once it has been dequeued.
+// The wrapped queue must not be used directly while the UniqueQueue is in use.
+type UniqueQueue[T comparable] struct {
+	inner Queue[T]
+	// queued holds the values currently in inner.
+	queued lookup.OrderedSet[T]
+}
+
+// NewUniqueQueue wraps inner, which must be empty.
+func NewUniqueQueue[T comparable](inner Queue[T]) *UniqueQueue[T] {
+	return &UniqueQueue[T]{inner: inner}
+}
+
+func (uq *UniqueQueue[T]) Len() int {
+	return uq.inner.Len()
+}
+
+func (uq *UniqueQueue[T]) Ends() (front, back T, ok bool) {
+	return uq.inner.Ends()
+}
+
+func (uq *UniqueQueue[T]) Dequeue() T {
+	v := uq.inner.Dequeue()
+	uq.queued.Remove(v)
+	return v
+}
+
+// Enqueue adds v at the end of the queue, unless it is already present.
+func (uq *UniqueQueue[T]) Enqueue(v T) {
+	uq.EnqueueUnique(v)
+}
+
+// EnqueueUnique adds v at the end of the queue and reports true, or reports
+// false without enqueueing if v is already present.
+func (uq *UniqueQueue[T]) EnqueueUnique(v T) bool {
+	if !uq.queued.Add(v) {
+		return false
+	}
+	uq.inner.Enqueue(v)
+	return true
+}
+
+var (
+	_ Queue[int]     = &RecentDedupQueue[int]{}
+	_ unwrapper[int] = &RecentDedupQueue[int]{}
+)
+
+// RecentDedupQueue wraps a queue to drop values that were enqueued within the
+// last few enqueues. Unlike UniqueQueue it only remembers a bounded window of
+// values, which are forgotten as newer ones are enqueued regardless of
+// whether they have been dequeued.
+// The wrapped queue must not be used directly while the RecentDedupQueue is
+// in use. Helpers like ForEach and ToSlice rotate the wrapped queue, so they
+// don't affect the history.
+type RecentDedupQueue[T comparable] struct {
+	inner Queue[T]
+	size  int
+	// history holds the last size enqueued values, and seen counts how many
+	// times each of them appears in it.
+	history ringQueue[T]
+	seen    map[T]int
+}
+
+// NewRecentDedupQueue wraps inner so that values already among the last
+// historySize enqueued ones are dropped.
+func NewRecentDedupQueue[T comparable](inner Queue[T], historySize int) *RecentDedupQueue[T] {
+	return &RecentDedupQueue[T]{
+		inner: inner,
+		size:  max(historySize, 1),
+		seen:  make(map[T]int),
+	}
+}
+
+func (rq *RecentDedupQueue[T]) Len() int {
+	return rq.inner.Len()
+}
+
+func (rq *RecentDedupQueue[T]) Ends() (front, back T, ok bool) {
+	return rq.inner.Ends()
+}
+
+func (rq *RecentDedupQueue[T]) Dequeue() T {
+	return rq.inner.Dequeue()
+}
+
+func (rq *RecentDedupQueue[T]) unwrap() Queue[T] {
+	return rq.inner
+}
+
+// Enqueue adds v at the end of the queue, unless it is in the recent history.
+func (rq *RecentDedupQueue[T]) Enqueue(v T) {
+	rq.EnqueueUnique(v)
+}
+
+// EnqueueUnique adds v at the end of the queue and reports true, or reports
+// false without enqueueing if v is in the recent history.
+// Dropped values don't enter the history, so they don't extend the time a
+// value is remembered for.
+func (rq *RecentDedupQueue[T]) EnqueueUnique(v T) bool {
+	if rq.seen[v] > 0 {
+		return false
+	}
+	if rq.history.Len() == rq.size {
+		old := rq.history.Dequeue()
+		if rq.seen[old]--; rq.seen[old] == 0 {
+			delete(rq.seen, old)
+		}
+	}
+	rq.history.Enqueue(v)
+	rq.seen[v]++
+	rq.inner.Enqueue(v)
+	return true
+}
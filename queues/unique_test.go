@@ -0,0 +1,108 @@
+package queues
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUniqueQueue(t *testing.T) {
+	q := NewUniqueQueue[string](&ringQueue[string]{})
+	steps := []struct {
+		v    string
+		want bool
+	}{
+		{"a", true},
+		{"b", true},
+		{"a", false},
+		{"c", true},
+		{"b", false},
+	}
+	for _, s := range steps {
+		if got := q.EnqueueUnique(s.v); got != s.want {
+			t.Errorf("EnqueueUnique(%q): got %v want %v", s.v, got, s.want)
+		}
+	}
+	if got, want := q.Len(), 3; got != want {
+		t.Fatalf("Len: got %v want %v", got, want)
+	}
+	if got := q.Dequeue(); got != "a" {
+		t.Errorf("Dequeue: got %q want %q", got, "a")
+	}
+	// Dequeued values count as new.
+	if !q.EnqueueUnique("a") {
+		t.Errorf(`EnqueueUnique("a") after Dequeue: got false want true`)
+	}
+	if q.EnqueueUnique("c") {
+		t.Errorf(`EnqueueUnique("c"): got true want false`)
+	}
+	q.Enqueue("b")
+	var got []string
+	for q.Len() > 0 {
+		got = append(got, q.Dequeue())
+	}
+	if diff := cmp.Diff([]string{"b", "c", "a"}, got); diff != "" {
+		t.Errorf("got %v diff:\n%s", got, diff)
+	}
+	for _, v := range got {
+		if !q.EnqueueUnique(v) {
+			t.Errorf("EnqueueUnique(%q) on drained queue: got false want true", v)
+		}
+	}
+}
+
+func TestRecentDedupQueue(t *testing.T) {
+	tests := []struct {
+		name    string
+		history int
+		in      []int
+		want    []int
+	}{
+		{"no repeats", 2, []int{1, 2, 3}, []int{1, 2, 3}},
+		{"repeats within window", 3, []int{1, 2, 1, 3, 2}, []int{1, 2, 3}},
+		{"repeats outside window", 2, []int{1, 2, 3, 1, 2, 3}, []int{1, 2, 3, 1, 2, 3}},
+		{"repeating pattern", 2, []int{1, 2, 1, 2, 3, 1, 3, 2}, []int{1, 2, 3, 1, 2}},
+		{"single slot", 1, []int{1, 1, 2, 2, 1}, []int{1, 2, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := NewRecentDedupQueue[int](&ringQueue[int]{}, tt.history)
+			for _, v := range tt.in {
+				q.Enqueue(v)
+			}
+			if diff := cmp.Diff(tt.want, DrainToSlice[int](q)); diff != "" {
+				t.Errorf("diff:\n%s", diff)
+			}
+		})
+	}
+
+	// Dequeueing doesn't forget values.
+	q := NewRecentDedupQueue[int](&ringQueue[int]{}, 2)
+	q.Enqueue(1)
+	q.Dequeue()
+	if q.EnqueueUnique(1) {
+		t.Errorf("EnqueueUnique(1) after Dequeue: got true want false")
+	}
+}
+
+func TestRecentDedupQueueRotation(t *testing.T) {
+	q := NewRecentDedupQueue[int](&ringQueue[int]{}, 4)
+	EnqueueMany[int](q, []int{1, 2, 3})
+	// Rotating helpers must not drop the elements as duplicates.
+	if diff := cmp.Diff([]int{1, 2, 3}, ToSlice[int](q)); diff != "" {
+		t.Errorf("ToSlice diff:\n%s", diff)
+	}
+	if got := q.Len(); got != 3 {
+		t.Errorf("Len after ToSlice: got %v want 3", got)
+	}
+	if v, ok := DequeueMatching[int](q, func(v int) bool { return v == 2 }); !ok || v != 2 {
+		t.Errorf("DequeueMatching: got (%v, %v) want (2, true)", v, ok)
+	}
+	if diff := cmp.Diff([]int{1, 3}, ToSlice[int](q)); diff != "" {
+		t.Errorf("ToSlice after DequeueMatching diff:\n%s", diff)
+	}
+	// Rotations don't enter the history either.
+	if q.EnqueueUnique(3) {
+		t.Errorf("EnqueueUnique(3): got true want false")
+	}
+}
@@ -0,0 +1,59 @@
+package queues
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// versionHeader is the size of the version that starts versioned data.
+const versionHeader = 2
+
+// MarshalVersioned encodes the elements of q with enc, in FIFO order, after a
+// header holding version. q is left unchanged.
+// Elements are stored as length-prefixed records, like in file queues, so
+// their encoding can change freely between versions.
+func MarshalVersioned[T any](q Queue[T], version uint16, enc func(T) []byte) []byte {
+	b := binary.BigEndian.AppendUint16(nil, version)
+	ForEach(q, func(v T) bool {
+		rec := enc(v)
+		b = binary.BigEndian.AppendUint32(b, uint32(len(rec)))
+		b = append(b, rec...)
+		return true
+	})
+	return b
+}
+
+// UnmarshalVersioned decodes data encoded by MarshalVersioned into a new
+// queue, using the decoder in dec for the version data was encoded with.
+// Keeping the decoders of older versions in dec allows to read data that was
+// written by older binaries.
+// It returns an error if there is no decoder for the version, if data is
+// truncated, or if the decoder fails on any element.
+func UnmarshalVersioned[T any](data []byte, dec map[uint16]func([]byte) (T, error)) (Queue[T], error) {
+	if len(data) < versionHeader {
+		return nil, fmt.Errorf("versioned data is %d bytes long, too short for the header", len(data))
+	}
+	version := binary.BigEndian.Uint16(data)
+	decode, ok := dec[version]
+	if !ok {
+		return nil, fmt.Errorf("no decoder for version %d", version)
+	}
+	q := &ringQueue[T]{}
+	for off := versionHeader; off < len(data); {
+		if len(data)-off < recordHeader {
+			return nil, fmt.Errorf("truncated record header at offset %d", off)
+		}
+		n := int(binary.BigEndian.Uint32(data[off:]))
+		off += recordHeader
+		if len(data)-off < n {
+			return nil, fmt.Errorf("truncated record at offset %d: want %d bytes, have %d", off, n, len(data)-off)
+		}
+		v, err := decode(data[off : off+n])
+		if err != nil {
+			return nil, fmt.Errorf("decoding element %d with version %d: %w", q.Len(), version, err)
+		}
+		q.Enqueue(v)
+		off += n
+	}
+	return q, nil
+}
@@ -0,0 +1,115 @@
+package queues
+
+import (
+	"encoding/binary"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type versionedPoint struct {
+	X, Y int
+	// Label was added in v2.
+	Label string
+}
+
+// encodeV1 writes the coordinates as two big endian uint32.
+func encodeV1(p versionedPoint) []byte {
+	b := binary.BigEndian.AppendUint32(nil, uint32(p.X))
+	return binary.BigEndian.AppendUint32(b, uint32(p.Y))
+}
+
+func decodeV1(b []byte) (versionedPoint, error) {
+	if len(b) != 8 {
+		return versionedPoint{}, errors.New("v1 record must be 8 bytes long")
+	}
+	return versionedPoint{X: int(binary.BigEndian.Uint32(b)), Y: int(binary.BigEndian.Uint32(b[4:]))}, nil
+}
+
+// encodeV2 writes "x,y,label".
+func encodeV2(p versionedPoint) []byte {
+	return []byte(strconv.Itoa(p.X) + "," + strconv.Itoa(p.Y) + "," + p.Label)
+}
+
+func decodeV2(b []byte) (versionedPoint, error) {
+	fields := strings.SplitN(string(b), ",", 3)
+	if len(fields) != 3 {
+		return versionedPoint{}, errors.New("v2 record must have 3 fields")
+	}
+	x, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return versionedPoint{}, err
+	}
+	y, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return versionedPoint{}, err
+	}
+	return versionedPoint{X: x, Y: y, Label: fields[2]}, nil
+}
+
+func TestVersioned(t *testing.T) {
+	q := &ringQueue[versionedPoint]{}
+	// Labels are lost in v1, so the v1 data only needs zero labels to match.
+	EnqueueMany(q, []versionedPoint{{1, 2, ""}, {3, 4, ""}, {5, 6, ""}})
+	decoders := map[uint16]func([]byte) (versionedPoint, error){
+		1: decodeV1,
+		2: decodeV2,
+	}
+	want := ToSlice(q)
+
+	v1 := MarshalVersioned(q, 1, encodeV1)
+	if diff := cmp.Diff(want, ToSlice(q)); diff != "" {
+		t.Errorf("MarshalVersioned changed the queue:\n%s", diff)
+	}
+	got, err := UnmarshalVersioned(v1, decoders)
+	if err != nil {
+		t.Fatalf("UnmarshalVersioned of v1 data: %v", err)
+	}
+	if diff := cmp.Diff(want, ToSlice(got)); diff != "" {
+		t.Errorf("v1 round trip diff:\n%s", diff)
+	}
+
+	// Re-encode with the new version, adding the labels v1 couldn't store.
+	ReplaceBack(got, versionedPoint{5, 6, "last"})
+	want = ToSlice(got)
+	v2 := MarshalVersioned(got, 2, encodeV2)
+	got, err = UnmarshalVersioned(v2, decoders)
+	if err != nil {
+		t.Fatalf("UnmarshalVersioned of v2 data: %v", err)
+	}
+	if diff := cmp.Diff(want, ToSlice(got)); diff != "" {
+		t.Errorf("v2 round trip diff:\n%s", diff)
+	}
+
+	empty, err := UnmarshalVersioned(MarshalVersioned(&ringQueue[versionedPoint]{}, 2, encodeV2), decoders)
+	if err != nil || empty.Len() != 0 {
+		t.Errorf("round trip of empty queue: got (Len %v, %v) want (Len 0, nil)", empty.Len(), err)
+	}
+}
+
+func TestUnmarshalVersionedErrors(t *testing.T) {
+	q := &ringQueue[versionedPoint]{}
+	EnqueueMany(q, []versionedPoint{{1, 2, ""}, {3, 4, ""}})
+	v1 := MarshalVersioned(q, 1, encodeV1)
+	tests := []struct {
+		name string
+		data []byte
+		dec  map[uint16]func([]byte) (versionedPoint, error)
+	}{
+		{"no header", v1[:1], map[uint16]func([]byte) (versionedPoint, error){1: decodeV1}},
+		{"unknown version", v1, map[uint16]func([]byte) (versionedPoint, error){2: decodeV2}},
+		{"truncated header", v1[:len(v1)-10], map[uint16]func([]byte) (versionedPoint, error){1: decodeV1}},
+		{"truncated record", v1[:len(v1)-1], map[uint16]func([]byte) (versionedPoint, error){1: decodeV1}},
+		{"decoder error", v1, map[uint16]func([]byte) (versionedPoint, error){1: decodeV2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := UnmarshalVersioned(tt.data, tt.dec); err == nil {
+				t.Errorf("UnmarshalVersioned: got nil error")
+			}
+		})
+	}
+}
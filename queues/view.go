@@ -0,0 +1,63 @@
+package queues
+
+var _ Queue[int] = &QueueView[int]{}
+
+// QueueView wraps a queue to make repeated calls to Peek O(1), even for
+// implementations that can only peek by consuming the front element.
+// The peeked element is held by the view, so the wrapped queue must not be
+// used directly while the view is in use.
+type QueueView[T any] struct {
+	inner  Queue[T]
+	front  T
+	cached bool
+}
+
+// NewQueueView returns a view over inner.
+func NewQueueView[T any](inner Queue[T]) *QueueView[T] {
+	return &QueueView[T]{inner: inner}
+}
+
+func (qv *QueueView[T]) Len() int {
+	if qv.cached {
+		return qv.inner.Len() + 1
+	}
+	return qv.inner.Len()
+}
+
+// Peek returns the first element without removing it, or false if the queue is empty.
+func (qv *QueueView[T]) Peek() (t T, ok bool) {
+	if !qv.cached {
+		if qv.inner.Len() == 0 {
+			return t, false
+		}
+		qv.front = qv.inner.Dequeue()
+		qv.cached = true
+	}
+	return qv.front, true
+}
+
+func (qv *QueueView[T]) Ends() (front, back T, ok bool) {
+	if !qv.cached {
+		return qv.inner.Ends()
+	}
+	if _, back, ok = qv.inner.Ends(); !ok {
+		back = qv.front
+	}
+	return qv.front, back, true
+}
+
+func (qv *QueueView[T]) Dequeue() T {
+	if !qv.cached {
+		return qv.inner.Dequeue()
+	}
+	var zero T
+	v := qv.front
+	qv.front = zero
+	qv.cached = false
+	return v
+}
+
+func (qv *QueueView[T]) Enqueue(v T) {
+	// Enqueues happen at the back, so the cached front is still valid.
+	qv.inner.Enqueue(v)
+}
@@ -0,0 +1,78 @@
+package queues
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestQueueView(t *testing.T) {
+	for _, i := range impls {
+		t.Run(i.name, func(t *testing.T) {
+			qv := NewQueueView(i.ctor())
+			if _, ok := qv.Peek(); ok {
+				t.Fatalf("Peek on empty queue: got ok")
+			}
+			qv.Enqueue(1)
+			qv.Peek()
+			// The only element is cached by the view.
+			checkEnds(t, qv, 1, 1, true)
+			qv.Enqueue(2)
+			for range 3 {
+				if got, ok := qv.Peek(); !ok || got != 1 {
+					t.Fatalf("Peek: got (%v, %v) want (1, true)", got, ok)
+				}
+			}
+			if got, want := qv.Len(), 2; got != want {
+				t.Errorf("Len: got %v want %v", got, want)
+			}
+			checkEnds(t, qv, 1, 2, true)
+			qv.Enqueue(3)
+			if got, ok := qv.Peek(); !ok || got != 1 {
+				t.Errorf("Peek after Enqueue: got (%v, %v) want (1, true)", got, ok)
+			}
+			if got := qv.Dequeue(); got != 1 {
+				t.Errorf("Dequeue: got %v want 1", got)
+			}
+			if got, ok := qv.Peek(); !ok || got != 2 {
+				t.Errorf("Peek after Dequeue: got (%v, %v) want (2, true)", got, ok)
+			}
+			checkEnds(t, qv, 2, 3, true)
+			var got []int
+			for qv.Len() > 0 {
+				got = append(got, qv.Dequeue())
+			}
+			if diff := cmp.Diff([]int{2, 3}, got); diff != "" {
+				t.Errorf("got %v diff:\n%s", got, diff)
+			}
+		})
+	}
+}
+
+func BenchmarkPeek(b *testing.B) {
+	const size = 1000
+	b.Run("rotate", func(b *testing.B) {
+		q := newChanQueue[int]()
+		for v := range size {
+			q.Enqueue(v)
+		}
+		b.ReportAllocs()
+		b.ResetTimer()
+		for range b.N {
+			// Peeking without a view requires consuming and putting back.
+			v := q.Dequeue()
+			enqueueFront[int](q, v)
+		}
+	})
+	b.Run("view", func(b *testing.B) {
+		q := NewQueueView[int](newChanQueue[int]())
+		for v := range size {
+			q.Enqueue(v)
+		}
+		b.ReportAllocs()
+		b.ResetTimer()
+		for range b.N {
+			q.Peek()
+		}
+	})
+}
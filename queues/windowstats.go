@@ -0,0 +1,119 @@
+package queues
+
+import (
+	"cmp"
+	"math"
+	"slices"
+)
+
+var _ Queue[int] = &WindowStats[int]{}
+
+// WindowStats is a queue of samples holding at most a fixed amount of them,
+// the oldest being evicted when it is full, that can report quantiles of the
+// current window.
+// Alongside the ring of samples it keeps a sorted copy of them: Quantile is
+// O(1), while Enqueue and Dequeue are O(window) because they shift the sorted
+// copy to insert and remove samples.
+type WindowStats[T cmp.Ordered] struct {
+	ring   *OverwriteRing[T]
+	sorted []T
+}
+
+// NewWindowStats returns an empty WindowStats holding at most windowSize samples.
+func NewWindowStats[T cmp.Ordered](windowSize int) *WindowStats[T] {
+	return &WindowStats[T]{
+		ring:   NewOverwriteRing[T](windowSize),
+		sorted: make([]T, 0, windowSize),
+	}
+}
+
+func (ws *WindowStats[T]) Len() int {
+	return ws.ring.Len()
+}
+
+func (ws *WindowStats[T]) Ends() (front, back T, ok bool) {
+	return ws.ring.Ends()
+}
+
+func (ws *WindowStats[T]) remove(v T) {
+	i, _ := slices.BinarySearch(ws.sorted, v)
+	ws.sorted = slices.Delete(ws.sorted, i, i+1)
+}
+
+// Dequeue removes and returns the oldest sample.
+func (ws *WindowStats[T]) Dequeue() T {
+	v := ws.ring.Dequeue()
+	ws.remove(v)
+	return v
+}
+
+// Enqueue adds v to the window, evicting the oldest sample if it is full.
+func (ws *WindowStats[T]) Enqueue(v T) {
+	if ws.ring.Len() == ws.ring.Cap() {
+		ws.Dequeue()
+	}
+	ws.ring.Enqueue(v)
+	i, _ := slices.BinarySearch(ws.sorted, v)
+	ws.sorted = slices.Insert(ws.sorted, i, v)
+}
+
+// Quantile returns the sample at quantile p, in [0, 1], of the current window
+// using the nearest-rank method. It panics if the window is empty.
+func (ws *WindowStats[T]) Quantile(p float64) T {
+	n := len(ws.sorted)
+	if n == 0 {
+		panic("quantile of empty window")
+	}
+	rank := int(math.Ceil(p * float64(n)))
+	return ws.sorted[min(max(rank, 1), n)-1]
+}
+
+var _ Queue[float64] = &MovingAverage{}
+
+// MovingAverage is a queue of samples holding at most a fixed amount of them,
+// the oldest being evicted when it is full, that keeps a running sum so that
+// Average is O(1).
+// The running sum accumulates rounding errors as samples come and go, which
+// are negligible unless samples span many orders of magnitude.
+type MovingAverage struct {
+	ring *OverwriteRing[float64]
+	sum  float64
+}
+
+// NewMovingAverage returns an empty MovingAverage holding at most window
+// samples.
+func NewMovingAverage(window int) *MovingAverage {
+	return &MovingAverage{ring: NewOverwriteRing[float64](window)}
+}
+
+func (ma *MovingAverage) Len() int {
+	return ma.ring.Len()
+}
+
+func (ma *MovingAverage) Ends() (front, back float64, ok bool) {
+	return ma.ring.Ends()
+}
+
+// Dequeue removes and returns the oldest sample.
+func (ma *MovingAverage) Dequeue() float64 {
+	v := ma.ring.Dequeue()
+	ma.sum -= v
+	return v
+}
+
+// Enqueue adds v to the window, evicting the oldest sample if it is full.
+func (ma *MovingAverage) Enqueue(v float64) {
+	if evicted, had := ma.ring.EnqueuePop(v); had {
+		ma.sum -= evicted
+	}
+	ma.sum += v
+}
+
+// Average returns the mean of the samples in the window, or NaN if it is
+// empty.
+func (ma *MovingAverage) Average() float64 {
+	if ma.ring.Len() == 0 {
+		return math.NaN()
+	}
+	return ma.sum / float64(ma.ring.Len())
+}
@@ -0,0 +1,78 @@
+package queues
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWindowStats(t *testing.T) {
+	ws := NewWindowStats[int](5)
+	quantiles := func() []int {
+		var r []int
+		for _, p := range []float64{0, 0.5, 0.8, 1} {
+			r = append(r, ws.Quantile(p))
+		}
+		return r
+	}
+	for _, v := range []int{50, 10, 40, 20, 30} {
+		ws.Enqueue(v)
+	}
+	if diff := cmp.Diff([]int{10, 30, 40, 50}, quantiles()); diff != "" {
+		t.Errorf("full window quantiles diff:\n%s", diff)
+	}
+	// Evicts 50 and 10.
+	ws.Enqueue(5)
+	ws.Enqueue(35)
+	if diff := cmp.Diff([]int{5, 30, 35, 40}, quantiles()); diff != "" {
+		t.Errorf("quantiles after eviction diff:\n%s", diff)
+	}
+	if diff := cmp.Diff([]int{40, 20, 30, 5, 35}, ToSlice[int](ws)); diff != "" {
+		t.Errorf("window diff:\n%s", diff)
+	}
+	if got := ws.Dequeue(); got != 40 {
+		t.Errorf("Dequeue: got %v want 40", got)
+	}
+	if got := ws.Quantile(1); got != 35 {
+		t.Errorf("max after Dequeue: got %v want 35", got)
+	}
+}
+
+func TestMovingAverage(t *testing.T) {
+	const window = 5
+	ma := NewMovingAverage(window)
+	if got := ma.Average(); !math.IsNaN(got) {
+		t.Errorf("Average of empty window: got %v want NaN", got)
+	}
+	// bruteForce recomputes the average of the window from scratch.
+	bruteForce := func() float64 {
+		var sum float64
+		s := ToSlice[float64](ma)
+		for _, v := range s {
+			sum += v
+		}
+		return sum / float64(len(s))
+	}
+	r := rand.New(rand.NewSource(1))
+	for i := range 50 {
+		ma.Enqueue(r.Float64()*200 - 100)
+		if got, want := ma.Len(), min(i+1, window); got != want {
+			t.Fatalf("Len after %v samples: got %v want %v", i+1, got, want)
+		}
+		if got, want := ma.Average(), bruteForce(); math.Abs(got-want) > 1e-9 {
+			t.Errorf("Average after %v samples: got %v want %v", i+1, got, want)
+		}
+	}
+	for ma.Len() > 1 {
+		ma.Dequeue()
+		if got, want := ma.Average(), bruteForce(); math.Abs(got-want) > 1e-9 {
+			t.Errorf("Average after Dequeue: got %v want %v", got, want)
+		}
+	}
+	ma.Dequeue()
+	if got := ma.Average(); !math.IsNaN(got) {
+		t.Errorf("Average after draining: got %v want NaN", got)
+	}
+}